@@ -0,0 +1,166 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package admin serves internal states of a running core.Consensus instance
+// as JSON over HTTP, it's meant for debugging stalled nodes and should not be
+// exposed to public networks.
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core"
+)
+
+// StatusGetter is the interface to get snapshots of internal states, which is
+// implemented by core.Consensus.
+type StatusGetter interface {
+	Status() *core.Status
+}
+
+// Health is the response of the health endpoint.
+type Health struct {
+	Healthy bool `json:"healthy"`
+	// Elapsed is the time since the last delivered block, or since dMoment
+	// when nothing is delivered yet.
+	Elapsed           time.Duration `json:"elapsed"`
+	LastDelivered     string        `json:"last_delivered"`
+	LastDeliveredTime time.Time     `json:"last_delivered_time"`
+}
+
+// Server serves the admin API, the endpoints are:
+//   - /status: everything below.
+//   - /agreement: the running BA.
+//   - /blockchain: blocks confirmed but not delivered yet.
+//   - /dkg: the latest DKG protocol.
+//   - /tsig: the running TSig protocols.
+//   - /health: 200 when blocks are delivered in time, 503 otherwise.
+type Server struct {
+	getter              StatusGetter
+	maxDeliveryInterval time.Duration
+	mux                 *http.ServeMux
+	server              *http.Server
+	logger              common.Logger
+}
+
+// NewServer constructs a Server instance. The node is considered unhealthy
+// when no block is delivered within maxDeliveryInterval.
+func NewServer(getter StatusGetter, maxDeliveryInterval time.Duration,
+	logger common.Logger) *Server {
+	s := &Server{
+		getter:              getter,
+		maxDeliveryInterval: maxDeliveryInterval,
+		mux:                 http.NewServeMux(),
+		logger:              logger,
+	}
+	s.mux.HandleFunc("/status", s.handle(
+		func(st *core.Status) interface{} { return st }))
+	s.mux.HandleFunc("/agreement", s.handle(
+		func(st *core.Status) interface{} { return st.Agreement }))
+	s.mux.HandleFunc("/blockchain", s.handle(
+		func(st *core.Status) interface{} { return st.BlockChain }))
+	s.mux.HandleFunc("/dkg", s.handle(
+		func(st *core.Status) interface{} { return st.DKG }))
+	s.mux.HandleFunc("/tsig", s.handle(
+		func(st *core.Status) interface{} { return st.TSigs }))
+	s.mux.HandleFunc("/health", s.handleHealth)
+	s.server = &http.Server{Handler: s.mux}
+	return s
+}
+
+// ServeHTTP implements http.Handler, which allows the admin API to be mounted
+// on an existing HTTP server.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe listens on the TCP address and serves the admin API until
+// Close is called.
+func (s *Server) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ln)
+}
+
+// Serve serves the admin API on the listener until Close is called.
+func (s *Server) Serve(ln net.Listener) error {
+	s.logger.Info("Admin server started", "address", ln.Addr())
+	err := s.server.Serve(ln)
+	if err == http.ErrServerClosed {
+		err = nil
+	}
+	return err
+}
+
+// Close stops the server gracefully.
+func (s *Server) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
+
+// Health checks the delivery progress.
+func (s *Server) Health() *Health {
+	st := s.getter.Status()
+	h := &Health{LastDeliveredTime: st.LastDeliveredTime}
+	if st.BlockChain != nil {
+		h.LastDelivered = st.BlockChain.LastDelivered.String()
+	}
+	if st.Now.Before(st.DMoment) {
+		// Consensus is not started yet.
+		h.Healthy = true
+		return h
+	}
+	since := st.DMoment
+	if !st.LastDeliveredTime.IsZero() {
+		since = st.LastDeliveredTime
+	}
+	h.Elapsed = st.Now.Sub(since)
+	h.Healthy = h.Elapsed <= s.maxDeliveryInterval
+	return h
+}
+
+func (s *Server) handle(
+	pick func(*core.Status) interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.writeJSON(w, http.StatusOK, pick(s.getter.Status()))
+	}
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	h := s.Health()
+	code := http.StatusOK
+	if !h.Healthy {
+		code = http.StatusServiceUnavailable
+	}
+	s.writeJSON(w, code, h)
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.logger.Error("Failed to write admin response", "error", err)
+	}
+}
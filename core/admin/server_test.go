@@ -0,0 +1,130 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package admin
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type fakeGetter struct {
+	status core.Status
+}
+
+func (g *fakeGetter) Status() *core.Status {
+	s := g.status
+	return &s
+}
+
+type ServerTestSuite struct {
+	suite.Suite
+}
+
+func (s *ServerTestSuite) newStatus() core.Status {
+	now := time.Now().UTC()
+	return core.Status{
+		ID:      types.NodeID{Hash: common.NewRandomHash()},
+		Now:     now,
+		DMoment: now.Add(-time.Minute),
+		Agreement: &core.AgreementStatus{
+			Position: types.Position{Round: 1, Height: 10},
+			State:    "PreCommit",
+			Period:   3,
+		},
+		BlockChain: &core.BlockChainStatus{
+			LastDelivered: types.Position{Round: 1, Height: 9},
+		},
+		DKG: &core.DKGStatus{Round: 2, Step: 3, Running: true},
+	}
+}
+
+func (s *ServerTestSuite) get(
+	server *Server, path string, v interface{}) int {
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+	s.Require().Equal("application/json", rec.Header().Get("Content-Type"))
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), v))
+	return rec.Code
+}
+
+func (s *ServerTestSuite) TestStatus() {
+	getter := &fakeGetter{status: s.newStatus()}
+	server := NewServer(getter, time.Minute, &common.NullLogger{})
+	var status core.Status
+	s.Require().Equal(http.StatusOK, s.get(server, "/status", &status))
+	s.Require().Equal(getter.status.ID, status.ID)
+	s.Require().Equal(getter.status.Agreement, status.Agreement)
+	var agreement core.AgreementStatus
+	s.Require().Equal(http.StatusOK, s.get(server, "/agreement", &agreement))
+	s.Require().Equal(*getter.status.Agreement, agreement)
+	var dkg core.DKGStatus
+	s.Require().Equal(http.StatusOK, s.get(server, "/dkg", &dkg))
+	s.Require().Equal(*getter.status.DKG, dkg)
+	var bc core.BlockChainStatus
+	s.Require().Equal(http.StatusOK, s.get(server, "/blockchain", &bc))
+	s.Require().Equal(getter.status.BlockChain.LastDelivered, bc.LastDelivered)
+}
+
+func (s *ServerTestSuite) TestHealth() {
+	getter := &fakeGetter{status: s.newStatus()}
+	server := NewServer(getter, 30*time.Second, &common.NullLogger{})
+	// Nothing delivered since dMoment.
+	var h Health
+	s.Require().Equal(
+		http.StatusServiceUnavailable, s.get(server, "/health", &h))
+	s.Require().False(h.Healthy)
+	s.Require().Equal(time.Minute, h.Elapsed)
+	// Blocks delivered recently.
+	getter.status.LastDeliveredTime = getter.status.Now.Add(-time.Second)
+	s.Require().Equal(http.StatusOK, s.get(server, "/health", &h))
+	s.Require().True(h.Healthy)
+	s.Require().Equal(time.Second, h.Elapsed)
+	// Not started yet.
+	getter.status.LastDeliveredTime = time.Time{}
+	getter.status.DMoment = getter.status.Now.Add(time.Hour)
+	s.Require().True(server.Health().Healthy)
+}
+
+func (s *ServerTestSuite) TestServe() {
+	getter := &fakeGetter{status: s.newStatus()}
+	server := NewServer(getter, time.Minute, &common.NullLogger{})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	s.Require().NoError(err)
+	errChan := make(chan error, 1)
+	go func() { errChan <- server.Serve(ln) }()
+	resp, err := http.Get("http://" + ln.Addr().String() + "/health")
+	s.Require().NoError(err)
+	resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	s.Require().NoError(server.Close())
+	s.Require().NoError(<-errChan)
+}
+
+func TestServer(t *testing.T) {
+	suite.Run(t, new(ServerTestSuite))
+}
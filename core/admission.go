@@ -0,0 +1,296 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+)
+
+// MsgType is the type of messages received from network.
+type MsgType int
+
+// MsgType enum.
+const (
+	MsgTypeVote MsgType = iota
+	MsgTypeBlock
+	MsgTypeAgreementResult
+	MsgTypeEvidence
+	MsgTypeDKGPrivateShare
+	MsgTypeDKGPartialSignature
+	MsgTypeUnknown
+	// Do not add any type below maxMsgType.
+	maxMsgType
+)
+
+func (t MsgType) String() string {
+	switch t {
+	case MsgTypeVote:
+		return "Vote"
+	case MsgTypeBlock:
+		return "Block"
+	case MsgTypeAgreementResult:
+		return "AgreementResult"
+	case MsgTypeEvidence:
+		return "Evidence"
+	case MsgTypeDKGPrivateShare:
+		return "DKGPrivateShare"
+	case MsgTypeDKGPartialSignature:
+		return "DKGPartialSignature"
+	}
+	return "Unknown"
+}
+
+// MsgTypeOf returns the type of a message received from network.
+func MsgTypeOf(msg interface{}) MsgType {
+	switch msg.(type) {
+	case *types.Vote:
+		return MsgTypeVote
+	case *types.Block:
+		return MsgTypeBlock
+	case *types.AgreementResult:
+		return MsgTypeAgreementResult
+	case *types.Evidence:
+		return MsgTypeEvidence
+	case *typesDKG.PrivateShare:
+		return MsgTypeDKGPrivateShare
+	case *typesDKG.PartialSignature:
+		return MsgTypeDKGPartialSignature
+	}
+	return MsgTypeUnknown
+}
+
+// ErrPeerFlooding is the reason to report peers sending too many messages.
+type ErrPeerFlooding struct {
+	Type    MsgType
+	Dropped int
+}
+
+func (e ErrPeerFlooding) Error() string {
+	return fmt.Sprintf("peer flooding: %d %s messages dropped",
+		e.Dropped, e.Type)
+}
+
+// AdmissionController decides if a message received from network would be
+// processed, it's called before any verification on the message.
+type AdmissionController interface {
+	// Admit decides if msg from peer is admitted. When reason is not nil, the
+	// peer would be reported as a bad peer with it.
+	Admit(peer interface{}, msg interface{}) (admitted bool, reason error)
+
+	// UpdateNotarySet is called when the notary set of the current round is
+	// changed.
+	UpdateNotarySet(notarySet map[types.NodeID]struct{})
+}
+
+// Quota is the capacity of a token bucket.
+type Quota struct {
+	// Rate is the count of tokens refilled per second.
+	Rate float64
+	// Burst is the maximum count of tokens.
+	Burst int
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(q Quota, now time.Time) *tokenBucket {
+	return &tokenBucket{tokens: float64(q.Burst), last: now}
+}
+
+// take refills the bucket and takes one token if available.
+func (b *tokenBucket) take(q Quota, scale float64, now time.Time) bool {
+	capacity := float64(q.Burst) * scale
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * q.Rate * scale
+		b.last = now
+	}
+	if b.tokens > capacity {
+		b.tokens = capacity
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// RateLimitConfig is the configuration of RateLimiter. Messages of types
+// without quotas are not limited.
+type RateLimitConfig struct {
+	// PeerQuotas limit messages of each type from a single peer.
+	PeerQuotas map[MsgType]Quota
+	// Budgets limit messages of each type from all peers outside the notary
+	// set, which bounds the verification work spent on them. Peers in the
+	// notary set are not limited by budgets.
+	Budgets map[MsgType]Quota
+	// NotaryFactor scales per-peer quotas of peers in the notary set.
+	NotaryFactor float64
+	// ReportThreshold is the count of messages of a peer dropped by its
+	// quotas before reporting it, the count restarts after reported.
+	ReportThreshold int
+	// IdleTimeout is the duration to keep states of peers sending nothing.
+	IdleTimeout time.Duration
+	// PeerNodeID maps peer IDs to node IDs to find peers in the notary set.
+	// Peer IDs are taken as types.NodeID when it's nil.
+	PeerNodeID func(peer interface{}) (types.NodeID, bool)
+}
+
+// DefaultRateLimitConfig returns the default configuration of RateLimiter.
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		PeerQuotas: map[MsgType]Quota{
+			MsgTypeVote:                {Rate: 200, Burst: 400},
+			MsgTypeBlock:               {Rate: 20, Burst: 100},
+			MsgTypeAgreementResult:     {Rate: 20, Burst: 100},
+			MsgTypeEvidence:            {Rate: 5, Burst: 10},
+			MsgTypeDKGPrivateShare:     {Rate: 20, Burst: 50},
+			MsgTypeDKGPartialSignature: {Rate: 50, Burst: 100},
+			MsgTypeUnknown:             {Rate: 1, Burst: 5},
+		},
+		Budgets: map[MsgType]Quota{
+			MsgTypeVote:                {Rate: 2000, Burst: 4000},
+			MsgTypeDKGPartialSignature: {Rate: 500, Burst: 1000},
+		},
+		NotaryFactor:    4,
+		ReportThreshold: 100,
+		IdleTimeout:     10 * time.Minute,
+	}
+}
+
+type peerState struct {
+	buckets [maxMsgType]*tokenBucket
+	dropped [maxMsgType]int
+	last    time.Time
+}
+
+// RateLimiter is an AdmissionController limiting messages by token buckets
+// for each peer and each message type.
+type RateLimiter struct {
+	config    RateLimitConfig
+	clock     Clock
+	lock      sync.Mutex
+	peers     map[interface{}]*peerState
+	budgets   [maxMsgType]*tokenBucket
+	notarySet map[types.NodeID]struct{}
+	lastPurge time.Time
+}
+
+// NewRateLimiter constructs a RateLimiter instance, the system clock is used
+// when clock is nil.
+func NewRateLimiter(config RateLimitConfig, clock Clock) *RateLimiter {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	if config.NotaryFactor <= 0 {
+		config.NotaryFactor = 1
+	}
+	if config.PeerNodeID == nil {
+		config.PeerNodeID = func(peer interface{}) (types.NodeID, bool) {
+			nID, ok := peer.(types.NodeID)
+			return nID, ok
+		}
+	}
+	now := clock.Now()
+	l := &RateLimiter{
+		config:    config,
+		clock:     clock,
+		peers:     make(map[interface{}]*peerState),
+		notarySet: make(map[types.NodeID]struct{}),
+		lastPurge: now,
+	}
+	for t, q := range config.Budgets {
+		l.budgets[t] = newTokenBucket(q, now)
+	}
+	return l
+}
+
+// UpdateNotarySet implements AdmissionController interface.
+func (l *RateLimiter) UpdateNotarySet(notarySet map[types.NodeID]struct{}) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.notarySet = make(map[types.NodeID]struct{}, len(notarySet))
+	for nID := range notarySet {
+		l.notarySet[nID] = struct{}{}
+	}
+}
+
+// Admit implements AdmissionController interface.
+func (l *RateLimiter) Admit(peer interface{}, msg interface{}) (
+	admitted bool, reason error) {
+	t := MsgTypeOf(msg)
+	now := l.clock.Now()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.purgeIdlePeers(now)
+	isNotary := false
+	if nID, ok := l.config.PeerNodeID(peer); ok {
+		_, isNotary = l.notarySet[nID]
+	}
+	s, exist := l.peers[peer]
+	if !exist {
+		s = &peerState{}
+		l.peers[peer] = s
+	}
+	s.last = now
+	if q, exist := l.config.PeerQuotas[t]; exist {
+		scale := 1.0
+		if isNotary {
+			scale = l.config.NotaryFactor
+		}
+		if s.buckets[t] == nil {
+			s.buckets[t] = newTokenBucket(q, now)
+			s.buckets[t].tokens *= scale
+		}
+		if !s.buckets[t].take(q, scale, now) {
+			s.dropped[t]++
+			if l.config.ReportThreshold > 0 &&
+				s.dropped[t] >= l.config.ReportThreshold {
+				reason = ErrPeerFlooding{Type: t, Dropped: s.dropped[t]}
+				s.dropped[t] = 0
+			}
+			return false, reason
+		}
+	}
+	// Budgets are shared by all peers, exceeding them is not the fault of a
+	// single peer.
+	if b := l.budgets[t]; b != nil && !isNotary {
+		if !b.take(l.config.Budgets[t], 1, now) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (l *RateLimiter) purgeIdlePeers(now time.Time) {
+	if l.config.IdleTimeout <= 0 || now.Sub(l.lastPurge) < l.config.IdleTimeout {
+		return
+	}
+	l.lastPurge = now
+	for peer, s := range l.peers {
+		if now.Sub(s.last) >= l.config.IdleTimeout {
+			delete(l.peers, peer)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/test"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+)
+
+type AdmissionTestSuite struct {
+	suite.Suite
+}
+
+func (s *AdmissionTestSuite) newLimiter() (*RateLimiter, *test.FakeClock) {
+	clock := test.NewFakeClock(time.Now().UTC())
+	config := RateLimitConfig{
+		PeerQuotas: map[MsgType]Quota{
+			MsgTypeVote: {Rate: 10, Burst: 5},
+		},
+		Budgets: map[MsgType]Quota{
+			MsgTypeVote: {Rate: 10, Burst: 8},
+		},
+		NotaryFactor:    2,
+		ReportThreshold: 3,
+		IdleTimeout:     time.Minute,
+	}
+	return NewRateLimiter(config, clock), clock
+}
+
+func (s *AdmissionTestSuite) newPeer() types.NodeID {
+	return types.NodeID{Hash: common.NewRandomHash()}
+}
+
+func (s *AdmissionTestSuite) admitN(
+	l *RateLimiter, peer types.NodeID, msg interface{}, n int) int {
+	admitted := 0
+	for i := 0; i < n; i++ {
+		if ok, _ := l.Admit(peer, msg); ok {
+			admitted++
+		}
+	}
+	return admitted
+}
+
+func (s *AdmissionTestSuite) TestMsgTypeOf() {
+	s.Require().Equal(MsgTypeVote, MsgTypeOf(&types.Vote{}))
+	s.Require().Equal(MsgTypeBlock, MsgTypeOf(&types.Block{}))
+	s.Require().Equal(MsgTypeAgreementResult,
+		MsgTypeOf(&types.AgreementResult{}))
+	s.Require().Equal(MsgTypeDKGPartialSignature,
+		MsgTypeOf(&typesDKG.PartialSignature{}))
+	s.Require().Equal(MsgTypeUnknown, MsgTypeOf("not a message"))
+}
+
+func (s *AdmissionTestSuite) TestPeerQuota() {
+	l, clock := s.newLimiter()
+	peer := s.newPeer()
+	// Burst is exhausted.
+	s.Require().Equal(5, s.admitN(l, peer, &types.Vote{}, 6))
+	// Other peers are not affected.
+	s.Require().Equal(1, s.admitN(l, s.newPeer(), &types.Vote{}, 1))
+	// Types without quotas are not limited.
+	s.Require().Equal(10, s.admitN(l, peer, &types.Block{}, 10))
+	// Tokens are refilled over time.
+	clock.Advance(200 * time.Millisecond)
+	s.Require().Equal(2, s.admitN(l, peer, &types.Vote{}, 3))
+	// Tokens are never refilled more than burst.
+	clock.Advance(time.Hour)
+	s.Require().Equal(5, s.admitN(l, peer, &types.Vote{}, 6))
+}
+
+func (s *AdmissionTestSuite) TestNotaryFactor() {
+	l, _ := s.newLimiter()
+	notary := s.newPeer()
+	l.UpdateNotarySet(map[types.NodeID]struct{}{notary: struct{}{}})
+	s.Require().Equal(10, s.admitN(l, notary, &types.Vote{}, 12))
+}
+
+func (s *AdmissionTestSuite) TestBudget() {
+	l, _ := s.newLimiter()
+	notary := s.newPeer()
+	l.UpdateNotarySet(map[types.NodeID]struct{}{notary: struct{}{}})
+	// The budget is shared by peers outside the notary set.
+	s.Require().Equal(5, s.admitN(l, s.newPeer(), &types.Vote{}, 5))
+	s.Require().Equal(3, s.admitN(l, s.newPeer(), &types.Vote{}, 5))
+	// Exceeding the budget is not reported.
+	ok, reason := l.Admit(s.newPeer(), &types.Vote{})
+	s.Require().False(ok)
+	s.Require().NoError(reason)
+	// Peers in the notary set are not limited by budget.
+	s.Require().Equal(10, s.admitN(l, notary, &types.Vote{}, 10))
+}
+
+func (s *AdmissionTestSuite) TestReport() {
+	l, _ := s.newLimiter()
+	peer := s.newPeer()
+	s.Require().Equal(5, s.admitN(l, peer, &types.Vote{}, 5))
+	var reasons []error
+	for i := 0; i < 6; i++ {
+		ok, reason := l.Admit(peer, &types.Vote{})
+		s.Require().False(ok)
+		if reason != nil {
+			reasons = append(reasons, reason)
+		}
+	}
+	s.Require().Len(reasons, 2)
+	for _, reason := range reasons {
+		s.Require().Equal(
+			ErrPeerFlooding{Type: MsgTypeVote, Dropped: 3}, reason)
+	}
+}
+
+func (s *AdmissionTestSuite) TestPurgeIdlePeers() {
+	l, clock := s.newLimiter()
+	idle, active := s.newPeer(), s.newPeer()
+	s.admitN(l, idle, &types.Vote{}, 1)
+	clock.Advance(30 * time.Second)
+	s.admitN(l, active, &types.Vote{}, 1)
+	clock.Advance(30 * time.Second)
+	s.admitN(l, active, &types.Vote{}, 1)
+	s.Require().Len(l.peers, 1)
+	s.Require().Contains(l.peers, interface{}(active))
+}
+
+func TestAdmission(t *testing.T) {
+	suite.Run(t, new(AdmissionTestSuite))
+}
@@ -49,7 +49,7 @@ const settingLimit = 3
 func genValidLeader(
 	mgr *agreementMgr) validLeaderFn {
 	return func(block *types.Block, crs common.Hash) (bool, error) {
-		if block.Timestamp.After(time.Now()) {
+		if block.Timestamp.After(mgr.clock.Now()) {
 			return false, nil
 		}
 		if block.Position.Round >= DKGDelayRound {
@@ -111,7 +111,8 @@ func newAgreementMgrConfig(prev agreementMgrConfig, config *types.Config,
 type baRoundSetting struct {
 	round     uint64
 	dkgSet    map[types.NodeID]struct{}
-	threshold int
+	weights   map[types.NodeID]uint64
+	threshold uint64
 	ticker    Ticker
 	crs       common.Hash
 }
@@ -124,6 +125,7 @@ type agreementMgr struct {
 	gov               Governance
 	network           Network
 	logger            common.Logger
+	clock             Clock
 	cache             *utils.NodeSetCache
 	signer            *utils.Signer
 	bcModule          *blockChain
@@ -149,6 +151,7 @@ func newAgreementMgr(con *Consensus) (mgr *agreementMgr, err error) {
 		gov:               con.gov,
 		network:           con.network,
 		logger:            con.logger,
+		clock:             con.clock,
 		cache:             con.nodeSetCache,
 		signer:            con.signer,
 		bcModule:          con.bcModule,
@@ -166,12 +169,17 @@ func newAgreementMgr(con *Consensus) (mgr *agreementMgr, err error) {
 
 func (mgr *agreementMgr) prepare() {
 	round := mgr.bcModule.tipRound()
+	leader := newLeaderSelector(genValidLeader(mgr), mgr.logger)
+	leader.selector = mgr.con.leaderSelector
 	agr := newAgreement(
 		mgr.ID,
 		mgr.recv,
-		newLeaderSelector(genValidLeader(mgr), mgr.logger),
+		leader,
 		mgr.signer,
+		mgr.con.db,
 		mgr.logger)
+	agr.sigCache = mgr.con.sigCache
+	agr.clock = mgr.clock
 	setting := mgr.generateSetting(round)
 	if setting == nil {
 		mgr.logger.Warn("Unable to prepare init setting", "round", round)
@@ -216,26 +224,24 @@ func (mgr *agreementMgr) calcLeader(
 	dkgSet map[types.NodeID]struct{},
 	crs common.Hash, pos types.Position) (
 	types.NodeID, error) {
-	nodeSet := types.NewNodeSetFromMap(dkgSet)
-	leader := nodeSet.GetSubSet(1, types.NewNodeLeaderTarget(
-		crs, pos.Height))
-	for nID := range leader {
-		return nID, nil
-	}
-	return types.NodeID{}, ErrNoValidLeader
+	return mgr.con.leaderSelector.Leader(dkgSet, crs, pos)
 }
 
-func (mgr *agreementMgr) config(round uint64) *agreementMgrConfig {
+// config returns the config of a round, nil is returned when it's not ready.
+func (mgr *agreementMgr) config(round uint64) (*agreementMgrConfig, error) {
 	mgr.lock.RLock()
 	defer mgr.lock.RUnlock()
+	if len(mgr.configs) == 0 {
+		return nil, nil
+	}
 	if round < mgr.configs[0].RoundID() {
-		panic(ErrRoundOutOfRange)
+		return nil, ErrRoundOutOfRange
 	}
 	roundIndex := round - mgr.configs[0].RoundID()
 	if roundIndex >= uint64(len(mgr.configs)) {
-		return nil
+		return nil, nil
 	}
-	return &mgr.configs[roundIndex]
+	return &mgr.configs[roundIndex], nil
 }
 
 func (mgr *agreementMgr) notifyRoundEvents(evts []utils.RoundEventParam) error {
@@ -290,18 +296,24 @@ func (mgr *agreementMgr) checkProposer(
 }
 
 func (mgr *agreementMgr) processVote(v *types.Vote) (err error) {
-	if !mgr.recv.isNotary {
+	// Both are replaced by the BA routine when a round begins.
+	isNotary, voteFilter := func() (bool, *utils.VoteFilter) {
+		mgr.lock.RLock()
+		defer mgr.lock.RUnlock()
+		return mgr.recv.isNotary, mgr.voteFilter
+	}()
+	if !isNotary {
 		return nil
 	}
-	if mgr.voteFilter.Filter(v) {
+	if voteFilter.Filter(v) {
 		return nil
 	}
 	if err := mgr.checkProposer(v.Position.Round, v.ProposerID); err != nil {
 		return err
 	}
 	if err = mgr.baModule.processVote(v); err == nil {
-		mgr.baModule.updateFilter(mgr.voteFilter)
-		mgr.voteFilter.AddVote(v)
+		mgr.baModule.updateFilter(voteFilter)
+		voteFilter.AddVote(v)
 	}
 	if err == ErrSkipButNoError {
 		err = nil
@@ -379,7 +391,7 @@ func (mgr *agreementMgr) processAgreementResult(
 			return err
 		}
 		mgr.baModule.restart(
-			setting.dkgSet, setting.threshold,
+			setting.dkgSet, setting.weights, setting.threshold,
 			result.Position, leader, setting.crs)
 		if result.Position.Round >= DKGDelayRound {
 			return mgr.baModule.processAgreementResult(result)
@@ -412,7 +424,11 @@ func (mgr *agreementMgr) generateSetting(round uint64) *baRoundSetting {
 	if setting, exist := mgr.settingCache.Get(round); exist {
 		return setting.(*baRoundSetting)
 	}
-	curConfig := mgr.config(round)
+	curConfig, err := mgr.config(round)
+	if err != nil {
+		mgr.logger.Error("Failed to get config", "round", round, "error", err)
+		return nil
+	}
 	if curConfig == nil {
 		return nil
 	}
@@ -430,19 +446,28 @@ func (mgr *agreementMgr) generateSetting(round uint64) *baRoundSetting {
 		dkgSet = qualidifed
 	}
 	if len(dkgSet) == 0 {
-		var err error
 		dkgSet, err = mgr.cache.GetNotarySet(round)
 		if err != nil {
 			mgr.logger.Error("Failed to get notarySet", "round", round, "error", err)
 			return nil
 		}
 	}
+	weights, err := mgr.cache.GetNotarySetWeights(round)
+	if err != nil {
+		mgr.logger.Error("Failed to get weights of notarySet",
+			"round", round, "error", err)
+		return nil
+	}
 	setting := &baRoundSetting{
-		crs:    curConfig.crs,
-		dkgSet: dkgSet,
-		round:  round,
-		threshold: utils.GetBAThreshold(&types.Config{
-			NotarySetSize: curConfig.notarySetSize}),
+		crs:     curConfig.crs,
+		dkgSet:  dkgSet,
+		weights: weights,
+		round:   round,
+		threshold: uint64(utils.GetBAThreshold(&types.Config{
+			NotarySetSize: curConfig.notarySetSize})),
+	}
+	if weights != nil {
+		setting.threshold = utils.GetBAWeightThreshold(weights)
 	}
 	mgr.settingCache.Add(round, setting)
 	return setting
@@ -453,11 +478,15 @@ func (mgr *agreementMgr) runBA(initRound uint64) {
 	var (
 		currentRound uint64
 		nextRound    = initRound
-		curConfig    = mgr.config(initRound)
 		setting      = &baRoundSetting{}
 		tickDuration time.Duration
 		ticker       Ticker
 	)
+	curConfig, err := mgr.config(initRound)
+	if err != nil {
+		mgr.con.stopWithError(err)
+		return
+	}
 
 	// Check if this routine needs to awake in this round and prepare essential
 	// variables when yes.
@@ -472,7 +501,7 @@ func (mgr *agreementMgr) runBA(initRound uint64) {
 				break
 			} else {
 				mgr.logger.Debug("Round is not ready", "round", nextRound)
-				time.Sleep(1 * time.Second)
+				mgr.clock.Sleep(1 * time.Second)
 			}
 		}
 		_, isDKG = setting.dkgSet[mgr.ID]
@@ -490,7 +519,7 @@ func (mgr *agreementMgr) runBA(initRound uint64) {
 			if ticker != nil {
 				ticker.Stop()
 			}
-			ticker = newTicker(mgr.gov, nextRound, TickerBA)
+			ticker = mgr.con.tickerFactory(mgr.gov, nextRound, TickerBA)
 			tickDuration = curConfig.lambdaBA
 		}
 		setting.ticker = ticker
@@ -503,9 +532,15 @@ Loop:
 			break Loop
 		default:
 		}
-		mgr.recv.isNotary = checkRound()
-		mgr.voteFilter = utils.NewVoteFilter()
-		mgr.voteFilter.Position.Round = currentRound
+		isNotary := checkRound()
+		voteFilter := utils.NewVoteFilter()
+		voteFilter.Position.Round = currentRound
+		func() {
+			mgr.lock.Lock()
+			defer mgr.lock.Unlock()
+			mgr.recv.isNotary = isNotary
+			mgr.voteFilter = voteFilter
+		}()
 		mgr.recv.emptyBlockHashMap = &sync.Map{}
 		if currentRound >= DKGDelayRound && mgr.recv.isNotary {
 			var err error
@@ -528,6 +563,7 @@ Loop:
 			mgr.logger.Error("BA routine failed",
 				"error", err,
 				"nodeID", mgr.ID)
+			mgr.con.stopWithError(err)
 			break Loop
 		}
 	}
@@ -540,7 +576,15 @@ func (mgr *agreementMgr) baRoutineForOneRound(
 	oldPos := agr.agreementID()
 	restart := func(restartPos types.Position) (breakLoop bool, err error) {
 		if !isStop(restartPos) {
-			if restartPos.Height+1 >= mgr.config(setting.round).RoundEndHeight() {
+			var cfg *agreementMgrConfig
+			if cfg, err = mgr.config(setting.round); err != nil {
+				return
+			}
+			if cfg == nil {
+				err = ErrConfigurationNotReady
+				return
+			}
+			if restartPos.Height+1 >= cfg.RoundEndHeight() {
 				for {
 					select {
 					case <-mgr.ctx.Done():
@@ -555,7 +599,7 @@ func (mgr *agreementMgr) baRoutineForOneRound(
 							"curRound", setting.round,
 							"tipRound", tipRound)
 					}
-					time.Sleep(100 * time.Millisecond)
+					mgr.clock.Sleep(100 * time.Millisecond)
 				}
 				// This round is finished.
 				breakLoop = true
@@ -588,7 +632,7 @@ func (mgr *agreementMgr) baRoutineForOneRound(
 			}
 			mgr.logger.Debug("BlockChain not ready!!!",
 				"old", oldPos, "restart", restartPos, "next", nextHeight)
-			time.Sleep(100 * time.Millisecond)
+			mgr.clock.Sleep(100 * time.Millisecond)
 		}
 		nextPos := types.Position{
 			Round:  setting.round,
@@ -600,9 +644,11 @@ func (mgr *agreementMgr) baRoutineForOneRound(
 		if err != nil {
 			return
 		}
-		time.Sleep(nextTime.Sub(time.Now()))
+		mgr.clock.Sleep(nextTime.Sub(mgr.clock.Now()))
 		setting.ticker.Restart()
-		agr.restart(setting.dkgSet, setting.threshold, nextPos, leader, setting.crs)
+		agr.restart(setting.dkgSet, setting.weights, setting.threshold, nextPos,
+			leader, setting.crs)
+		mgr.con.events.emit(&BARestartedEvent{Position: nextPos, Leader: leader})
 		return
 	}
 Loop:
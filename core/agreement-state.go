@@ -44,6 +44,28 @@ const (
 	stateSleep
 )
 
+func (s agreementStateType) String() string {
+	switch s {
+	case stateFast:
+		return "Fast"
+	case stateFastVote:
+		return "FastVote"
+	case stateInitial:
+		return "Initial"
+	case statePreCommit:
+		return "PreCommit"
+	case stateCommit:
+		return "Commit"
+	case stateForward:
+		return "Forward"
+	case statePullVote:
+		return "PullVote"
+	case stateSleep:
+		return "Sleep"
+	}
+	return "Unknown"
+}
+
 type agreementState interface {
 	state() agreementStateType
 	nextState() (agreementState, error)
@@ -66,6 +66,9 @@ func (r *agreementStateTestReceiver) PullBlocks(common.Hashes) {}
 
 func (r *agreementStateTestReceiver) ReportForkVote(v1, v2 *types.Vote)   {}
 func (r *agreementStateTestReceiver) ReportForkBlock(b1, b2 *types.Block) {}
+func (r *agreementStateTestReceiver) ReportPeriodAdvanced(
+	pos types.Position, period uint64) {
+}
 
 func (s *AgreementStateTestSuite) proposeBlock(
 	leader *leaderSelector) *types.Block {
@@ -124,12 +127,13 @@ func (s *AgreementStateTestSuite) newAgreement(numNode int) *agreement {
 		},
 		leader,
 		s.signers[s.ID],
+		nil,
 		logger,
 	)
-	agreement.restart(notarySet,
-		utils.GetBAThreshold(&types.Config{
+	agreement.restart(notarySet, nil,
+		uint64(utils.GetBAThreshold(&types.Config{
 			NotarySetSize: uint32(len(notarySet)),
-		}),
+		})),
 		types.Position{Height: types.GenesisHeight},
 		types.NodeID{}, common.NewRandomHash())
 	return agreement
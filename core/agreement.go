@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/db"
 	"github.com/dexon-foundation/dexon-consensus/core/types"
 	"github.com/dexon-foundation/dexon-consensus/core/utils"
 )
@@ -43,6 +44,8 @@ var (
 	ErrIncorrectVoteSignature        = fmt.Errorf("incorrect vote signature")
 	ErrIncorrectVotePartialSignature = fmt.Errorf("incorrect vote psig")
 	ErrMismatchBlockPosition         = fmt.Errorf("mismatch block position")
+	ErrConflictingVote               = fmt.Errorf(
+		"conflicting with a vote signed before")
 )
 
 // ErrFork for fork error in agreement.
@@ -85,6 +88,7 @@ type agreementReceiver interface {
 	PullBlocks(common.Hashes)
 	ReportForkVote(v1, v2 *types.Vote)
 	ReportForkBlock(b1, b2 *types.Block)
+	ReportPeriodAdvanced(pos types.Position, period uint64)
 	VerifyPartialSignature(vote *types.Vote) (bool, bool)
 }
 
@@ -108,7 +112,8 @@ type agreementData struct {
 	lockValue    common.Hash
 	lockIter     uint64
 	period       uint64
-	requiredVote int
+	requiredVote uint64
+	weights      map[types.NodeID]uint64
 	votes        map[uint64][]map[types.NodeID]*types.Vote
 	lock         sync.RWMutex
 	blocks       map[types.NodeID]*types.Block
@@ -131,15 +136,23 @@ type agreement struct {
 	candidateBlock         map[common.Hash]*types.Block
 	fastForward            chan uint64
 	signer                 *utils.Signer
+	sigCache               *utils.SignatureCache
+	clock                  Clock
 	logger                 common.Logger
+	// Write-ahead log of votes signed by this node and the lock state.
+	db       db.Database
+	walState *db.AgreementState
+	walLock  sync.Mutex
 }
 
-// newAgreement creates a agreement instance.
+// newAgreement creates a agreement instance. The agreement state would be
+// logged to dbInst if it's not nil.
 func newAgreement(
 	ID types.NodeID,
 	recv agreementReceiver,
 	leader *leaderSelector,
 	signer *utils.Signer,
+	dbInst db.Database,
 	logger common.Logger) *agreement {
 	agreement := &agreement{
 		data: &agreementData{
@@ -152,16 +165,19 @@ func newAgreement(
 		candidateBlock:         make(map[common.Hash]*types.Block),
 		fastForward:            make(chan uint64, 1),
 		signer:                 signer,
+		clock:                  systemClock{},
 		logger:                 logger,
+		db:                     dbInst,
 	}
 	agreement.stop()
 	return agreement
 }
 
-// restart the agreement
+// restart the agreement, threshold is the total weight of votes required if
+// weights is not nil.
 func (a *agreement) restart(
-	notarySet map[types.NodeID]struct{},
-	threshold int, aID types.Position, leader types.NodeID,
+	notarySet map[types.NodeID]struct{}, weights map[types.NodeID]uint64,
+	threshold uint64, aID types.Position, leader types.NodeID,
 	crs common.Hash) {
 	if !func() bool {
 		a.lock.Lock()
@@ -183,7 +199,8 @@ func (a *agreement) restart(
 		a.data.period = 2
 		a.data.blocks = make(map[types.NodeID]*types.Block)
 		a.data.requiredVote = threshold
-		a.data.leader.restart(crs)
+		a.data.weights = weights
+		a.data.leader.restart(crs, notarySet)
 		a.data.lockValue = types.SkipBlockHash
 		a.data.lockIter = 0
 		a.data.isLeader = a.data.ID == leader
@@ -201,6 +218,7 @@ func (a *agreement) restart(
 			pos    types.Position
 			leader types.NodeID
 		}{aID, leader})
+		a.restoreWAL(aID)
 		return true
 	}() {
 		return
@@ -226,7 +244,7 @@ func (a *agreement) restart(
 		a.pendingAgreementResult = newPendingAgreementResult
 	}()
 
-	expireTime := time.Now().Add(-10 * time.Second)
+	expireTime := a.clock.Now().Add(-10 * time.Second)
 	replayBlock := make([]*types.Block, 0)
 	func() {
 		a.lock.Lock()
@@ -290,7 +308,7 @@ func (a *agreement) restart(
 }
 
 func (a *agreement) stop() {
-	a.restart(make(map[types.NodeID]struct{}), int(math.MaxInt32),
+	a.restart(make(map[types.NodeID]struct{}), nil, math.MaxUint64,
 		types.Position{
 			Height: math.MaxUint64,
 		},
@@ -361,7 +379,7 @@ func (a *agreement) sanityCheck(vote *types.Vote) error {
 	if vote.Type >= types.MaxVoteType {
 		return ErrInvalidVote
 	}
-	ok, err := utils.VerifyVoteSignature(vote)
+	ok, err := a.sigCache.VerifyVoteSignature(vote)
 	if err != nil {
 		return err
 	}
@@ -398,13 +416,102 @@ func (a *agreement) checkForkVote(vote *types.Vote) (
 	return
 }
 
-// prepareVote prepares a vote.
+// prepareVote prepares a vote. The signed vote is logged before returning,
+// and the one signed before would be reused instead of signing a conflicting
+// one.
 func (a *agreement) prepareVote(vote *types.Vote) (err error) {
 	vote.Position = a.agreementID()
-	err = a.signer.SignVote(vote)
+	if a.db == nil {
+		err = a.signer.SignVote(vote)
+		return
+	}
+	a.walLock.Lock()
+	defer a.walLock.Unlock()
+	if a.walState == nil || a.walState.Position != vote.Position {
+		a.walState = &db.AgreementState{Position: vote.Position}
+	}
+	for _, v := range a.walState.Votes {
+		if v.Period != vote.Period || v.Type != vote.Type {
+			continue
+		}
+		if v.BlockHash != vote.BlockHash {
+			a.logger.Error("Refuse to sign conflicting vote",
+				"signed", &v,
+				"vote", vote)
+			err = ErrConflictingVote
+			return
+		}
+		*vote = *v.Clone()
+		return
+	}
+	if err = a.signer.SignVote(vote); err != nil {
+		return
+	}
+	a.walState.Votes = append(a.walState.Votes, *vote.Clone())
+	err = a.db.PutOrUpdateAgreementState(*a.walState)
 	return
 }
 
+// restoreWAL restores lock state and period of a position from write-ahead
+// log, it should be called with a.lock and a.data.lock held.
+func (a *agreement) restoreWAL(aID types.Position) {
+	if a.db == nil {
+		return
+	}
+	a.walLock.Lock()
+	defer a.walLock.Unlock()
+	a.walState = &db.AgreementState{
+		Position:  aID,
+		Period:    a.data.period,
+		LockValue: a.data.lockValue,
+		LockIter:  a.data.lockIter,
+	}
+	if isStop(aID) {
+		return
+	}
+	state, err := a.db.GetAgreementState()
+	if err != nil {
+		if err != db.ErrAgreementStateDoesNotExist {
+			a.logger.Error("Failed to load agreement state", "error", err)
+		}
+		return
+	}
+	if state.Position != aID {
+		return
+	}
+	a.logger.Info("Restore agreement state",
+		"position", aID,
+		"period", state.Period,
+		"lock-iter", state.LockIter,
+		"votes", len(state.Votes))
+	a.walState = state.Clone()
+	a.data.lockValue = state.LockValue
+	a.data.lockIter = state.LockIter
+	if state.Period > a.data.period {
+		a.data.setPeriod(state.Period)
+		a.state = newPreCommitState(a.data)
+	}
+}
+
+// updateWAL logs the lock state and period, it should be called with
+// a.data.lock held.
+func (a *agreement) updateWAL() {
+	if a.db == nil {
+		return
+	}
+	a.walLock.Lock()
+	defer a.walLock.Unlock()
+	if a.walState == nil || a.walState.Position != a.agreementID() {
+		return
+	}
+	a.walState.Period = a.data.period
+	a.walState.LockValue = a.data.lockValue
+	a.walState.LockIter = a.data.lockIter
+	if err := a.db.PutOrUpdateAgreementState(*a.walState); err != nil {
+		a.logger.Error("Failed to save agreement state", "error", err)
+	}
+}
+
 func (a *agreement) updateFilter(filter *utils.VoteFilter) {
 	if isStop(a.agreementID()) {
 		return
@@ -434,7 +541,7 @@ func (a *agreement) processVote(vote *types.Vote) error {
 		if vote.Position.Round == aID.Round {
 			a.pendingVote = append(a.pendingVote, pendingVote{
 				vote:         vote,
-				receivedTime: time.Now().UTC(),
+				receivedTime: a.clock.Now().UTC(),
 			})
 			return nil
 		}
@@ -446,7 +553,7 @@ func (a *agreement) processVote(vote *types.Vote) error {
 		}
 		a.pendingVote = append(a.pendingVote, pendingVote{
 			vote:         vote,
-			receivedTime: time.Now().UTC(),
+			receivedTime: a.clock.Now().UTC(),
 		})
 		return nil
 	}
@@ -485,6 +592,7 @@ func (a *agreement) processVote(vote *types.Vote) error {
 					if a.data.lockIter == 0 {
 						a.data.lockValue = hash
 						a.data.lockIter = 1
+						a.updateWAL()
 					}
 				}
 			} else {
@@ -517,6 +625,7 @@ func (a *agreement) processVote(vote *types.Vote) error {
 			if vote.Period > a.data.lockIter {
 				a.data.lockValue = hash
 				a.data.lockIter = vote.Period
+				a.updateWAL()
 			}
 			// Condition 2.
 			if vote.Period > a.data.period {
@@ -531,7 +640,8 @@ func (a *agreement) processVote(vote *types.Vote) error {
 	}
 	// Condition 3.
 	if vote.Type == types.VoteCom && vote.Period >= a.data.period &&
-		len(a.data.votes[vote.Period][types.VoteCom]) >= a.data.requiredVote {
+		a.data.sumVoteWeights(a.data.votes[vote.Period][types.VoteCom]) >=
+			a.data.requiredVote {
 		hashes := common.Hashes{}
 		addPullBlocks := func(voteType types.VoteType) {
 			for _, vote := range a.data.votes[vote.Period][voteType] {
@@ -618,6 +728,8 @@ func (a *agreement) done() <-chan struct{} {
 			break
 		}
 		a.data.setPeriod(period)
+		a.updateWAL()
+		a.data.recv.ReportPeriodAdvanced(a.agreementID(), period)
 		a.state = newPreCommitState(a.data)
 		a.doneChan = make(chan struct{})
 		return closedchan
@@ -671,7 +783,7 @@ func (a *agreement) processBlock(block *types.Block) error {
 	} else if aID != block.Position {
 		a.pendingBlock = append(a.pendingBlock, pendingBlock{
 			block:        block,
-			receivedTime: time.Now().UTC(),
+			receivedTime: a.clock.Now().UTC(),
 		})
 		return nil
 	} else if a.confirmedNoLock() {
@@ -724,7 +836,7 @@ func (a *agreement) processBlock(block *types.Block) error {
 				return true
 			}() {
 				// TODO(jimmy): retry interval should be related to configurations.
-				time.Sleep(250 * time.Millisecond)
+				a.clock.Sleep(250 * time.Millisecond)
 			}
 		}()
 	}
@@ -770,12 +882,12 @@ func (a *agreementData) countVoteNoLock(
 	if !exist {
 		return
 	}
-	candidate := make(map[common.Hash]int)
+	candidate := make(map[common.Hash]uint64)
 	for _, vote := range votes[voteType] {
 		if _, exist := candidate[vote.BlockHash]; !exist {
 			candidate[vote.BlockHash] = 0
 		}
-		candidate[vote.BlockHash]++
+		candidate[vote.BlockHash] += a.voteWeight(vote.ProposerID)
 	}
 	for candidateHash, votes := range candidate {
 		if votes >= a.requiredVote {
@@ -787,6 +899,23 @@ func (a *agreementData) countVoteNoLock(
 	return
 }
 
+// voteWeight returns the weight of a vote from nID, all votes are equally
+// weighted if weights are not provided.
+func (a *agreementData) voteWeight(nID types.NodeID) uint64 {
+	if a.weights == nil {
+		return 1
+	}
+	return a.weights[nID]
+}
+
+func (a *agreementData) sumVoteWeights(
+	votes map[types.NodeID]*types.Vote) (sum uint64) {
+	for nID := range votes {
+		sum += a.voteWeight(nID)
+	}
+	return
+}
+
 func (a *agreementData) setPeriod(period uint64) {
 	for i := a.period + 1; i <= period; i++ {
 		if _, exist := a.votes[i]; !exist {
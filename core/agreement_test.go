@@ -23,6 +23,7 @@ import (
 
 	"github.com/dexon-foundation/dexon-consensus/common"
 	"github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
+	"github.com/dexon-foundation/dexon-consensus/core/db"
 	"github.com/dexon-foundation/dexon-consensus/core/types"
 	"github.com/dexon-foundation/dexon-consensus/core/utils"
 	"github.com/stretchr/testify/suite"
@@ -74,6 +75,10 @@ func (r *agreementTestReceiver) ReportForkVote(v1, v2 *types.Vote) {
 	r.s.forkVoteChan <- v2.BlockHash
 }
 
+func (r *agreementTestReceiver) ReportPeriodAdvanced(
+	pos types.Position, period uint64) {
+}
+
 func (r *agreementTestReceiver) ReportForkBlock(b1, b2 *types.Block) {
 	r.s.forkBlockChan <- b1.Hash
 	r.s.forkBlockChan <- b2.Hash
@@ -161,11 +166,12 @@ func (s *AgreementTestSuite) newAgreement(
 		},
 		leader,
 		s.signers[s.ID],
+		nil,
 		logger,
 	)
-	agreement.restart(notarySet, utils.GetBAThreshold(&types.Config{
+	agreement.restart(notarySet, nil, uint64(utils.GetBAThreshold(&types.Config{
 		NotarySetSize: uint32(len(notarySet)),
-	}), s.agreementID, leaderNode,
+	})), s.agreementID, leaderNode,
 		common.NewRandomHash())
 	s.agreement = append(s.agreement, agreement)
 	return agreement, leaderNode
@@ -493,6 +499,43 @@ func (s *AgreementTestSuite) TestFastForwardCond3() {
 	}
 }
 
+func (s *AgreementTestSuite) TestWeightedVotes() {
+	a, _ := s.newAgreement(4, -1, s.defaultValidLeader)
+	notarySet, crs := a.notarySet, a.data.leader.hashCRS
+	// The node itself holds the majority of weights.
+	weights := make(map[types.NodeID]uint64)
+	for nID := range notarySet {
+		weights[nID] = 1
+	}
+	weights[s.ID] = 7
+	a.stop()
+	a.restart(notarySet, weights, utils.GetBAWeightThreshold(weights),
+		s.agreementID, types.NodeID{}, crs)
+	a.data.period = 1
+	hash := common.NewRandomHash()
+	for nID := range notarySet {
+		if nID == s.ID {
+			continue
+		}
+		s.Require().NoError(a.processVote(
+			s.prepareVote(nID, types.VotePreCom, hash, uint64(2))))
+	}
+	select {
+	case <-a.done():
+		s.FailNow("Unexpected fast forward.")
+	default:
+	}
+	s.Require().NoError(a.processVote(
+		s.prepareVote(s.ID, types.VotePreCom, hash, uint64(2))))
+	select {
+	case <-a.done():
+	default:
+		s.FailNow("Expecting fast forward.")
+	}
+	s.Equal(hash, a.data.lockValue)
+	s.Equal(uint64(2), a.data.period)
+}
+
 func (s *AgreementTestSuite) TestDecide() {
 	votes := 0
 	a, _ := s.newAgreement(4, -1, s.defaultValidLeader)
@@ -578,6 +621,45 @@ func (s *AgreementTestSuite) TestConfirmWithBlock() {
 	s.True(a.confirmed())
 }
 
+func (s *AgreementTestSuite) TestWAL() {
+	dbInst, err := db.NewMemBackedDB()
+	s.Require().NoError(err)
+	newAgreementWithDB := func() *agreement {
+		a, _ := s.newAgreement(4, -1, s.defaultValidLeader)
+		notarySet, threshold := a.notarySet, a.data.requiredVote
+		crs := a.data.leader.hashCRS
+		a.stop()
+		a.db = dbInst
+		a.restart(notarySet, nil, threshold, s.agreementID, types.NodeID{}, crs)
+		return a
+	}
+	a := newAgreementWithDB()
+	hash := common.NewRandomHash()
+	vote := types.NewVote(types.VotePreCom, hash, 1)
+	s.Require().NoError(a.prepareVote(vote))
+	a.data.lock.Lock()
+	a.data.lockValue = hash
+	a.data.lockIter = 1
+	a.data.setPeriod(3)
+	a.updateWAL()
+	a.data.lock.Unlock()
+	// Simulate a restarted node.
+	a = newAgreementWithDB()
+	s.Equal(hash, a.data.lockValue)
+	s.Equal(uint64(1), a.data.lockIter)
+	s.Equal(uint64(3), a.data.period)
+	// The same vote is reused.
+	vote2 := types.NewVote(types.VotePreCom, hash, 1)
+	s.Require().NoError(a.prepareVote(vote2))
+	s.Equal(vote.Signature, vote2.Signature)
+	// A conflicting vote is refused.
+	vote3 := types.NewVote(types.VotePreCom, common.NewRandomHash(), 1)
+	s.Equal(ErrConflictingVote, a.prepareVote(vote3))
+	// Votes of other types are fine.
+	vote4 := types.NewVote(types.VoteCom, hash, 1)
+	s.Require().NoError(a.prepareVote(vote4))
+}
+
 func TestAgreement(t *testing.T) {
 	suite.Run(t, new(AgreementTestSuite))
 }
@@ -140,6 +140,7 @@ type blockChain struct {
 	vGetter             tsigVerifierGetter
 	app                 Application
 	logger              common.Logger
+	metrics             Metrics
 	pendingRandomnesses map[types.Position][]byte
 	configs             []blockChainConfig
 	pendingBlocks       pendingBlockRecords
@@ -152,7 +153,7 @@ type blockChain struct {
 
 func newBlockChain(nID types.NodeID, dMoment time.Time, initBlock *types.Block,
 	app Application, vGetter tsigVerifierGetter, signer *utils.Signer,
-	logger common.Logger) *blockChain {
+	logger common.Logger, metrics Metrics) *blockChain {
 	return &blockChain{
 		ID:            nID,
 		lastConfirmed: initBlock,
@@ -161,6 +162,7 @@ func newBlockChain(nID types.NodeID, dMoment time.Time, initBlock *types.Block,
 		vGetter:       vGetter,
 		app:           app,
 		logger:        logger,
+		metrics:       metrics,
 		dMoment:       dMoment,
 		pendingRandomnesses: make(
 			map[types.Position][]byte),
@@ -238,6 +240,7 @@ func (bc *blockChain) extractBlocks() (ret []*types.Block) {
 		ret = append(ret, c)
 		bc.lastDelivered = c
 	}
+	bc.reportPendingBlocks()
 	return
 }
 
@@ -300,6 +303,7 @@ func (bc *blockChain) addEmptyBlock(position types.Position) (
 		}
 		bc.confirmBlock(emptyB)
 		bc.checkIfBlocksConfirmed()
+		bc.reportPendingBlocks()
 		return emptyB
 	}
 	if bc.lastConfirmed != nil {
@@ -317,6 +321,7 @@ func (bc *blockChain) addEmptyBlock(position types.Position) (
 	} else {
 		return nil, ErrInvalidBlockHeight
 	}
+	defer bc.reportPendingBlocks()
 	return nil, bc.addPendingBlockRecord(pendingBlockRecord{position, nil})
 }
 
@@ -344,6 +349,7 @@ func (bc *blockChain) addBlock(b *types.Block) error {
 		confirmed = true
 	}
 	delete(bc.pendingRandomnesses, b.Position)
+	defer bc.reportPendingBlocks()
 	if !confirmed {
 		return bc.addPendingBlockRecord(pendingBlockRecord{b.Position, b})
 	}
@@ -465,6 +471,10 @@ func (bc *blockChain) addPendingBlockRecord(p pendingBlockRecord) error {
 	return nil
 }
 
+func (bc *blockChain) reportPendingBlocks() {
+	bc.metrics.SetPendingBlocks(len(bc.pendingBlocks), len(bc.confirmedBlocks))
+}
+
 func (bc *blockChain) checkIfBlocksConfirmed() {
 	var err error
 	for len(bc.pendingBlocks) > 0 {
@@ -151,7 +151,8 @@ func (s *BlockChainTestSuite) newBlockChain(initB *types.Block,
 		initHeight = initB.Position.Height
 	}
 	bc = newBlockChain(s.nID, s.dMoment, initB, test.NewApp(0, nil, nil),
-		&testTSigVerifierGetter{}, s.signer, &common.NullLogger{})
+		&testTSigVerifierGetter{}, s.signer, &common.NullLogger{},
+		&NullMetrics{})
 	// Provide the genesis round event.
 	s.Require().NoError(bc.notifyRoundEvents([]utils.RoundEventParam{
 		utils.RoundEventParam{
@@ -0,0 +1,242 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package codec implements a binary wire format for messages sent through
+// core.Network. Each encoded message starts with a protocol version and a
+// message type byte, followed by the RLP encoding of the message.
+package codec
+
+import (
+	"errors"
+
+	"github.com/dexon-foundation/dexon/rlp"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+)
+
+const (
+	// ProtocolVersion is the version of the wire format, it's bumped on
+	// incompatible changes.
+	ProtocolVersion byte = 1
+	// DefaultMaxSize is the default maximum size of an encoded message.
+	DefaultMaxSize = 8 * 1024 * 1024
+	headerSize     = 2
+)
+
+// Errors for codec package.
+var (
+	// ErrUnknownMessageType means the message type is not handled by the
+	// codec.
+	ErrUnknownMessageType = errors.New("unknown message type")
+	// ErrMessageTypeMismatch means the type byte in the payload doesn't match
+	// the message type carried along with it.
+	ErrMessageTypeMismatch = errors.New("message type mismatch")
+	// ErrVersionMismatch means the payload is encoded by another protocol
+	// version.
+	ErrVersionMismatch = errors.New("protocol version mismatch")
+	// ErrMessageTooLarge means the size of a message exceeds the limit.
+	ErrMessageTooLarge = errors.New("message too large")
+	// ErrMessageTooShort means the payload is shorter than the header.
+	ErrMessageTooShort = errors.New("message too short")
+)
+
+// MsgType is the type byte of an encoded message.
+type MsgType byte
+
+// MsgType enum, new types should be appended to keep values of existing ones.
+const (
+	MsgBlock MsgType = iota + 1
+	MsgVote
+	MsgAgreementResult
+	MsgEvidence
+	MsgDKGPrivateShare
+	MsgDKGMasterPublicKey
+	MsgDKGComplaint
+	MsgDKGPartialSignature
+	MsgDKGMPKReady
+	MsgDKGFinalize
+	MsgDKGSuccess
+)
+
+// msgTypeNames are names of message types used by test.Marshaller, they're
+// the same as test.DefaultMarshaller for compatibility.
+var msgTypeNames = map[MsgType]string{
+	MsgBlock:               "block",
+	MsgVote:                "vote",
+	MsgAgreementResult:     "agreement-result",
+	MsgEvidence:            "evidence",
+	MsgDKGPrivateShare:     "dkg-private-share",
+	MsgDKGMasterPublicKey:  "dkg-master-public-key",
+	MsgDKGComplaint:        "dkg-complaint",
+	MsgDKGPartialSignature: "dkg-partial-signature",
+	MsgDKGMPKReady:         "dkg-mpk-ready",
+	MsgDKGFinalize:         "dkg-finalize",
+	MsgDKGSuccess:          "dkg-success",
+}
+
+var msgTypesByName = func() map[string]MsgType {
+	m := make(map[string]MsgType, len(msgTypeNames))
+	for t, name := range msgTypeNames {
+		m[name] = t
+	}
+	return m
+}()
+
+func (t MsgType) String() string {
+	if name, exists := msgTypeNames[t]; exists {
+		return name
+	}
+	return "unknown"
+}
+
+// Codec encodes messages sent by core.Consensus, it implements
+// test.Marshaller interface.
+type Codec struct {
+	maxSize int
+}
+
+// NewCodec constructs a Codec instance, messages larger than maxSize are
+// rejected. DefaultMaxSize is used if maxSize is not positive.
+func NewCodec(maxSize int) *Codec {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	return &Codec{maxSize: maxSize}
+}
+
+// Encode encodes a message with the header.
+func (c *Codec) Encode(msg interface{}) (t MsgType, b []byte, err error) {
+	switch msg.(type) {
+	case *types.Block:
+		t = MsgBlock
+	case *types.Vote:
+		t = MsgVote
+	case *types.AgreementResult:
+		t = MsgAgreementResult
+	case *types.Evidence:
+		t = MsgEvidence
+	case *typesDKG.PrivateShare:
+		t = MsgDKGPrivateShare
+	case *typesDKG.MasterPublicKey:
+		t = MsgDKGMasterPublicKey
+	case *typesDKG.Complaint:
+		t = MsgDKGComplaint
+	case *typesDKG.PartialSignature:
+		t = MsgDKGPartialSignature
+	case *typesDKG.MPKReady:
+		t = MsgDKGMPKReady
+	case *typesDKG.Finalize:
+		t = MsgDKGFinalize
+	case *typesDKG.Success:
+		t = MsgDKGSuccess
+	default:
+		err = ErrUnknownMessageType
+		return
+	}
+	body, err := rlp.EncodeToBytes(msg)
+	if err != nil {
+		return
+	}
+	if headerSize+len(body) > c.maxSize {
+		err = ErrMessageTooLarge
+		return
+	}
+	b = make([]byte, headerSize, headerSize+len(body))
+	b[0] = ProtocolVersion
+	b[1] = byte(t)
+	b = append(b, body...)
+	return
+}
+
+// Decode decodes a message encoded by Encode.
+func (c *Codec) Decode(b []byte) (t MsgType, msg interface{}, err error) {
+	if len(b) > c.maxSize {
+		err = ErrMessageTooLarge
+		return
+	}
+	if len(b) < headerSize {
+		err = ErrMessageTooShort
+		return
+	}
+	if b[0] != ProtocolVersion {
+		err = ErrVersionMismatch
+		return
+	}
+	t = MsgType(b[1])
+	switch t {
+	case MsgBlock:
+		msg = &types.Block{}
+	case MsgVote:
+		msg = &types.Vote{}
+	case MsgAgreementResult:
+		msg = &types.AgreementResult{}
+	case MsgEvidence:
+		msg = &types.Evidence{}
+	case MsgDKGPrivateShare:
+		msg = &typesDKG.PrivateShare{}
+	case MsgDKGMasterPublicKey:
+		msg = typesDKG.NewMasterPublicKey()
+	case MsgDKGComplaint:
+		msg = &typesDKG.Complaint{}
+	case MsgDKGPartialSignature:
+		msg = &typesDKG.PartialSignature{}
+	case MsgDKGMPKReady:
+		msg = &typesDKG.MPKReady{}
+	case MsgDKGFinalize:
+		msg = &typesDKG.Finalize{}
+	case MsgDKGSuccess:
+		msg = &typesDKG.Success{}
+	default:
+		err = ErrUnknownMessageType
+		return
+	}
+	if err = rlp.DecodeBytes(b[headerSize:], msg); err != nil {
+		msg = nil
+	}
+	return
+}
+
+// Marshal implements test.Marshaller interface.
+func (c *Codec) Marshal(
+	msg interface{}) (msgType string, payload []byte, err error) {
+	t, payload, err := c.Encode(msg)
+	if err != nil {
+		return
+	}
+	msgType = t.String()
+	return
+}
+
+// Unmarshal implements test.Marshaller interface, ErrUnknownMessageType is
+// returned for types not handled by the codec without touching the payload.
+func (c *Codec) Unmarshal(
+	msgType string, payload []byte) (msg interface{}, err error) {
+	expected, exists := msgTypesByName[msgType]
+	if !exists {
+		err = ErrUnknownMessageType
+		return
+	}
+	t, msg, err := c.Decode(payload)
+	if err != nil {
+		return
+	}
+	if t != expected {
+		msg, err = nil, ErrMessageTypeMismatch
+	}
+	return
+}
@@ -0,0 +1,167 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package codec
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	cryptoDKG "github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+)
+
+type CodecTestSuite struct {
+	suite.Suite
+}
+
+func (s *CodecTestSuite) newSignature() crypto.Signature {
+	return crypto.Signature{
+		Type:      "ecdsa",
+		Signature: common.NewRandomHash().Bytes(),
+	}
+}
+
+func (s *CodecTestSuite) newVote() *types.Vote {
+	vote := types.NewVote(types.VotePreCom, common.NewRandomHash(), 2)
+	vote.ProposerID = types.NodeID{Hash: common.NewRandomHash()}
+	vote.Position = types.Position{Round: 1, Height: 100}
+	vote.PartialSignature = cryptoDKG.PartialSignature(s.newSignature())
+	vote.Signature = s.newSignature()
+	return vote
+}
+
+func (s *CodecTestSuite) TestEncodeDecode() {
+	var (
+		c      = NewCodec(0)
+		nID    = types.NodeID{Hash: common.NewRandomHash()}
+		vote   = s.newVote()
+		result = &types.AgreementResult{
+			BlockHash:  vote.BlockHash,
+			Position:   vote.Position,
+			Votes:      []types.Vote{*vote, *s.newVote()},
+			Randomness: common.NewRandomHash().Bytes(),
+			Format:     types.AgreementResultCertificate,
+		}
+	)
+	msgs := []interface{}{
+		&types.Block{
+			ProposerID:   nID,
+			ParentHash:   common.NewRandomHash(),
+			Hash:         common.NewRandomHash(),
+			Position:     vote.Position,
+			Timestamp:    time.Now().UTC(),
+			Payload:      []byte{1, 2, 3},
+			PayloadHash:  common.NewRandomHash(),
+			Witness:      types.Witness{Height: 3, Data: []byte{4}},
+			Randomness:   []byte{5, 6},
+			Signature:    s.newSignature(),
+			CRSSignature: s.newSignature(),
+		},
+		vote,
+		result,
+		&types.Evidence{
+			ProposerID: nID,
+			Type:       types.EvidenceForkVote,
+			Offender:   vote.ProposerID,
+			Payload:    []byte{7, 8},
+			Signature:  s.newSignature(),
+		},
+		&typesDKG.PartialSignature{
+			ProposerID:       nID,
+			Round:            2,
+			Hash:             common.NewRandomHash(),
+			PartialSignature: cryptoDKG.PartialSignature(s.newSignature()),
+			Signature:        s.newSignature(),
+		},
+		&typesDKG.MPKReady{
+			ProposerID: nID,
+			Round:      2,
+			Reset:      1,
+			Signature:  s.newSignature(),
+		},
+		&typesDKG.Finalize{
+			ProposerID: nID,
+			Round:      2,
+			Reset:      1,
+			Signature:  s.newSignature(),
+		},
+		&typesDKG.Success{
+			ProposerID: nID,
+			Round:      2,
+			Reset:      1,
+			Signature:  s.newSignature(),
+		},
+	}
+	for _, msg := range msgs {
+		msgType, payload, err := c.Marshal(msg)
+		s.Require().NoError(err)
+		s.Require().Equal(ProtocolVersion, payload[0])
+		s.Require().Equal(msgType, MsgType(payload[1]).String())
+		dec, err := c.Unmarshal(msgType, payload)
+		s.Require().NoError(err)
+		s.Require().Equal(msg, dec)
+		// The binary format should be more compact than JSON.
+		b, err := json.Marshal(msg)
+		s.Require().NoError(err)
+		s.Require().True(len(payload) < len(b))
+	}
+}
+
+func (s *CodecTestSuite) TestInvalidPayload() {
+	c := NewCodec(0)
+	msgType, payload, err := c.Marshal(s.newVote())
+	s.Require().NoError(err)
+	// Unknown types are left to others.
+	_, _, err = c.Marshal(struct{}{})
+	s.Require().Equal(ErrUnknownMessageType, err)
+	_, err = c.Unmarshal("packed-state-changes", payload)
+	s.Require().Equal(ErrUnknownMessageType, err)
+	// The type byte should match the message type.
+	_, err = c.Unmarshal("block", payload)
+	s.Require().Equal(ErrMessageTypeMismatch, err)
+	invalid := append([]byte(nil), payload...)
+	invalid[1] = 0xff
+	_, _, err = c.Decode(invalid)
+	s.Require().Equal(ErrUnknownMessageType, err)
+	// Version.
+	invalid = append([]byte(nil), payload...)
+	invalid[0] = ProtocolVersion + 1
+	_, err = c.Unmarshal(msgType, invalid)
+	s.Require().Equal(ErrVersionMismatch, err)
+	// Truncated payloads.
+	_, err = c.Unmarshal(msgType, payload[:1])
+	s.Require().Equal(ErrMessageTooShort, err)
+	_, err = c.Unmarshal(msgType, payload[:len(payload)-1])
+	s.Require().Error(err)
+	// Size limit.
+	small := NewCodec(len(payload) - 1)
+	_, _, err = small.Marshal(s.newVote())
+	s.Require().Equal(ErrMessageTooLarge, err)
+	_, err = small.Unmarshal(msgType, payload)
+	s.Require().Equal(ErrMessageTooLarge, err)
+}
+
+func TestCodec(t *testing.T) {
+	suite.Run(t, new(CodecTestSuite))
+}
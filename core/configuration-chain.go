@@ -67,6 +67,10 @@ type configurationChain struct {
 	dkg             *dkgProtocol
 	dkgRunPhases    []dkgStepFn
 	logger          common.Logger
+	metrics         Metrics
+	tickerFactory   TickerFactory
+	clock           Clock
+	events          *eventFeed
 	dkgLock         sync.RWMutex
 	dkgSigner       map[uint64]*dkgShareSecret
 	npks            map[uint64]*typesDKG.NodePublicKeys
@@ -82,10 +86,15 @@ type configurationChain struct {
 	pendingPrvShare map[types.NodeID]*typesDKG.PrivateShare
 	// TODO(jimmy-dexon): add timeout to pending psig.
 	pendingPsig  map[common.Hash][]*typesDKG.PartialSignature
+	sigCache     *utils.SignatureCache
 	prevHash     common.Hash
 	dkgCtx       context.Context
 	dkgCtxCancel context.CancelFunc
 	dkgRunning   bool
+	// A snapshot of DKG status, which could be read without waiting for
+	// dkgLock held by DKG phases.
+	dkgStatusLock     sync.Mutex
+	dkgStatusSnapshot *DKGStatus
 }
 
 func newConfigurationChain(
@@ -94,12 +103,14 @@ func newConfigurationChain(
 	gov Governance,
 	cache *utils.NodeSetCache,
 	dbInst db.Database,
-	logger common.Logger) *configurationChain {
+	logger common.Logger,
+	metrics Metrics) *configurationChain {
 	configurationChain := &configurationChain{
 		ID:          ID,
 		recv:        recv,
 		gov:         gov,
 		logger:      logger,
+		metrics:     metrics,
 		dkgSigner:   make(map[uint64]*dkgShareSecret),
 		npks:        make(map[uint64]*typesDKG.NodePublicKeys),
 		tsig:        make(map[common.Hash]*tsigProtocol),
@@ -109,6 +120,10 @@ func newConfigurationChain(
 		db:          dbInst,
 		pendingPsig: make(map[common.Hash][]*typesDKG.PartialSignature),
 	}
+	configurationChain.clock = systemClock{}
+	configurationChain.tickerFactory = newClockTickerFactory(
+		configurationChain.clock)
+	configurationChain.events = newEventFeed()
 	configurationChain.initDKGPhasesFunc()
 	return configurationChain
 }
@@ -153,12 +168,13 @@ func (cc *configurationChain) abortDKGNoLock(
 		cc.dkgLock.Lock()
 		if cc.dkgRunning == false {
 			cc.dkg = nil
+			cc.updateDKGStatusNoLock()
 			break
 		}
 		select {
 		case <-ctx.Done():
 			return false
-		case <-time.After(100 * time.Millisecond):
+		case <-cc.clock.After(100 * time.Millisecond):
 		}
 		cc.dkgLock.Unlock()
 	}
@@ -171,61 +187,65 @@ func (cc *configurationChain) abortDKGNoLock(
 func (cc *configurationChain) registerDKG(
 	parentCtx context.Context,
 	round, reset uint64,
-	threshold int) {
+	threshold int) error {
 	cc.dkgLock.Lock()
 	defer cc.dkgLock.Unlock()
 	if cc.dkg != nil {
 		// Make sure we only proceed when cc.dkg is nil.
 		if !cc.abortDKGNoLock(parentCtx, round, reset) {
-			return
+			return nil
 		}
 		select {
 		case <-parentCtx.Done():
-			return
+			return nil
 		default:
 		}
 		if cc.dkg != nil {
-			// This panic would only raise when multiple attampts to register
+			// This error would only raise when multiple attampts to register
 			// a DKG protocol at the same time.
-			panic(ErrMismatchDKG{
+			return ErrMismatchDKG{
 				expectRound: round,
 				expectReset: reset,
 				actualRound: cc.dkg.round,
 				actualReset: cc.dkg.reset,
-			})
+			}
 		}
 	}
 	notarySet, err := cc.cache.GetNotarySet(round)
 	if err != nil {
 		cc.logger.Error("Error getting notary set from cache", "error", err)
-		return
+		return nil
 	}
-	cc.notarySet = notarySet
-	cc.pendingPrvShare = make(map[types.NodeID]*typesDKG.PrivateShare)
-	cc.mpkReady = false
-	cc.dkg, err = recoverDKGProtocol(cc.ID, cc.recv, round, reset, cc.db)
-	cc.dkgCtx, cc.dkgCtxCancel = context.WithCancel(parentCtx)
+	dkg, err := recoverDKGProtocol(cc.ID, cc.recv, round, reset, cc.db)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	if cc.dkg == nil {
-		cc.dkg = newDKGProtocol(
+	recovered := dkg != nil
+	if !recovered {
+		dkg = newDKGProtocol(
 			cc.ID,
 			cc.recv,
 			round,
 			reset,
 			threshold)
-
+	}
+	cc.notarySet = notarySet
+	cc.pendingPrvShare = make(map[types.NodeID]*typesDKG.PrivateShare)
+	cc.mpkReady = false
+	cc.dkg = dkg
+	cc.dkgCtx, cc.dkgCtxCancel = context.WithCancel(parentCtx)
+	cc.updateDKGStatusNoLock()
+	if !recovered {
 		err = cc.db.PutOrUpdateDKGProtocol(cc.dkg.toDKGProtocolInfo())
 		if err != nil {
 			cc.logger.Error("Error put or update DKG protocol", "error",
 				err)
-			return
+			return nil
 		}
 	}
 
 	go func() {
-		ticker := newTicker(cc.gov, round, TickerDKG)
+		ticker := cc.tickerFactory(cc.gov, round, TickerDKG)
 		defer ticker.Stop()
 		<-ticker.Tick()
 		cc.dkgLock.Lock()
@@ -234,6 +254,7 @@ func (cc *configurationChain) registerDKG(
 			cc.dkg.proposeMPKReady()
 		}
 	}()
+	return nil
 }
 
 func (cc *configurationChain) runDKGPhaseOne(round uint64, reset uint64) error {
@@ -260,7 +281,7 @@ func (cc *configurationChain) runDKGPhaseOne(round uint64, reset uint64) error {
 		select {
 		case <-cc.dkgCtx.Done():
 			err = ErrDKGAborted
-		case <-time.After(500 * time.Millisecond):
+		case <-cc.clock.After(500 * time.Millisecond):
 		}
 		cc.dkgLock.Lock()
 	}
@@ -360,7 +381,7 @@ func (cc *configurationChain) runDKGPhaseNine(round uint64, reset uint64) error
 		select {
 		case <-cc.dkgCtx.Done():
 			err = ErrDKGAborted
-		case <-time.After(500 * time.Millisecond):
+		case <-cc.clock.After(500 * time.Millisecond):
 		}
 		cc.dkgLock.Lock()
 	}
@@ -396,9 +417,14 @@ func (cc *configurationChain) runDKGPhaseNine(round uint64, reset uint64) error
 	if err != nil {
 		return err
 	}
-	// Save private shares to DB.
-	if err =
-		cc.db.PutDKGPrivateKey(round, reset, *signer.privateKey); err != nil {
+	// Save private shares to DB along with the DKG protocol info marking this
+	// phase done, they should not be inconsistent after crashed.
+	info := cc.dkg.toDKGProtocolInfo()
+	info.Step++
+	batch := cc.db.NewBatch()
+	batch.PutDKGPrivateKey(round, reset, *signer.privateKey)
+	batch.PutOrUpdateDKGProtocol(info)
+	if err = batch.Commit(); err != nil {
 		return err
 	}
 	cc.dkg.proposeSuccess()
@@ -475,6 +501,7 @@ func (cc *configurationChain) runDKG(
 			cc.dkg = nil
 		}
 		cc.dkgRunning = false
+		cc.updateDKGStatusNoLock()
 	}()
 	wg := sync.WaitGroup{}
 	var dkgError error
@@ -482,6 +509,7 @@ func (cc *configurationChain) runDKG(
 	// context.
 	ctx := cc.dkgCtx
 	cc.dkg.step = skipPhase
+	cc.updateDKGStatusNoLock()
 	for i := skipPhase; i < len(cc.dkgRunPhases); i++ {
 		wg.Add(1)
 		event.RegisterHeight(dkgBeginHeight+phaseHeight*uint64(i), func(uint64) {
@@ -499,10 +527,19 @@ func (cc *configurationChain) runDKG(
 				default:
 				}
 
-				err := cc.dkgRunPhases[cc.dkg.step](round, reset)
+				step, start := cc.dkg.step, cc.clock.Now()
+				cc.events.emit(&DKGPhaseEvent{
+					Round: round,
+					Reset: reset,
+					Phase: step,
+				})
+				err := cc.dkgRunPhases[step](round, reset)
+				cc.metrics.ObserveDKGPhase(
+					round, reset, step, cc.clock.Now().Sub(start))
 				if err == nil || err == ErrSkipButNoError {
 					err = nil
 					cc.dkg.step++
+					cc.updateDKGStatusNoLock()
 					err = cc.db.PutOrUpdateDKGProtocol(cc.dkg.toDKGProtocolInfo())
 					if err != nil {
 						cc.logger.Error("Failed to save DKG Protocol",
@@ -699,21 +736,20 @@ func (cc *configurationChain) runTSig(
 	if _, exist := cc.tsig[hash]; exist {
 		return crypto.Signature{}, ErrTSigAlreadyRunning
 	}
-	cc.tsig[hash] = newTSigProtocol(npks, hash)
+	start := cc.clock.Now()
+	tsig := newTSigProtocol(npks, hash)
+	tsig.sigCache = cc.sigCache
+	cc.tsig[hash] = tsig
 	pendingPsig := cc.pendingPsig[hash]
 	delete(cc.pendingPsig, hash)
-	go func() {
-		for _, psig := range pendingPsig {
-			if err := cc.processPartialSignature(psig); err != nil {
-				cc.logger.Error("Failed to process partial signature",
-					"nodeID", cc.ID,
-					"error", err)
-			}
-		}
-	}()
+	for _, err := range tsig.processPartialSignatures(pendingPsig) {
+		cc.logger.Error("Failed to process partial signature",
+			"nodeID", cc.ID,
+			"error", err)
+	}
 	timeout := make(chan struct{}, 1)
 	go func() {
-		time.Sleep(wait)
+		cc.clock.Sleep(wait)
 		timeout <- struct{}{}
 		cc.tsigReady.Broadcast()
 	}()
@@ -734,6 +770,13 @@ func (cc *configurationChain) runTSig(
 	if err != nil {
 		return crypto.Signature{}, err
 	}
+	elapsed := cc.clock.Now().Sub(start)
+	cc.metrics.ObserveTSigLatency(round, elapsed)
+	cc.events.emit(&TSigCompletedEvent{
+		Round:   round,
+		Hash:    hash,
+		Elapsed: elapsed,
+	})
 	return signature, nil
 }
 
@@ -777,7 +820,7 @@ func (cc *configurationChain) processPartialSignature(
 	cc.tsigReady.L.Lock()
 	defer cc.tsigReady.L.Unlock()
 	if _, exist := cc.tsig[psig.Hash]; !exist {
-		ok, err := utils.VerifyDKGPartialSignatureSignature(psig)
+		ok, err := cc.sigCache.VerifyDKGPartialSignatureSignature(psig)
 		if err != nil {
 			return err
 		}
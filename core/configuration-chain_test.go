@@ -261,13 +261,14 @@ func (s *ConfigurationChainTestSuite) runDKG(
 		s.Require().NoError(err)
 		cfgChains[nID] = newConfigurationChain(nID,
 			newTestCCReceiver(nID, recv), gov, cache, dbInst,
-			&common.NullLogger{})
+			&common.NullLogger{}, &NullMetrics{})
 		recv.nodes[nID] = cfgChains[nID]
 		recv.govs[nID] = gov
 	}
 
 	for _, cc := range cfgChains {
-		cc.registerDKG(context.Background(), round, reset, k)
+		s.Require().NoError(cc.registerDKG(
+			context.Background(), round, reset, k))
 	}
 
 	for _, gov := range recv.govs {
@@ -396,7 +397,7 @@ func (s *ConfigurationChainTestSuite) TestDKGMasterPublicKeyDelayAdd() {
 		s.Require().NoError(err)
 		cfgChains[nID] = newConfigurationChain(
 			nID, newTestCCReceiver(nID, recv), gov, cache, dbInst,
-			&common.NullLogger{})
+			&common.NullLogger{}, &NullMetrics{})
 		recv.nodes[nID] = cfgChains[nID]
 		recv.govs[nID] = gov
 	}
@@ -405,10 +406,12 @@ func (s *ConfigurationChainTestSuite) TestDKGMasterPublicKeyDelayAdd() {
 		if nID == delayNode {
 			continue
 		}
-		cc.registerDKG(context.Background(), round, reset, k)
+		s.Require().NoError(cc.registerDKG(
+			context.Background(), round, reset, k))
 	}
 	time.Sleep(lambdaDKG)
-	cfgChains[delayNode].registerDKG(context.Background(), round, reset, k)
+	s.Require().NoError(cfgChains[delayNode].registerDKG(
+		context.Background(), round, reset, k))
 
 	for _, gov := range recv.govs {
 		s.Require().Len(gov.DKGMasterPublicKeys(round), n-1)
@@ -468,13 +471,14 @@ func (s *ConfigurationChainTestSuite) TestDKGComplaintDelayAdd() {
 		s.Require().NoError(err)
 		recvs[nID] = newTestCCReceiver(nID, recv)
 		cfgChains[nID] = newConfigurationChain(nID, recvs[nID], gov, cache,
-			dbInst, &common.NullLogger{})
+			dbInst, &common.NullLogger{}, &NullMetrics{})
 		recv.nodes[nID] = cfgChains[nID]
 		recv.govs[nID] = gov
 	}
 
 	for _, cc := range cfgChains {
-		cc.registerDKG(context.Background(), round, reset, k)
+		s.Require().NoError(cc.registerDKG(
+			context.Background(), round, reset, k))
 	}
 
 	for _, gov := range recv.govs {
@@ -650,7 +654,7 @@ func (s *ConfigurationChainTestSuite) TestDKGSignerRecoverFromDB() {
 		// Create a cloned configurationChain, we should be able to recover
 		// the DKG signer.
 		clonedCC := newConfigurationChain(
-			cc.ID, cc.recv, cc.gov, cc.cache, cc.db, cc.logger,
+			cc.ID, cc.recv, cc.gov, cc.cache, cc.db, cc.logger, cc.metrics,
 		)
 		psig2, err := clonedCC.preparePartialSignature(round, hash)
 		s.Require().NoError(err)
@@ -692,11 +696,11 @@ func (s *ConfigurationChainTestSuite) TestDKGAbort() {
 	nID := s.nIDs[0]
 	cc := newConfigurationChain(nID,
 		newTestCCReceiver(nID, recv), gov, cache, dbInst,
-		&common.NullLogger{})
+		&common.NullLogger{}, &NullMetrics{})
 	recv.nodes[nID] = cc
 	recv.govs[nID] = gov
 	// The first register should not be blocked.
-	cc.registerDKG(context.Background(), round, reset, k)
+	s.Require().NoError(cc.registerDKG(context.Background(), round, reset, k))
 	// We should be blocked because DKGReady is not enough.
 	errs := make(chan error, 1)
 	evt := newTestEvent()
@@ -716,7 +720,7 @@ func (s *ConfigurationChainTestSuite) TestDKGAbort() {
 	}() {
 		time.Sleep(100 * time.Millisecond)
 	}
-	cc.registerDKG(context.Background(), round, reset+1, k)
+	s.Require().NoError(cc.registerDKG(context.Background(), round, reset+1, k))
 	err = <-errs
 	s.Require().EqualError(ErrDKGAborted, err.Error())
 	go func() {
@@ -734,7 +738,8 @@ func (s *ConfigurationChainTestSuite) TestDKGAbort() {
 	}() {
 		time.Sleep(100 * time.Millisecond)
 	}
-	cc.registerDKG(context.Background(), round+1, reset+1, k)
+	s.Require().NoError(cc.registerDKG(
+		context.Background(), round+1, reset+1, k))
 	err = <-errs
 	s.Require().EqualError(ErrDKGAborted, err.Error())
 	go func() {
@@ -771,11 +776,68 @@ func (s *ConfigurationChainTestSuite) TestDKGAbort() {
 	err = <-errs
 	s.Require().EqualError(ErrDKGAborted, err.Error())
 	// Abort while not running yet, should return "aborted".
-	cc.registerDKG(context.Background(), round+1, reset+1, k)
+	s.Require().NoError(cc.registerDKG(
+		context.Background(), round+1, reset+1, k))
 	aborted = cc.abortDKG(context.Background(), round+1, reset+1)
 	s.Require().True(aborted)
 }
 
+// dkgFailDB fails to load the DKG protocol.
+type dkgFailDB struct {
+	db.Database
+}
+
+func (d *dkgFailDB) GetDKGProtocol() (db.DKGProtocolInfo, error) {
+	return db.DKGProtocolInfo{}, errors.New("dkg fail db")
+}
+
+func (s *ConfigurationChainTestSuite) TestRegisterDKGError() {
+	round := DKGDelayRound
+	prvKeys, pubKeys, err := test.NewKeys(4)
+	s.Require().NoError(err)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		pubKeys, 100*time.Millisecond, &common.NullLogger{}, true,
+	), ConfigRoundShift)
+	s.Require().NoError(err)
+	gov.CatchUpWithRound(round + 1)
+	dbInst, err := db.NewMemBackedDB()
+	s.Require().NoError(err)
+	nID := types.NewNodeID(prvKeys[0].PublicKey())
+	cc := newConfigurationChain(nID,
+		newTestCCReceiver(nID, newTestCCGlobalReceiver(s)), gov,
+		utils.NewNodeSetCache(gov), &dkgFailDB{Database: dbInst},
+		&common.NullLogger{}, &NullMetrics{})
+	// Errors from db are returned instead of panic.
+	s.Require().EqualError(
+		cc.registerDKG(context.Background(), round, 0, 1), "dkg fail db")
+	s.Require().Nil(cc.dkg)
+}
+
+func (s *ConfigurationChainTestSuite) TestDKGStatusNotBlocked() {
+	dbInst, err := db.NewMemBackedDB()
+	s.Require().NoError(err)
+	cc := newConfigurationChain(types.NodeID{}, nil, nil, nil, dbInst,
+		&common.NullLogger{}, &NullMetrics{})
+	s.Require().Nil(cc.dkgStatus())
+	// DKG phases hold dkgLock while running.
+	cc.dkgLock.Lock()
+	defer cc.dkgLock.Unlock()
+	cc.dkg = &dkgProtocol{round: 2, reset: 1, step: 3}
+	cc.dkgRunning = true
+	cc.updateDKGStatusNoLock()
+	statusChan := make(chan *DKGStatus, 1)
+	go func() {
+		statusChan <- cc.dkgStatus()
+	}()
+	select {
+	case status := <-statusChan:
+		s.Require().Equal(
+			&DKGStatus{Round: 2, Reset: 1, Step: 3, Running: true}, status)
+	case <-time.After(time.Second):
+		s.FailNow("blocked by dkgLock")
+	}
+}
+
 func TestConfigurationChain(t *testing.T) {
 	suite.Run(t, new(ConfigurationChainTestSuite))
 }
@@ -0,0 +1,221 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// Default values of consensus options.
+const (
+	defaultMsgChanSize           = 1024
+	defaultPriorityMsgChanSize   = 1024
+	defaultProcessBlockChanSize  = 1024
+	defaultTSigVerifierCacheSize = 7
+	defaultSignatureCacheSize    = 8192
+)
+
+// TickerFactory creates a ticker of tickerType for a round, tickers driven by
+// the Clock of Consensus are used when not provided.
+type TickerFactory func(
+	gov Governance, round uint64, tickerType TickerType) Ticker
+
+// ConsensusOption tunes internals of a Consensus instance.
+type ConsensusOption func(*consensusOptions)
+
+type consensusOptions struct {
+	msgChanSize           int
+	priorityMsgChanSize   int
+	processBlockChanSize  int
+	tsigVerifierCacheSize int
+	nonBlocking           bool
+	tickerFactory         TickerFactory
+	clock                 Clock
+	agreementResultFormat types.AgreementResultFormat
+	admission             AdmissionController
+	sigVerifyWorkers      int
+	sigCacheSize          int
+	leaderSelector        LeaderSelector
+	metrics               Metrics
+	livenessPolicy        LivenessPolicy
+}
+
+func newConsensusOptions(
+	nonBlocking bool, opts []ConsensusOption) *consensusOptions {
+	o := &consensusOptions{
+		msgChanSize:           defaultMsgChanSize,
+		priorityMsgChanSize:   defaultPriorityMsgChanSize,
+		processBlockChanSize:  defaultProcessBlockChanSize,
+		tsigVerifierCacheSize: defaultTSigVerifierCacheSize,
+		nonBlocking:           nonBlocking,
+		clock:                 systemClock{},
+		sigCacheSize:          defaultSignatureCacheSize,
+		leaderSelector:        CRSDistanceLeaderSelector{},
+		metrics:               &NullMetrics{},
+		livenessPolicy:        DefaultLivenessPolicy(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.tickerFactory == nil {
+		o.tickerFactory = newClockTickerFactory(o.clock)
+	}
+	return o
+}
+
+// WithMsgChanSize sets the buffer size of the channel for messages received
+// from network, negative sizes are ignored.
+func WithMsgChanSize(size int) ConsensusOption {
+	return func(o *consensusOptions) {
+		if size >= 0 {
+			o.msgChanSize = size
+		}
+	}
+}
+
+// WithPriorityMsgChanSize sets the buffer size of the channel for messages
+// generated locally, which are handled before those from network. Negative
+// sizes are ignored.
+func WithPriorityMsgChanSize(size int) ConsensusOption {
+	return func(o *consensusOptions) {
+		if size >= 0 {
+			o.priorityMsgChanSize = size
+		}
+	}
+}
+
+// WithProcessBlockChanSize sets the buffer size of the channel for blocks
+// waiting to be added to the block chain, negative sizes are ignored.
+func WithProcessBlockChanSize(size int) ConsensusOption {
+	return func(o *consensusOptions) {
+		if size >= 0 {
+			o.processBlockChanSize = size
+		}
+	}
+}
+
+// WithTSigVerifierCacheSize sets the count of rounds of TSig verifiers to
+// keep, non-positive sizes are ignored.
+func WithTSigVerifierCacheSize(size int) ConsensusOption {
+	return func(o *consensusOptions) {
+		if size > 0 {
+			o.tsigVerifierCacheSize = size
+		}
+	}
+}
+
+// WithNonBlockingApp decides if the Application is wrapped by a non-blocking
+// layer, which overrides the choice of the constructor.
+func WithNonBlockingApp(nonBlocking bool) ConsensusOption {
+	return func(o *consensusOptions) {
+		o.nonBlocking = nonBlocking
+	}
+}
+
+// WithTickerFactory replaces the way tickers for BA and DKG are created.
+func WithTickerFactory(f TickerFactory) ConsensusOption {
+	return func(o *consensusOptions) {
+		if f != nil {
+			o.tickerFactory = f
+		}
+	}
+}
+
+// WithClock replaces the clock used by Consensus, which is useful to drive
+// Consensus by a virtual clock in tests.
+func WithClock(clock Clock) ConsensusOption {
+	return func(o *consensusOptions) {
+		if clock != nil {
+			o.clock = clock
+		}
+	}
+}
+
+// WithMetrics sets the collector of metrics reported by Consensus, nothing
+// is collected when not provided.
+func WithMetrics(metrics Metrics) ConsensusOption {
+	return func(o *consensusOptions) {
+		if metrics != nil {
+			o.metrics = metrics
+		}
+	}
+}
+
+// WithAgreementResultFormat sets the format of agreement results proposed by
+// this node, results in all formats are accepted regardless of this option.
+// Results in rounds before DKGDelayRound always carry votes, unknown formats
+// are ignored.
+func WithAgreementResultFormat(
+	format types.AgreementResultFormat) ConsensusOption {
+	return func(o *consensusOptions) {
+		if format < types.MaxAgreementResultFormat {
+			o.agreementResultFormat = format
+		}
+	}
+}
+
+// WithAdmissionController sets the stage deciding if messages received from
+// network are processed, all messages are admitted when not provided.
+func WithAdmissionController(ac AdmissionController) ConsensusOption {
+	return func(o *consensusOptions) {
+		o.admission = ac
+	}
+}
+
+// WithSignatureVerifyWorkers sets the count of workers verifying signatures of
+// messages from network before they're processed, the pipeline is disabled
+// when it's zero, which is the default. Negative counts are ignored.
+func WithSignatureVerifyWorkers(workers int) ConsensusOption {
+	return func(o *consensusOptions) {
+		if workers >= 0 {
+			o.sigVerifyWorkers = workers
+		}
+	}
+}
+
+// WithSignatureCacheSize sets the count of ECDSA signature verification
+// results to keep, non-positive sizes are ignored.
+func WithSignatureCacheSize(size int) ConsensusOption {
+	return func(o *consensusOptions) {
+		if size > 0 {
+			o.sigCacheSize = size
+		}
+	}
+}
+
+// WithLeaderSelector replaces the way leaders of BA are selected, the
+// CRSDistanceLeaderSelector is used when not provided. All nodes in the
+// network should use the same one.
+func WithLeaderSelector(selector LeaderSelector) ConsensusOption {
+	return func(o *consensusOptions) {
+		if selector != nil {
+			o.leaderSelector = selector
+		}
+	}
+}
+
+// WithLivenessPolicy replaces the way Consensus reacts when no block is
+// delivered for too long, DefaultLivenessPolicy is used when not provided.
+// Policies failing LivenessPolicy.Verify are ignored.
+func WithLivenessPolicy(policy LivenessPolicy) ConsensusOption {
+	return func(o *consensusOptions) {
+		if policy.Verify() == nil {
+			o.livenessPolicy = policy
+		}
+	}
+}
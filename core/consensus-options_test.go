@@ -0,0 +1,124 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type ConsensusOptionsTestSuite struct {
+	suite.Suite
+}
+
+func (s *ConsensusOptionsTestSuite) TestDefault() {
+	o := newConsensusOptions(true, nil)
+	s.Require().Equal(defaultMsgChanSize, o.msgChanSize)
+	s.Require().Equal(defaultPriorityMsgChanSize, o.priorityMsgChanSize)
+	s.Require().Equal(defaultProcessBlockChanSize, o.processBlockChanSize)
+	s.Require().Equal(defaultTSigVerifierCacheSize, o.tsigVerifierCacheSize)
+	s.Require().True(o.nonBlocking)
+	s.Require().NotNil(o.tickerFactory)
+	s.Require().Equal(types.AgreementResultVotes, o.agreementResultFormat)
+	s.Require().Nil(o.admission)
+	s.Require().Equal(0, o.sigVerifyWorkers)
+	s.Require().Equal(defaultSignatureCacheSize, o.sigCacheSize)
+	s.Require().Equal(CRSDistanceLeaderSelector{}, o.leaderSelector)
+	s.Require().Equal(&NullMetrics{}, o.metrics)
+	s.Require().Equal(DefaultLivenessPolicy(), o.livenessPolicy)
+	s.Require().False(newConsensusOptions(false, nil).nonBlocking)
+}
+
+func (s *ConsensusOptionsTestSuite) TestOverride() {
+	called := false
+	factory := func(Governance, uint64, TickerType) Ticker {
+		called = true
+		return newDefaultTicker(time.Second, systemClock{})
+	}
+	limiter := NewRateLimiter(DefaultRateLimitConfig(), nil)
+	metrics := NewMetricsRegistry()
+	policy := LivenessPolicy{
+		DeliveryTimeout: time.Second,
+		Actions:         LivenessActionStop,
+	}
+	o := newConsensusOptions(true, []ConsensusOption{
+		WithMsgChanSize(1),
+		WithPriorityMsgChanSize(2),
+		WithProcessBlockChanSize(0),
+		WithTSigVerifierCacheSize(3),
+		WithNonBlockingApp(false),
+		WithTickerFactory(factory),
+		WithAgreementResultFormat(types.AgreementResultCertificate),
+		WithAdmissionController(limiter),
+		WithSignatureVerifyWorkers(runtime.NumCPU()),
+		WithSignatureCacheSize(4),
+		WithLeaderSelector(RoundRobinLeaderSelector{}),
+		WithMetrics(metrics),
+		WithLivenessPolicy(policy),
+	})
+	s.Require().Equal(1, o.msgChanSize)
+	s.Require().Equal(2, o.priorityMsgChanSize)
+	s.Require().Equal(0, o.processBlockChanSize)
+	s.Require().Equal(3, o.tsigVerifierCacheSize)
+	s.Require().False(o.nonBlocking)
+	s.Require().Equal(
+		types.AgreementResultCertificate, o.agreementResultFormat)
+	s.Require().Equal(limiter, o.admission)
+	s.Require().Equal(runtime.NumCPU(), o.sigVerifyWorkers)
+	s.Require().Equal(4, o.sigCacheSize)
+	s.Require().Equal(RoundRobinLeaderSelector{}, o.leaderSelector)
+	s.Require().Equal(metrics, o.metrics)
+	s.Require().Equal(policy, o.livenessPolicy)
+	o.tickerFactory(nil, 0, TickerBA).Stop()
+	s.Require().True(called)
+	// Invalid values are ignored.
+	o = newConsensusOptions(false, []ConsensusOption{
+		WithMsgChanSize(-1),
+		WithTSigVerifierCacheSize(0),
+		WithTickerFactory(nil),
+		WithAgreementResultFormat(types.MaxAgreementResultFormat),
+		WithSignatureVerifyWorkers(-1),
+		WithSignatureCacheSize(0),
+		WithLeaderSelector(nil),
+		WithMetrics(nil),
+		// Required fields for actions are not provided.
+		WithLivenessPolicy(LivenessPolicy{
+			DeliveryTimeout: time.Second,
+			Actions:         LivenessActionNotify,
+		}),
+		WithLivenessPolicy(LivenessPolicy{Actions: LivenessActionStop}),
+	})
+	s.Require().Equal(defaultMsgChanSize, o.msgChanSize)
+	s.Require().Equal(defaultTSigVerifierCacheSize, o.tsigVerifierCacheSize)
+	s.Require().NotNil(o.tickerFactory)
+	s.Require().Equal(types.AgreementResultVotes, o.agreementResultFormat)
+	s.Require().Equal(0, o.sigVerifyWorkers)
+	s.Require().Equal(defaultSignatureCacheSize, o.sigCacheSize)
+	s.Require().Equal(CRSDistanceLeaderSelector{}, o.leaderSelector)
+	s.Require().Equal(&NullMetrics{}, o.metrics)
+	s.Require().Equal(DefaultLivenessPolicy(), o.livenessPolicy)
+}
+
+func TestConsensusOptions(t *testing.T) {
+	suite.Run(t, new(ConsensusOptionsTestSuite))
+}
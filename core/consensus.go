@@ -18,16 +18,19 @@
 package core
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dexon-foundation/dexon-consensus/common"
 	"github.com/dexon-foundation/dexon-consensus/core/crypto"
 	cryptoDKG "github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
 	"github.com/dexon-foundation/dexon-consensus/core/db"
+	"github.com/dexon-foundation/dexon-consensus/core/evidence"
 	"github.com/dexon-foundation/dexon-consensus/core/types"
 	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
 	"github.com/dexon-foundation/dexon-consensus/core/utils"
@@ -55,6 +58,20 @@ var (
 		"randomness of block is incorrect")
 	ErrCannotVerifyBlockRandomness = fmt.Errorf(
 		"cannot verify block randomness")
+	ErrConsensusStarted = fmt.Errorf(
+		"consensus is already started")
+	ErrGenesisDKGDelayRound = fmt.Errorf(
+		"starting from genesis with DKGDelayRound == 0 is not supported")
+	ErrMasterPublicKeyNotFound = fmt.Errorf(
+		"master public key not found")
+	ErrBlockNotFinalized = fmt.Errorf(
+		"block is not finalized")
+	ErrTSigVerifierNotReady = fmt.Errorf(
+		"tsig verifier is not ready")
+	ErrInvalidDBTip = fmt.Errorf(
+		"tip of compaction chain in db is invalid")
+	ErrDBGovernanceMismatch = fmt.Errorf(
+		"db mismatches governance")
 )
 
 type selfAgreementResult types.AgreementResult
@@ -158,6 +175,7 @@ func (recv *consensusBAReceiver) ProposeVote(vote *types.Vote) {
 		recv.consensus.logger.Error("Failed to prepare vote", "error", err)
 		return
 	}
+	recv.consensus.events.emit(&VoteProposedEvent{Vote: vote})
 	go func() {
 		if err := recv.agreementModule.processVote(vote); err != nil {
 			recv.consensus.logger.Error("Failed to process self vote",
@@ -199,6 +217,8 @@ func (recv *consensusBAReceiver) ConfirmBlock(
 		aID   = recv.agreementModule.agreementID()
 	)
 
+	recv.consensus.metrics.ObserveBAPeriods(
+		aID, recv.agreementModule.data.period)
 	isEmptyBlockConfirmed := hash == common.Hash{}
 	if isEmptyBlockConfirmed {
 		recv.consensus.logger.Info("Empty block is confirmed", "position", aID)
@@ -242,7 +262,7 @@ func (recv *consensusBAReceiver) ConfirmBlock(
 					select {
 					case block = <-ch:
 						break PullBlockLoop
-					case <-time.After(1 * time.Second):
+					case <-recv.consensus.clock.After(1 * time.Second):
 					}
 				}
 				recv.consensus.logger.Debug("Receive unknown block",
@@ -279,6 +299,7 @@ func (recv *consensusBAReceiver) ConfirmBlock(
 				voteList = append(voteList, *vote)
 			}
 		}
+		format := types.AgreementResultVotes
 		if block.Position.Round >= DKGDelayRound {
 			rand, err := cryptoDKG.RecoverSignature(psigs, IDs)
 			if err != nil {
@@ -287,6 +308,7 @@ func (recv *consensusBAReceiver) ConfirmBlock(
 					"error", err)
 			} else {
 				block.Randomness = rand.Signature[:]
+				format = recv.consensus.agreementResultFormat
 			}
 		} else {
 			block.Randomness = NoRand
@@ -299,11 +321,13 @@ func (recv *consensusBAReceiver) ConfirmBlock(
 				Votes:        voteList,
 				IsEmptyBlock: isEmptyBlockConfirmed,
 				Randomness:   block.Randomness,
+				Format:       format,
 			}
 			// touchAgreementResult does not support concurrent access.
 			go func() {
 				recv.consensus.priorityMsgChan <- (*selfAgreementResult)(result)
 			}()
+			recv.consensus.events.emit(&AgreementResultEvent{Result: result})
 			recv.consensus.logger.Debug("Broadcast AgreementResult",
 				"result", result)
 			recv.consensus.network.BroadcastAgreementResult(result)
@@ -349,7 +373,7 @@ func (recv *consensusBAReceiver) ConfirmBlock(
 					select {
 					case block = <-ch:
 						break PullBlockLoop
-					case <-time.After(1 * time.Second):
+					case <-recv.consensus.clock.After(1 * time.Second):
 					}
 				}
 				recv.consensus.logger.Info("Receive parent block",
@@ -393,7 +417,14 @@ func (recv *consensusBAReceiver) PullBlocks(hashes common.Hashes) {
 }
 
 func (recv *consensusBAReceiver) ReportForkVote(v1, v2 *types.Vote) {
-	recv.consensus.gov.ReportForkVote(v1, v2)
+	recv.consensus.events.emit(&ForkVoteEvent{Vote1: v1, Vote2: v2})
+	if err := recv.consensus.evidencePool.AddForkVote(
+		v1, v2); err != nil && err != evidence.ErrEvidenceExists {
+		recv.consensus.logger.Error("Failed to report fork vote",
+			"vote1", v1,
+			"vote2", v2,
+			"error", err)
+	}
 }
 
 func (recv *consensusBAReceiver) ReportForkBlock(b1, b2 *types.Block) {
@@ -401,7 +432,22 @@ func (recv *consensusBAReceiver) ReportForkBlock(b1, b2 *types.Block) {
 	b2Clone := b2.Clone()
 	b1Clone.Payload = []byte{}
 	b2Clone.Payload = []byte{}
-	recv.consensus.gov.ReportForkBlock(b1Clone, b2Clone)
+	recv.consensus.events.emit(&ForkBlockEvent{Block1: b1Clone, Block2: b2Clone})
+	if err := recv.consensus.evidencePool.AddForkBlock(
+		b1Clone, b2Clone); err != nil && err != evidence.ErrEvidenceExists {
+		recv.consensus.logger.Error("Failed to report fork block",
+			"block1", b1Clone,
+			"block2", b2Clone,
+			"error", err)
+	}
+}
+
+func (recv *consensusBAReceiver) ReportPeriodAdvanced(
+	pos types.Position, period uint64) {
+	recv.consensus.events.emit(&PeriodAdvancedEvent{
+		Position: pos,
+		Period:   period,
+	})
 }
 
 // consensusDKGReceiver implements dkgReceiver.
@@ -412,6 +458,7 @@ type consensusDKGReceiver struct {
 	nodeSetCache *utils.NodeSetCache
 	cfgModule    *configurationChain
 	network      Network
+	evidencePool *evidence.Pool
 	logger       common.Logger
 }
 
@@ -422,11 +469,35 @@ func (recv *consensusDKGReceiver) ProposeDKGComplaint(
 		recv.logger.Error("Failed to sign DKG complaint", "error", err)
 		return
 	}
+	if !complaint.IsNack() {
+		// A complaint on a bad private share is an evidence, the pool would
+		// submit it to governance.
+		err := recv.reportDKGPrivateShare(complaint)
+		if err == nil || err == evidence.ErrEvidenceExists {
+			return
+		}
+		recv.logger.Error("Failed to report DKG private share",
+			"complaint", complaint,
+			"error", err)
+	}
 	recv.logger.Debug("Calling Governace.AddDKGComplaint",
 		"complaint", complaint)
 	recv.gov.AddDKGComplaint(complaint)
 }
 
+func (recv *consensusDKGReceiver) reportDKGPrivateShare(
+	complaint *typesDKG.Complaint) error {
+	recv.logger.Debug("Calling Governance.DKGMasterPublicKeys",
+		"round", complaint.Round)
+	for _, mpk := range recv.gov.DKGMasterPublicKeys(complaint.Round) {
+		if mpk.ProposerID != complaint.PrivateShare.ProposerID {
+			continue
+		}
+		return recv.evidencePool.AddDKGPrivateShare(complaint, mpk)
+	}
+	return ErrMasterPublicKeyNotFound
+}
+
 // ProposeDKGMasterPublicKey propose a DKGMasterPublicKey.
 func (recv *consensusDKGReceiver) ProposeDKGMasterPublicKey(
 	mpk *typesDKG.MasterPublicKey) {
@@ -529,7 +600,13 @@ type Consensus struct {
 	gov      Governance
 	network  Network
 
+	// Evidences of misbehaviour.
+	evidencePool *evidence.Pool
+
 	// Misc.
+	metrics                  Metrics
+	proposeTimes             map[types.Position]time.Time
+	proposeTimesLock         sync.Mutex
 	bcModule                 *blockChain
 	dMoment                  time.Time
 	nodeSetCache             *utils.NodeSetCache
@@ -541,6 +618,21 @@ type Consensus struct {
 	roundEvent               *utils.RoundEvent
 	logger                   common.Logger
 	resetDeliveryGuardTicker chan struct{}
+	lastDeliveredTime        atomic.Value
+	livenessPolicy           LivenessPolicy
+	tickerFactory            TickerFactory
+	clock                    Clock
+	agreementResultFormat    types.AgreementResultFormat
+	admission                AdmissionController
+	sigCache                 *utils.SignatureCache
+	sigVerifier              *sigVerifier
+	leaderSelector           LeaderSelector
+	events                   *eventFeed
+	errLock                  sync.RWMutex
+	err                      error
+	started                  int32
+	stopOnce                 sync.Once
+	done                     chan struct{}
 	msgChan                  chan types.Msg
 	priorityMsgChan          chan interface{}
 	waitGroup                sync.WaitGroup
@@ -553,6 +645,10 @@ type Consensus struct {
 }
 
 // NewConsensus construct an Consensus instance.
+//
+// When blocks are already delivered to db, the instance would resume from the
+// tip of compaction chain in db. The tip block might not be received by the
+// application if the node crashed right after saving it.
 func NewConsensus(
 	dMoment time.Time,
 	app Application,
@@ -560,9 +656,14 @@ func NewConsensus(
 	db db.Database,
 	network Network,
 	prv crypto.PrivateKey,
-	logger common.Logger) *Consensus {
-	return newConsensusForRound(
-		nil, dMoment, app, gov, db, network, prv, logger, true)
+	logger common.Logger,
+	opts ...ConsensusOption) (*Consensus, error) {
+	initBlock, err := loadInitBlock(db, gov, logger)
+	if err != nil {
+		return nil, err
+	}
+	return newConsensusForRound(initBlock, dMoment, app, gov, db, network,
+		prv, logger, newConsensusOptions(true, opts))
 }
 
 // NewConsensusForSimulation creates an instance of Consensus for simulation,
@@ -574,9 +675,71 @@ func NewConsensusForSimulation(
 	db db.Database,
 	network Network,
 	prv crypto.PrivateKey,
-	logger common.Logger) *Consensus {
-	return newConsensusForRound(
-		nil, dMoment, app, gov, db, network, prv, logger, false)
+	logger common.Logger,
+	opts ...ConsensusOption) (*Consensus, error) {
+	initBlock, err := loadInitBlock(db, gov, logger)
+	if err != nil {
+		return nil, err
+	}
+	return newConsensusForRound(initBlock, dMoment, app, gov, db, network,
+		prv, logger, newConsensusOptions(false, opts))
+}
+
+// loadInitBlock loads the tip of compaction chain in db and checks it against
+// governance, nil is returned when nothing is delivered yet.
+func loadInitBlock(dbInst db.Database, gov Governance,
+	logger common.Logger) (*types.Block, error) {
+	hash, height := dbInst.GetCompactionChainTipInfo()
+	if height == 0 {
+		return nil, nil
+	}
+	b, err := dbInst.GetBlock(hash)
+	if err != nil {
+		logger.Error("Failed to load tip of compaction chain",
+			"hash", hash,
+			"height", height,
+			"error", err)
+		return nil, ErrInvalidDBTip
+	}
+	if b.Position.Height != height || !b.IsFinalized() {
+		logger.Error("Tip of compaction chain is not finalized",
+			"block", &b,
+			"height", height)
+		return nil, ErrInvalidDBTip
+	}
+	round := b.Position.Round
+	mismatch := func(reason string) (*types.Block, error) {
+		logger.Error("DB mismatches governance",
+			"block", &b,
+			"reason", reason)
+		return nil, ErrDBGovernanceMismatch
+	}
+	if gov.Configuration(round) == nil || (gov.CRS(round) == common.Hash{}) {
+		return mismatch("round not ready")
+	}
+	// The end of round would be checked by utils.RoundEvent, which takes DKG
+	// resets into account.
+	if height < utils.GetRoundHeight(gov, round) {
+		return mismatch("height before round")
+	}
+	if round < DKGDelayRound {
+		if !bytes.Equal(b.Randomness, NoRand) {
+			return mismatch("randomness before DKGDelayRound")
+		}
+	} else {
+		v, ok, err := NewTSigVerifierCache(gov, 1).UpdateAndGet(round)
+		if err != nil || !ok {
+			return mismatch("DKG not ready")
+		}
+		if !v.VerifySignature(b.Hash, crypto.Signature{
+			Type:      "bls",
+			Signature: b.Randomness,
+		}) {
+			return mismatch("randomness not signed by DKG")
+		}
+	}
+	logger.Info("Resume from tip of compaction chain", "block", &b)
+	return &b, nil
 }
 
 // NewConsensusFromSyncer constructs an Consensus instance from information
@@ -599,10 +762,14 @@ func NewConsensusFromSyncer(
 	prv crypto.PrivateKey,
 	confirmedBlocks []*types.Block,
 	cachedMessages []types.Msg,
-	logger common.Logger) (*Consensus, error) {
+	logger common.Logger,
+	opts ...ConsensusOption) (*Consensus, error) {
 	// Setup Consensus instance.
-	con := newConsensusForRound(initBlock, dMoment, app, gov, db,
-		networkModule, prv, logger, true)
+	con, err := newConsensusForRound(initBlock, dMoment, app, gov, db,
+		networkModule, prv, logger, newConsensusOptions(true, opts))
+	if err != nil {
+		return nil, err
+	}
 	// Launch a dummy receiver before we start receiving from network module.
 	con.dummyMsgBuffer = cachedMessages
 	con.dummyCancel, con.dummyFinished = utils.LaunchDummyReceiver(
@@ -619,7 +786,8 @@ func NewConsensusFromSyncer(
 		if b.Position.Height != refBlock.Position.Height+1 {
 			break
 		}
-		if err := con.processBlock(b); err != nil {
+		if err = con.processBlock(b); err != nil {
+			con.Stop()
 			return nil, err
 		}
 		refBlock = b
@@ -629,9 +797,9 @@ func NewConsensusFromSyncer(
 			Round:  con.bcModule.tipRound(),
 			Height: initBlock.Position.Height + 1,
 		}
-		_, err := con.bcModule.addEmptyBlock(emptyPos)
-		if err != nil {
-			panic(err)
+		if _, err = con.bcModule.addEmptyBlock(emptyPos); err != nil {
+			con.Stop()
+			return nil, err
 		}
 	}
 	return con, nil
@@ -647,8 +815,8 @@ func newConsensusForRound(
 	network Network,
 	prv crypto.PrivateKey,
 	logger common.Logger,
-	usingNonBlocking bool) *Consensus {
-	// TODO(w): load latest blockHeight from DB, and use config at that height.
+	opts *consensusOptions) (*Consensus, error) {
+	metrics := opts.metrics
 	nodeSetCache := utils.NewNodeSetCache(gov)
 	// Setup signer module.
 	signer := utils.NewSigner(prv)
@@ -665,17 +833,32 @@ func newConsensusForRound(
 	if initBlock != nil {
 		initPos = initBlock.Position
 	}
-	// Init configuration chain.
 	ID := types.NewNodeID(prv.PublicKey())
+	// Evidences are only gossiped when the network supports it.
+	broadcaster, _ := network.(EvidenceBroadcaster)
+	evidencePool, err := evidence.NewPool(
+		ID, signer, db, gov, broadcaster, logger)
+	if err != nil {
+		return nil, err
+	}
+	// Init configuration chain.
 	recv := &consensusDKGReceiver{
 		ID:           ID,
 		gov:          gov,
 		signer:       signer,
 		nodeSetCache: nodeSetCache,
 		network:      network,
+		evidencePool: evidencePool,
 		logger:       logger,
 	}
-	cfgModule := newConfigurationChain(ID, recv, gov, nodeSetCache, db, logger)
+	cfgModule := newConfigurationChain(ID, recv, gov, nodeSetCache, db, logger,
+		metrics)
+	cfgModule.tickerFactory = opts.tickerFactory
+	cfgModule.clock = opts.clock
+	sigCache := utils.NewSignatureCache(opts.sigCacheSize)
+	cfgModule.sigCache = sigCache
+	events := newEventFeed()
+	cfgModule.events = events
 	recv.cfgModule = cfgModule
 	signer.SetBLSSigner(
 		func(round uint64, hash common.Hash) (crypto.Signature, error) {
@@ -686,12 +869,12 @@ func newConsensusForRound(
 			return crypto.Signature(signer.sign(hash)), nil
 		})
 	appModule := app
-	if usingNonBlocking {
+	if opts.nonBlocking {
 		appModule = newNonBlocking(app, debugApp)
 	}
-	tsigVerifierCache := NewTSigVerifierCache(gov, 7)
+	tsigVerifierCache := NewTSigVerifierCache(gov, opts.tsigVerifierCacheSize)
 	bcModule := newBlockChain(ID, dMoment, initBlock, appModule,
-		tsigVerifierCache, signer, logger)
+		tsigVerifierCache, signer, logger, metrics)
 	// Construct Consensus instance.
 	con := &Consensus{
 		ID:                       ID,
@@ -700,6 +883,7 @@ func newConsensusForRound(
 		gov:                      gov,
 		db:                       db,
 		network:                  network,
+		evidencePool:             evidencePool,
 		baConfirmedBlock:         make(map[common.Hash]chan<- *types.Block),
 		dkgReady:                 sync.NewCond(&sync.Mutex{}),
 		cfgModule:                cfgModule,
@@ -710,25 +894,44 @@ func newConsensusForRound(
 		signer:                   signer,
 		event:                    common.NewEvent(),
 		logger:                   logger,
+		metrics:                  metrics,
+		proposeTimes:             make(map[types.Position]time.Time),
 		resetDeliveryGuardTicker: make(chan struct{}),
-		msgChan:                  make(chan types.Msg, 1024),
-		priorityMsgChan:          make(chan interface{}, 1024),
-		processBlockChan:         make(chan *types.Block, 1024),
+		livenessPolicy:           opts.livenessPolicy,
+		tickerFactory:            opts.tickerFactory,
+		clock:                    opts.clock,
+		agreementResultFormat:    opts.agreementResultFormat,
+		admission:                opts.admission,
+		sigCache:                 sigCache,
+		leaderSelector:           opts.leaderSelector,
+		events:                   events,
+		msgChan:                  make(chan types.Msg, opts.msgChanSize),
+		priorityMsgChan: make(
+			chan interface{}, opts.priorityMsgChanSize),
+		processBlockChan: make(
+			chan *types.Block, opts.processBlockChanSize),
+		done: make(chan struct{}),
+	}
+	if opts.sigVerifyWorkers > 0 {
+		con.sigVerifier = newSigVerifier(sigCache, opts.sigVerifyWorkers,
+			opts.msgChanSize, opts.clock, logger)
 	}
 	con.ctx, con.ctxCancel = context.WithCancel(context.Background())
-	var err error
 	con.roundEvent, err = utils.NewRoundEvent(con.ctx, gov, logger, initPos,
 		ConfigRoundShift)
 	if err != nil {
-		panic(err)
+		con.ctxCancel()
+		return nil, err
 	}
 	if con.baMgr, err = newAgreementMgr(con); err != nil {
-		panic(err)
+		con.ctxCancel()
+		return nil, err
 	}
 	if err = con.prepare(initBlock); err != nil {
-		panic(err)
+		con.Stop()
+		return nil, err
 	}
-	return con
+	return con, nil
 }
 
 // prepare the Consensus instance to be ready for blocks after 'initBlock'.
@@ -746,7 +949,7 @@ func (con *Consensus) prepare(initBlock *types.Block) (err error) {
 	}
 	if initRound == 0 {
 		if DKGDelayRound == 0 {
-			panic("not implemented yet")
+			return ErrGenesisDKGDelayRound
 		}
 	}
 	// Measure time elapse for each handler of round events.
@@ -773,6 +976,47 @@ func (con *Consensus) prepare(initBlock *types.Block) (err error) {
 			}
 			con.nodeSetCache.Purge(e.Round + 1)
 			con.tsigVerifierCache.Purge(e.Round + 1)
+			con.events.emit(&DKGResetEvent{Round: e.Round + 1, Reset: e.Reset})
+		}
+	})
+	// Register round event handler to prune evidences, the ones of the
+	// previous round are kept.
+	con.roundEvent.Register(func(evts []utils.RoundEventParam) {
+		e := evts[len(evts)-1]
+		if e.Round > 0 {
+			con.evidencePool.Prune(e.Round - 1)
+		}
+	})
+	// Register round event handler to update notary set for admission control.
+	// Round events are triggered before the round begins, so notary set of
+	// the previous round is kept.
+	if con.admission != nil {
+		con.roundEvent.Register(func(evts []utils.RoundEventParam) {
+			e := evts[len(evts)-1]
+			rounds := []uint64{e.Round}
+			if e.Round > 0 {
+				rounds = append(rounds, e.Round-1)
+			}
+			notarySet := make(map[types.NodeID]struct{})
+			for _, r := range rounds {
+				set, err := con.nodeSetCache.GetNotarySet(r)
+				if err != nil {
+					con.logger.Error("Failed to get notary set for admission",
+						"round", r,
+						"error", err)
+					continue
+				}
+				for nID := range set {
+					notarySet[nID] = struct{}{}
+				}
+			}
+			con.admission.UpdateNotarySet(notarySet)
+		})
+	}
+	// Register round event handler to notify subscribers.
+	con.roundEvent.Register(func(evts []utils.RoundEventParam) {
+		for _, e := range evts {
+			con.events.emit(&RoundEvent{Param: e})
 		}
 	})
 	// Register round event handler to abort previous running DKG if any.
@@ -795,10 +1039,11 @@ func (con *Consensus) prepare(initBlock *types.Block) (err error) {
 		// Always updates newer configs to the later modules first in the data
 		// flow.
 		if err := con.bcModule.notifyRoundEvents(evts); err != nil {
-			panic(err)
+			con.stopWithError(err)
+			return
 		}
 		if err := con.baMgr.notifyRoundEvents(evts); err != nil {
-			panic(err)
+			con.stopWithError(err)
 		}
 	})
 	// Register round event handler to reset DKG if the DKG set for next round
@@ -954,8 +1199,8 @@ func (con *Consensus) prepare(initBlock *types.Block) (err error) {
 			go func() {
 				// Normally, gov.CRS would return non-nil. Use this for in case
 				// of unexpected network fluctuation and ensure the robustness.
-				if !checkWithCancel(
-					con.ctx, 500*time.Millisecond, checkCRS(nextRound)) {
+				if !checkWithCancel(con.ctx, con.clock,
+					500*time.Millisecond, checkCRS(nextRound)) {
 					con.logger.Debug("unable to prepare CRS for notary set",
 						"round", nextRound,
 						"reset", e.Reset)
@@ -980,8 +1225,15 @@ func (con *Consensus) prepare(initBlock *types.Block) (err error) {
 					"reset", e.Reset)
 				nextConfig := utils.GetConfigWithPanic(con.gov, nextRound,
 					con.logger)
-				con.cfgModule.registerDKG(con.ctx, nextRound, e.Reset,
-					utils.GetDKGThreshold(nextConfig))
+				if err := con.cfgModule.registerDKG(con.ctx, nextRound,
+					e.Reset, utils.GetDKGThreshold(nextConfig)); err != nil {
+					con.logger.Error("Failed to register DKG",
+						"round", nextRound,
+						"reset", e.Reset,
+						"error", err)
+					con.stopWithError(err)
+					return
+				}
 				con.event.RegisterHeight(e.NextDKGPreparationHeight(),
 					func(h uint64) {
 						func() {
@@ -1006,19 +1258,77 @@ func (con *Consensus) prepare(initBlock *types.Block) (err error) {
 	return
 }
 
-// Run starts running DEXON Consensus.
-func (con *Consensus) Run() {
+// Start launches DEXON Consensus in background and returns immediately. The
+// Consensus instance would be stopped when ctx is done, Stop is called, or
+// any fatal error is encountered. Use Done and Err to supervise it.
+func (con *Consensus) Start(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&con.started, 0, 1) {
+		return ErrConsensusStarted
+	}
 	// There may have emptys block in blockchain added by force sync.
 	blocksWithoutRandomness := con.bcModule.pendingBlocksWithoutRandomness()
 	// Launch BA routines.
 	con.baMgr.run()
 	// Launch network handler.
 	con.logger.Debug("Calling Network.ReceiveChan")
+	if con.sigVerifier != nil {
+		con.sigVerifier.start(con.ctx, con.msgChan)
+	}
 	con.waitGroup.Add(1)
 	go con.deliverNetworkMsg()
 	con.waitGroup.Add(1)
 	go con.processMsg()
 	go con.processBlockLoop()
+	con.waitGroup.Add(1)
+	go con.bootstrap(blocksWithoutRandomness)
+	go func() {
+		select {
+		case <-ctx.Done():
+			con.stopWithError(ctx.Err())
+		case <-con.ctx.Done():
+		}
+		con.shutdown()
+	}()
+	return nil
+}
+
+// Run starts running DEXON Consensus and blocks until it's stopped, the
+// returned error is the same as Err.
+func (con *Consensus) Run() error {
+	if err := con.Start(context.Background()); err != nil {
+		return err
+	}
+	<-con.Done()
+	return con.Err()
+}
+
+// Done returns a channel which would be closed when the Consensus instance is
+// stopped and all its routines are finished.
+func (con *Consensus) Done() <-chan struct{} {
+	return con.done
+}
+
+// Err returns the reason why the Consensus instance is stopped, it's nil when
+// the instance is still running or stopped by Stop.
+func (con *Consensus) Err() error {
+	con.errLock.RLock()
+	defer con.errLock.RUnlock()
+	return con.err
+}
+
+// stopWithError stops the Consensus instance and keeps the first error as the
+// reason, it's safe to be called in routines waited by Stop.
+func (con *Consensus) stopWithError(err error) {
+	con.errLock.Lock()
+	defer con.errLock.Unlock()
+	if con.err == nil {
+		con.err = err
+	}
+	con.ctxCancel()
+}
+
+func (con *Consensus) bootstrap(blocksWithoutRandomness []*types.Block) {
+	defer con.waitGroup.Done()
 	// Stop dummy receiver if launched.
 	if con.dummyCancel != nil {
 		con.logger.Trace("Stop dummy receiver")
@@ -1033,7 +1343,9 @@ func (con *Consensus) Run() {
 				select {
 				case con.msgChan <- msg:
 					break loop
-				case <-time.After(50 * time.Millisecond):
+				case <-con.ctx.Done():
+					return
+				case <-con.clock.After(50 * time.Millisecond):
 					con.logger.Debug(
 						"internal message channel is full when syncing")
 				}
@@ -1042,16 +1354,14 @@ func (con *Consensus) Run() {
 		con.logger.Trace("Finish dumping cached messages")
 	}
 	con.generateBlockRandomness(blocksWithoutRandomness)
-	// Sleep until dMoment come.
-	time.Sleep(con.dMoment.Sub(time.Now().UTC()))
-	// Take some time to bootstrap.
-	time.Sleep(3 * time.Second)
-	con.waitGroup.Add(1)
-	go con.deliveryGuard()
-	// Block until done.
+	// Sleep until dMoment come, and take some time to bootstrap.
 	select {
 	case <-con.ctx.Done():
+		return
+	case <-con.clock.After(con.dMoment.Sub(con.clock.Now()) + 3*time.Second):
 	}
+	con.waitGroup.Add(1)
+	go con.deliveryGuard()
 }
 
 func (con *Consensus) generateBlockRandomness(blocks []*types.Block) {
@@ -1187,15 +1497,22 @@ func (con *Consensus) runCRS(round uint64, hash common.Hash, reset bool) {
 	}
 }
 
-// Stop the Consensus core.
+// Stop the Consensus core, it blocks until all routines are finished.
 func (con *Consensus) Stop() {
 	con.ctxCancel()
-	con.baMgr.stop()
-	con.event.Reset()
-	con.waitGroup.Wait()
-	if nbApp, ok := con.app.(*nonBlocking); ok {
-		nbApp.wait()
-	}
+	con.shutdown()
+}
+
+func (con *Consensus) shutdown() {
+	con.stopOnce.Do(func() {
+		con.baMgr.stop()
+		con.event.Reset()
+		con.waitGroup.Wait()
+		if nbApp, ok := con.app.(*nonBlocking); ok {
+			nbApp.wait()
+		}
+		close(con.done)
+	})
 }
 
 func (con *Consensus) deliverNetworkMsg() {
@@ -1209,12 +1526,21 @@ func (con *Consensus) deliverNetworkMsg() {
 		}
 		select {
 		case msg := <-recv:
+			if !con.admit(msg) {
+				continue
+			}
+			if con.sigVerifier != nil {
+				if !con.sigVerifier.submit(con.ctx, msg) {
+					return
+				}
+				continue
+			}
 		innerLoop:
 			for {
 				select {
 				case con.msgChan <- msg:
 					break innerLoop
-				case <-time.After(500 * time.Millisecond):
+				case <-con.clock.After(500 * time.Millisecond):
 					con.logger.Debug("internal message channel is full",
 						"pending", msg)
 				}
@@ -1225,6 +1551,22 @@ func (con *Consensus) deliverNetworkMsg() {
 	}
 }
 
+// admit checks a message from network by the admission controller and
+// reports the peer if required.
+func (con *Consensus) admit(msg types.Msg) bool {
+	if con.admission == nil {
+		return true
+	}
+	admitted, reason := con.admission.Admit(msg.PeerID, msg.Payload)
+	if reason != nil {
+		con.logger.Warn("Peer rejected by admission control",
+			"peer", msg.PeerID,
+			"reason", reason)
+		con.reportBadPeer(msg.PeerID, reason)
+	}
+	return admitted
+}
+
 func (con *Consensus) processMsg() {
 	defer con.waitGroup.Done()
 MessageLoop:
@@ -1234,6 +1576,7 @@ MessageLoop:
 			return
 		default:
 		}
+		con.metrics.SetMsgChanDepth(len(con.msgChan), len(con.priorityMsgChan))
 		var msg, peer interface{}
 		select {
 		case msg = <-con.priorityMsgChan:
@@ -1264,14 +1607,14 @@ MessageLoop:
 						con.logger.Error("Error verifying empty block hash",
 							"block", val,
 							"error, err")
-						con.network.ReportBadPeerChan() <- peer
+						con.reportBadPeer(peer, err)
 						continue MessageLoop
 					}
 					if hash != val.Hash {
 						con.logger.Error("Incorrect confirmed empty block hash",
 							"block", val,
 							"hash", hash)
-						con.network.ReportBadPeerChan() <- peer
+						con.reportBadPeer(peer, ErrIncorrectHash)
 						continue MessageLoop
 					}
 					if _, err := con.bcModule.proposeBlock(
@@ -1279,7 +1622,7 @@ MessageLoop:
 						con.logger.Error("Error adding empty block",
 							"block", val,
 							"error", err)
-						con.network.ReportBadPeerChan() <- peer
+						con.reportBadPeer(peer, err)
 						continue MessageLoop
 					}
 				} else {
@@ -1294,20 +1637,20 @@ MessageLoop:
 						con.logger.Error("Error verifying confirmed block randomness",
 							"block", val,
 							"error", err)
-						con.network.ReportBadPeerChan() <- peer
+						con.reportBadPeer(peer, err)
 						continue MessageLoop
 					}
 					if !ok {
 						con.logger.Error("Incorrect confirmed block randomness",
 							"block", val)
-						con.network.ReportBadPeerChan() <- peer
+						con.reportBadPeer(peer, ErrIncorrectBlockRandomness)
 						continue MessageLoop
 					}
 					if err := utils.VerifyBlockSignature(val); err != nil {
 						con.logger.Error("VerifyBlockSignature failed",
 							"block", val,
 							"error", err)
-						con.network.ReportBadPeerChan() <- peer
+						con.reportBadPeer(peer, err)
 						continue MessageLoop
 					}
 				}
@@ -1326,14 +1669,14 @@ MessageLoop:
 					con.logger.Error("Failed to process finalized block",
 						"block", val,
 						"error", err)
-					con.network.ReportBadPeerChan() <- peer
+					con.reportBadPeer(peer, err)
 				}
 			} else {
 				if err := con.preProcessBlock(val); err != nil {
 					con.logger.Error("Failed to pre process block",
 						"block", val,
 						"error", err)
-					con.network.ReportBadPeerChan() <- peer
+					con.reportBadPeer(peer, err)
 				}
 			}
 		case *types.Vote:
@@ -1341,38 +1684,83 @@ MessageLoop:
 				con.logger.Error("Failed to process vote",
 					"vote", val,
 					"error", err)
-				con.network.ReportBadPeerChan() <- peer
+				con.reportBadPeer(peer, err)
 			}
 		case *types.AgreementResult:
 			if err := con.ProcessAgreementResult(val); err != nil {
 				con.logger.Error("Failed to process agreement result",
 					"result", val,
 					"error", err)
-				con.network.ReportBadPeerChan() <- peer
+				con.reportBadPeer(peer, err)
+			}
+		case *types.Evidence:
+			if err := con.evidencePool.ProcessEvidence(val); err != nil &&
+				err != evidence.ErrEvidenceExists {
+				con.logger.Error("Failed to process evidence",
+					"evidence", val,
+					"error", err)
+				con.reportBadPeer(peer, err)
 			}
 		case *typesDKG.PrivateShare:
 			if err := con.cfgModule.processPrivateShare(val); err != nil {
 				con.logger.Error("Failed to process private share",
 					"error", err)
-				con.network.ReportBadPeerChan() <- peer
+				con.reportBadPeer(peer, err)
 			}
 
 		case *typesDKG.PartialSignature:
 			if err := con.cfgModule.processPartialSignature(val); err != nil {
 				con.logger.Error("Failed to process partial signature",
 					"error", err)
-				con.network.ReportBadPeerChan() <- peer
+				con.reportBadPeer(peer, err)
 			}
 		}
 	}
 }
 
+// reportBadPeer reports a peer with the reason via BadPeerReporter, or only
+// the peer via Network.ReportBadPeerChan if the network doesn't support it.
+// It's called when processing messages, so the report is dropped instead of
+// blocking when the channel is full.
+func (con *Consensus) reportBadPeer(peer interface{}, reason error) {
+	con.metrics.IncBadPeer()
+	if reporter, ok := con.network.(BadPeerReporter); ok {
+		select {
+		case reporter.BadPeerReportChan() <- &types.BadPeerReport{
+			PeerID: peer,
+			Reason: reason,
+		}:
+		case <-con.ctx.Done():
+		default:
+			con.metrics.IncDroppedBadPeerReport()
+		}
+		return
+	}
+	select {
+	case con.network.ReportBadPeerChan() <- peer:
+	case <-con.ctx.Done():
+	default:
+		con.metrics.IncDroppedBadPeerReport()
+	}
+}
+
 // ProcessVote is the entry point to submit ont vote to a Consensus instance.
 func (con *Consensus) ProcessVote(vote *types.Vote) (err error) {
-	err = con.baMgr.processVote(vote)
+	if err = con.baMgr.processVote(vote); err == nil {
+		con.events.emit(&VoteReceivedEvent{Vote: vote})
+	}
 	return
 }
 
+// Subscribe registers a subscription to events of eventTypes, all types of
+// events are subscribed when none is provided. Events are dropped when the
+// buffer of subscription is full, so the subscriber would never block
+// Consensus.
+func (con *Consensus) Subscribe(
+	bufferSize int, eventTypes ...EventType) *Subscription {
+	return con.events.subscribe(bufferSize, eventTypes)
+}
+
 // ProcessAgreementResult processes the randomness request.
 func (con *Consensus) ProcessAgreementResult(
 	rand *types.AgreementResult) error {
@@ -1446,13 +1834,14 @@ func (con *Consensus) deliveryGuard() {
 	defer con.waitGroup.Done()
 	select {
 	case <-con.ctx.Done():
-	case <-time.After(con.dMoment.Sub(time.Now())):
+	case <-con.clock.After(con.dMoment.Sub(con.clock.Now())):
 	}
 	// Node takes time to start.
 	select {
 	case <-con.ctx.Done():
-	case <-time.After(60 * time.Second):
+	case <-con.clock.After(con.livenessPolicy.StartupTimeout):
 	}
+	lastDelivered := con.clock.Now()
 	for {
 		select {
 		case <-con.ctx.Done():
@@ -1463,31 +1852,91 @@ func (con *Consensus) deliveryGuard() {
 		case <-con.ctx.Done():
 			return
 		case <-con.resetDeliveryGuardTicker:
-		case <-time.After(60 * time.Second):
-			con.logger.Error("No blocks delivered for too long", "ID", con.ID)
-			panic(fmt.Errorf("No blocks delivered for too long"))
+			lastDelivered = con.clock.Now()
+		case <-con.clock.After(con.livenessPolicy.DeliveryTimeout):
+			if con.handleLivenessTimeout(
+				con.clock.Now().Sub(lastDelivered)) {
+				return
+			}
+		}
+	}
+}
+
+// handleLivenessTimeout takes actions defined in liveness policy, it returns
+// true when the Consensus instance is stopped.
+func (con *Consensus) handleLivenessTimeout(elapsed time.Duration) bool {
+	evt := LivenessEvent{ID: con.ID, Elapsed: elapsed}
+	if b := con.bcModule.lastDeliveredBlock(); b != nil {
+		evt.LastDelivered = b.Position
+	}
+	con.logger.Error("No blocks delivered for too long",
+		"ID", con.ID,
+		"elapsed", elapsed,
+		"last-delivered", &evt.LastDelivered)
+	policy := con.livenessPolicy
+	if policy.Actions&LivenessActionNotify != 0 {
+		select {
+		case policy.Notify <- evt:
+		default:
+			con.logger.Warn("Liveness event dropped", "event", evt)
+		}
+	}
+	if policy.Actions&LivenessActionCallback != 0 {
+		policy.Callback(evt)
+	}
+	if policy.Actions&LivenessActionResync != 0 {
+		con.logger.Info("Calling Recovery.ProposeSkipBlock",
+			"height", evt.LastDelivered.Height)
+		if err := policy.Recovery.ProposeSkipBlock(
+			evt.LastDelivered.Height); err != nil {
+			con.logger.Error("Failed to propose skip block", "error", err)
 		}
 	}
+	if policy.Actions&LivenessActionStop != 0 {
+		con.stopWithError(ErrNoBlockDelivered)
+		return true
+	}
+	return false
 }
 
 // deliverBlock deliver a block to application layer.
-func (con *Consensus) deliverBlock(b *types.Block) {
+func (con *Consensus) deliverBlock(b *types.Block) (err error) {
 	select {
 	case con.resetDeliveryGuardTicker <- struct{}{}:
 	default:
 	}
-	if err := con.db.PutBlock(*b); err != nil {
-		panic(err)
-	}
-	if err := con.db.PutCompactionChainTipInfo(b.Hash,
-		b.Position.Height); err != nil {
-		panic(err)
+	// The block and the tip of compaction chain are saved atomically, or the
+	// block would be unable to be delivered again after restarted.
+	batch := con.db.NewBatch()
+	batch.PutBlock(*b)
+	batch.PutCompactionChainTipInfo(b.Hash, b.Position.Height)
+	if err = batch.Commit(); err != nil {
+		return
 	}
+	con.lastDeliveredTime.Store(con.clock.Now())
+	con.observeBlockLatency(b.Position)
 	con.logger.Debug("Calling Application.BlockDelivered", "block", b)
 	con.app.BlockDelivered(b.Hash, b.Position, common.CopyBytes(b.Randomness))
 	if con.debugApp != nil {
 		con.debugApp.BlockReady(b.Hash)
 	}
+	return
+}
+
+// observeBlockLatency reports the time elapsed from proposing a block at a
+// position to delivering that position.
+func (con *Consensus) observeBlockLatency(pos types.Position) {
+	con.proposeTimesLock.Lock()
+	defer con.proposeTimesLock.Unlock()
+	for p, t := range con.proposeTimes {
+		if p.Newer(pos) {
+			continue
+		}
+		if p.Equal(pos) {
+			con.metrics.ObserveBlockLatency(pos, con.clock.Now().Sub(t))
+		}
+		delete(con.proposeTimes, p)
+	}
 }
 
 // deliverFinalizedBlocks extracts and delivers finalized blocks to application
@@ -1504,7 +1953,15 @@ func (con *Consensus) deliverFinalizedBlocksWithoutLock() (err error) {
 		"delivered", con.bcModule.lastDeliveredBlock(),
 		"pending", con.bcModule.lastPendingBlock())
 	for _, b := range deliveredBlocks {
-		con.deliverBlock(b)
+		if err = con.deliverBlock(b); err != nil {
+			// Blocks are extracted from blockChain and can't be delivered
+			// again, it's not safe to keep running.
+			con.logger.Error("Failed to deliver block",
+				"block", b,
+				"error", err)
+			con.stopWithError(err)
+			return
+		}
 		con.event.NotifyHeight(b.Position.Height)
 	}
 	return
@@ -1549,7 +2006,8 @@ func (con *Consensus) processBlock(block *types.Block) (err error) {
 // PrepareBlock would setup header fields of block based on its ProposerID.
 func (con *Consensus) proposeBlock(position types.Position) (
 	*types.Block, error) {
-	b, err := con.bcModule.proposeBlock(position, time.Now().UTC(), false)
+	b, err := con.bcModule.proposeBlock(
+		position, con.clock.Now().UTC(), false)
 	if err != nil {
 		return nil, err
 	}
@@ -1563,5 +2021,8 @@ func (con *Consensus) proposeBlock(position types.Position) (
 	if err = con.signer.SignCRS(b, crs); err != nil {
 		return nil, err
 	}
+	con.proposeTimesLock.Lock()
+	defer con.proposeTimesLock.Unlock()
+	con.proposeTimes[position] = con.clock.Now()
 	return b, nil
 }
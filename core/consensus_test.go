@@ -28,6 +28,7 @@ import (
 
 	"github.com/dexon-foundation/dexon-consensus/common"
 	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	cryptoDKG "github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
 	"github.com/dexon-foundation/dexon-consensus/core/db"
 	"github.com/dexon-foundation/dexon-consensus/core/test"
 	"github.com/dexon-foundation/dexon-consensus/core/types"
@@ -88,6 +89,12 @@ func (n *network) BroadcastDKGPartialSignature(
 	n.conn.broadcast(n.nID, psig)
 }
 
+// BroadcastEvidence broadcasts evidence of misbehaviour to all nodes in
+// DEXON network.
+func (n *network) BroadcastEvidence(evidence *types.Evidence) {
+	n.conn.broadcast(n.nID, evidence)
+}
+
 // ReceiveChan returns a channel to receive messages from DEXON network.
 func (n *network) ReceiveChan() <-chan types.Msg {
 	return make(chan types.Msg)
@@ -168,7 +175,13 @@ func (nc *networkConnection) setCon(nID types.NodeID, con *Consensus) {
 			case *types.Vote:
 				err = con.ProcessVote(val)
 			case *types.AgreementResult:
-				err = con.ProcessAgreementResult(val)
+				// Agreement results are only processed in the message loop
+				// of Consensus, like those received from DEXON network.
+				select {
+				case con.msgChan <- msg:
+				case <-nc.s.ctx.Done():
+					return
+				}
 			case *typesDKG.PrivateShare:
 				err = con.cfgModule.processPrivateShare(val)
 			case *typesDKG.PartialSignature:
@@ -220,7 +233,8 @@ func (s *ConsensusTestSuite) prepareConsensus(
 	dMoment time.Time,
 	gov *test.Governance,
 	prvKey crypto.PrivateKey,
-	conn *networkConnection) (
+	conn *networkConnection,
+	opts ...ConsensusOption) (
 	*test.App, *Consensus) {
 
 	app := test.NewApp(0, nil, nil)
@@ -228,8 +242,9 @@ func (s *ConsensusTestSuite) prepareConsensus(
 	s.Require().NoError(err)
 	nID := types.NewNodeID(prvKey.PublicKey())
 	network := conn.newNetwork(nID)
-	con := NewConsensus(
-		dMoment, app, gov, dbInst, network, prvKey, &common.NullLogger{})
+	con, err := NewConsensus(dMoment, app, gov, dbInst, network, prvKey,
+		&common.NullLogger{}, opts...)
+	s.Require().NoError(err)
 	conn.setCon(nID, con)
 	return app, con
 }
@@ -245,8 +260,9 @@ func (s *ConsensusTestSuite) prepareConsensusWithDB(
 	app := test.NewApp(0, nil, nil)
 	nID := types.NewNodeID(prvKey.PublicKey())
 	network := conn.newNetwork(nID)
-	con := NewConsensus(
+	con, err := NewConsensus(
 		dMoment, app, gov, dbInst, network, prvKey, &common.NullLogger{})
+	s.Require().NoError(err)
 	conn.setCon(nID, con)
 	return app, con
 }
@@ -266,13 +282,13 @@ func (s *ConsensusTestSuite) TestRegisteredDKGRecover() {
 
 	s.Require().Nil(con.cfgModule.dkg)
 
-	con.cfgModule.registerDKG(con.ctx, 0, 0, 10)
+	s.Require().NoError(con.cfgModule.registerDKG(con.ctx, 0, 0, 10))
 	con.cfgModule.dkgLock.Lock()
 	defer con.cfgModule.dkgLock.Unlock()
 
 	_, newCon := s.prepareConsensusWithDB(dMoment, gov, prvKeys[0], conn, dbInst)
 
-	newCon.cfgModule.registerDKG(newCon.ctx, 0, 0, 10)
+	s.Require().NoError(newCon.cfgModule.registerDKG(newCon.ctx, 0, 0, 10))
 	newCon.cfgModule.dkgLock.Lock()
 	defer newCon.cfgModule.dkgLock.Unlock()
 
@@ -433,6 +449,340 @@ func (s *ConsensusTestSuite) TestInitialHeightEventTriggered() {
 	s.Require().Equal(con.bcModule.configs[0].RoundEndHeight(), uint64(301))
 }
 
+func (s *ConsensusTestSuite) TestLivenessPolicy() {
+	conn := s.newNetworkConnection()
+	prvKeys, pubKeys, err := test.NewKeys(1)
+	s.Require().NoError(err)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		pubKeys, time.Second, &common.NullLogger{}, true), ConfigRoundShift)
+	s.Require().NoError(err)
+	// Actions without stopping.
+	notify := make(chan LivenessEvent, 1)
+	var called []LivenessEvent
+	_, con := s.prepareConsensus(time.Now().UTC(), gov, prvKeys[0], conn,
+		WithLivenessPolicy(LivenessPolicy{
+			DeliveryTimeout: time.Second,
+			Actions:         LivenessActionNotify | LivenessActionCallback,
+			Notify:          notify,
+			Callback: func(e LivenessEvent) {
+				called = append(called, e)
+			},
+		}))
+	defer con.Stop()
+	s.Require().False(con.handleLivenessTimeout(2 * time.Second))
+	evt := <-notify
+	s.Require().Equal(con.ID, evt.ID)
+	s.Require().Equal(2*time.Second, evt.Elapsed)
+	s.Require().Equal([]LivenessEvent{evt}, called)
+	// Notification should not block when the channel is full.
+	notify <- evt
+	s.Require().False(con.handleLivenessTimeout(3 * time.Second))
+	s.Require().Len(called, 2)
+	select {
+	case <-con.ctx.Done():
+		s.FailNow("should not be stopped")
+	default:
+	}
+	// Stop.
+	con.livenessPolicy = DefaultLivenessPolicy()
+	s.Require().True(con.handleLivenessTimeout(time.Minute))
+	<-con.ctx.Done()
+	s.Require().Equal(ErrNoBlockDelivered, con.Err())
+}
+
+func (s *ConsensusTestSuite) TestLifecycle() {
+	conn := s.newNetworkConnection()
+	prvKeys, pubKeys, err := test.NewKeys(1)
+	s.Require().NoError(err)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		pubKeys, time.Second, &common.NullLogger{}, true), ConfigRoundShift)
+	s.Require().NoError(err)
+	dMoment := time.Now().UTC().Add(time.Hour)
+	// Stopped by Stop.
+	_, con := s.prepareConsensus(dMoment, gov, prvKeys[0], conn)
+	s.Require().NoError(con.Start(context.Background()))
+	s.Require().Equal(ErrConsensusStarted, con.Start(context.Background()))
+	con.Stop()
+	<-con.Done()
+	s.Require().NoError(con.Err())
+	// Stopped by the context passed to Start.
+	_, con = s.prepareConsensus(dMoment, gov, prvKeys[0], conn)
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Require().NoError(con.Start(ctx))
+	cancel()
+	<-con.Done()
+	s.Require().Equal(context.Canceled, con.Err())
+	// Stopped by a fatal error, only the first one is kept.
+	_, con = s.prepareConsensus(dMoment, gov, prvKeys[0], conn)
+	s.Require().NoError(con.Start(context.Background()))
+	con.stopWithError(ErrNoBlockDelivered)
+	con.stopWithError(ErrCRSNotReady)
+	<-con.Done()
+	s.Require().Equal(ErrNoBlockDelivered, con.Err())
+	con.Stop()
+}
+
+func (s *ConsensusTestSuite) TestStatus() {
+	conn := s.newNetworkConnection()
+	prvKeys, pubKeys, err := test.NewKeys(1)
+	s.Require().NoError(err)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		pubKeys, time.Second, &common.NullLogger{}, true), ConfigRoundShift)
+	s.Require().NoError(err)
+	dMoment := time.Now().UTC().Add(time.Hour)
+	_, con := s.prepareConsensus(dMoment, gov, prvKeys[0], conn)
+	defer con.Stop()
+	status := con.Status()
+	s.Require().Equal(con.ID, status.ID)
+	s.Require().Equal(dMoment, status.DMoment)
+	s.Require().True(status.LastDeliveredTime.IsZero())
+	s.Require().NotNil(status.Agreement)
+	s.Require().Equal(types.NodeIDs{con.ID}, status.Agreement.NotarySet)
+	s.Require().NotNil(status.BlockChain)
+	s.Require().Empty(status.BlockChain.PendingBlocks)
+	s.Require().Empty(status.TSigs)
+	// Delivered time is updated when a block is delivered.
+	b := &types.Block{
+		Hash:     common.NewRandomHash(),
+		Position: types.Position{Height: types.GenesisHeight},
+	}
+	s.Require().NoError(con.deliverBlock(b))
+	s.Require().False(con.Status().LastDeliveredTime.IsZero())
+}
+
+// newDBWithTip prepares a db with tip as the tip of compaction chain.
+func (s *ConsensusTestSuite) newDBWithTip(tip *types.Block) db.Database {
+	dbInst, err := db.NewMemBackedDB()
+	s.Require().NoError(err)
+	for h := types.GenesisHeight; h < tip.Position.Height; h++ {
+		s.Require().NoError(dbInst.PutCompactionChainTipInfo(
+			common.NewRandomHash(), h))
+	}
+	s.Require().NoError(dbInst.PutBlock(*tip))
+	s.Require().NoError(dbInst.PutCompactionChainTipInfo(
+		tip.Hash, tip.Position.Height))
+	return dbInst
+}
+
+func (s *ConsensusTestSuite) TestResumeFromDB() {
+	conn := s.newNetworkConnection()
+	prvKeys, pubKeys, err := test.NewKeys(1)
+	s.Require().NoError(err)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		pubKeys, time.Second, &common.NullLogger{}, true), ConfigRoundShift)
+	s.Require().NoError(err)
+	dMoment := time.Now().UTC().Add(time.Hour)
+	// Prepare a db with the tip of compaction chain at height 3.
+	tip := &types.Block{
+		Hash:       common.NewRandomHash(),
+		Position:   types.Position{Height: 3},
+		Randomness: NoRand,
+	}
+	_, con := s.prepareConsensusWithDB(
+		dMoment, gov, prvKeys[0], conn, s.newDBWithTip(tip))
+	s.Require().Equal(tip.Position, con.Status().BlockChain.LastConfirmed)
+	con.Stop()
+	// Tips not found or not finalized.
+	dbInst, err := db.NewMemBackedDB()
+	s.Require().NoError(err)
+	s.Require().NoError(dbInst.PutCompactionChainTipInfo(
+		common.NewRandomHash(), types.GenesisHeight))
+	_, err = NewConsensus(dMoment, test.NewApp(0, nil, nil), gov, dbInst,
+		conn.newNetwork(con.ID), prvKeys[0], &common.NullLogger{})
+	s.Require().Equal(ErrInvalidDBTip, err)
+	tip.Randomness = nil
+	_, err = NewConsensus(dMoment, test.NewApp(0, nil, nil), gov,
+		s.newDBWithTip(tip), conn.newNetwork(con.ID), prvKeys[0],
+		&common.NullLogger{})
+	s.Require().Equal(ErrInvalidDBTip, err)
+	// Randomness mismatches.
+	tip.Randomness = []byte{0x01}
+	_, err = NewConsensus(dMoment, test.NewApp(0, nil, nil), gov,
+		s.newDBWithTip(tip), conn.newNetwork(con.ID), prvKeys[0],
+		&common.NullLogger{})
+	s.Require().Equal(ErrDBGovernanceMismatch, err)
+	// Rounds unknown to governance.
+	tip.Randomness = NoRand
+	tip.Position.Round = 10
+	_, err = NewConsensus(dMoment, test.NewApp(0, nil, nil), gov,
+		s.newDBWithTip(tip), conn.newNetwork(con.ID), prvKeys[0],
+		&common.NullLogger{})
+	s.Require().Equal(ErrDBGovernanceMismatch, err)
+}
+
+func (s *ConsensusTestSuite) TestResumeAgreement() {
+	// Nodes are restarted with the same tip in their db, blocks proposed by
+	// one node are received by others through broadcasting.
+	conn := s.newNetworkConnection()
+	prvKeys, pubKeys, err := test.NewKeys(2)
+	s.Require().NoError(err)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		pubKeys, time.Second, &common.NullLogger{}, true), ConfigRoundShift)
+	s.Require().NoError(err)
+	tip := &types.Block{
+		Hash:       common.NewRandomHash(),
+		Position:   types.Position{Height: 3},
+		Timestamp:  time.Now().UTC(),
+		Randomness: NoRand,
+	}
+	dMoment := time.Now().UTC()
+	apps := make([]*test.App, 0, len(prvKeys))
+	cons := make([]*Consensus, 0, len(prvKeys))
+	for _, key := range prvKeys {
+		app, con := s.prepareConsensusWithDB(
+			dMoment, gov, key, conn, s.newDBWithTip(tip))
+		defer con.Stop()
+		// The application has confirmed blocks up to the tip before
+		// restarted.
+		app.LastConfirmedHeight = tip.Position.Height
+		apps = append(apps, app)
+		cons = append(cons, con)
+	}
+	sub := cons[0].Subscribe(1, EventBARestarted)
+	defer sub.Unsubscribe()
+	for _, con := range cons {
+		s.Require().NoError(con.Start(context.Background()))
+	}
+	// BA is restarted at the height following the tip.
+	select {
+	case e := <-sub.Chan():
+		s.Require().Equal(types.Position{Height: tip.Position.Height + 1},
+			e.(*BARestartedEvent).Position)
+	case <-time.After(30 * time.Second):
+		s.FailNow("BA is not restarted after resumed")
+	}
+	// The first block delivered after restarted follows the tip.
+	for i, app := range apps {
+		var first common.Hash
+		for deadline := time.Now().Add(30 * time.Second); ; {
+			app.WithLock(func(app *test.App) {
+				if len(app.DeliverSequence) > 0 {
+					first = app.DeliverSequence[0]
+				}
+			})
+			if (first != common.Hash{}) {
+				break
+			}
+			if time.Now().After(deadline) {
+				s.FailNow("no block delivered after resumed")
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		b, err := cons[i].db.GetBlock(first)
+		s.Require().NoError(err)
+		s.Require().Equal(tip.Position.Height+1, b.Position.Height)
+		s.Require().Equal(tip.Hash, b.ParentHash)
+	}
+}
+
+func (s *ConsensusTestSuite) TestResumeDKGFromDB() {
+	conn := s.newNetworkConnection()
+	prvKeys, pubKeys, err := test.NewKeys(1)
+	s.Require().NoError(err)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		pubKeys, time.Second, &common.NullLogger{}, true), ConfigRoundShift)
+	s.Require().NoError(err)
+	round := DKGDelayRound
+	beginHeight := types.GenesisHeight + gov.Configuration(0).RoundLength
+	gov.NotifyRound(round, beginHeight)
+	// Finish DKG of the round in governance. The only node makes up the
+	// notary set, its share is the group secret key since the threshold is 1.
+	nID := types.NewNodeID(pubKeys[0])
+	signer := utils.NewSigner(prvKeys[0])
+	prvShares, pubShares := cryptoDKG.NewPrivateKeyShares(
+		utils.GetDKGThreshold(gov.Configuration(round)))
+	prvShares.SetParticipants(cryptoDKG.IDs{typesDKG.NewID(nID)})
+	share, exist := prvShares.Share(typesDKG.NewID(nID))
+	s.Require().True(exist)
+	mpk := &typesDKG.MasterPublicKey{
+		Round:           round,
+		DKGID:           typesDKG.NewID(nID),
+		PublicKeyShares: *pubShares.Move(),
+	}
+	s.Require().NoError(signer.SignDKGMasterPublicKey(mpk))
+	gov.AddDKGMasterPublicKey(mpk)
+	final := &typesDKG.Finalize{ProposerID: nID, Round: round}
+	s.Require().NoError(signer.SignDKGFinalize(final))
+	gov.AddDKGFinalize(final)
+	s.Require().True(gov.IsDKGFinal(round))
+	tip := &types.Block{
+		Hash:     common.NewRandomHash(),
+		Position: types.Position{Round: round, Height: beginHeight + 2},
+	}
+	sig, err := share.Sign(tip.Hash)
+	s.Require().NoError(err)
+	tip.Randomness = sig.Signature
+	dkgSigner := func(con *Consensus) *dkgShareSecret {
+		con.cfgModule.dkgResult.RLock()
+		defer con.cfgModule.dkgResult.RUnlock()
+		return con.cfgModule.dkgSigner[round]
+	}
+	// The share can't be recovered without the db.
+	_, con := s.prepareConsensusWithDB(time.Now().UTC().Add(time.Hour), gov,
+		prvKeys[0], conn, s.newDBWithTip(tip))
+	s.Require().Nil(dkgSigner(con))
+	con.Stop()
+	// The share is restored from the db when BA is prepared for the round of
+	// the tip.
+	dbInst := s.newDBWithTip(tip)
+	s.Require().NoError(dbInst.PutDKGPrivateKey(round, 0, *share))
+	_, con = s.prepareConsensusWithDB(time.Now().UTC().Add(time.Hour), gov,
+		prvKeys[0], conn, dbInst)
+	defer con.Stop()
+	s.Require().Equal(round, con.baMgr.curRoundSetting.round)
+	restored := dkgSigner(con)
+	s.Require().NotNil(restored)
+	s.Require().Equal(share.Bytes(), restored.privateKey.Bytes())
+}
+
+// badPeerNetwork only implements ReportBadPeerChan of Network.
+type badPeerNetwork struct {
+	Network
+	sink chan interface{}
+}
+
+func (n *badPeerNetwork) ReportBadPeerChan() chan<- interface{} {
+	return n.sink
+}
+
+// badPeerReporterNetwork implements BadPeerReporter additionally.
+type badPeerReporterNetwork struct {
+	badPeerNetwork
+	reports chan *types.BadPeerReport
+}
+
+func (n *badPeerReporterNetwork) BadPeerReportChan() chan<- *types.BadPeerReport {
+	return n.reports
+}
+
+func (s *ConsensusTestSuite) TestReportBadPeer() {
+	var (
+		metrics     = NewMetricsRegistry()
+		network     = &badPeerNetwork{sink: make(chan interface{}, 1)}
+		ctx, cancel = context.WithCancel(context.Background())
+		peer        = types.NodeID{Hash: common.NewRandomHash()}
+	)
+	defer cancel()
+	con := &Consensus{network: network, metrics: metrics, ctx: ctx}
+	con.reportBadPeer(peer, ErrIncorrectHash)
+	// Reports are dropped instead of blocking when the channel is full.
+	con.reportBadPeer(peer, ErrIncorrectHash)
+	s.Require().Equal(float64(2), metrics.Value(MetricBadPeerReports))
+	s.Require().Equal(float64(1), metrics.Value(MetricBadPeerDropped))
+	s.Require().Equal(peer, <-network.sink)
+	// Reasons are reported if the network supports it.
+	reporter := &badPeerReporterNetwork{
+		badPeerNetwork: badPeerNetwork{sink: make(chan interface{}, 1)},
+		reports:        make(chan *types.BadPeerReport, 1),
+	}
+	con.network = reporter
+	con.reportBadPeer(peer, ErrIncorrectHash)
+	report := <-reporter.reports
+	s.Require().Equal(peer, report.PeerID)
+	s.Require().Equal(ErrIncorrectHash, report.Reason)
+	s.Require().Empty(reporter.sink)
+}
+
 func TestConsensus(t *testing.T) {
 	suite.Run(t, new(ConsensusTestSuite))
 }
@@ -0,0 +1,193 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type batchOpType int
+
+const (
+	batchOpPutBlock batchOpType = iota
+	batchOpUpdateBlock
+	batchOpPutCompactionChainTipInfo
+	batchOpPutDKGPrivateKey
+	batchOpPutOrUpdateDKGProtocol
+)
+
+type batchOp struct {
+	opType      batchOpType
+	block       types.Block
+	hash        common.Hash
+	height      uint64
+	round       uint64
+	reset       uint64
+	prvKey      dkg.PrivateKey
+	dkgProtocol DKGProtocolInfo
+}
+
+// batch implements Batch interface, the commit function is provided by
+// database implementations.
+type batch struct {
+	ops       []batchOp
+	committed bool
+	commit    func(ops []batchOp) error
+}
+
+func newBatch(commit func(ops []batchOp) error) *batch {
+	return &batch{commit: commit}
+}
+
+func (b *batch) PutBlock(block types.Block) {
+	b.ops = append(b.ops, batchOp{opType: batchOpPutBlock, block: block})
+}
+
+func (b *batch) UpdateBlock(block types.Block) {
+	b.ops = append(b.ops, batchOp{opType: batchOpUpdateBlock, block: block})
+}
+
+func (b *batch) PutCompactionChainTipInfo(hash common.Hash, height uint64) {
+	b.ops = append(b.ops, batchOp{
+		opType: batchOpPutCompactionChainTipInfo,
+		hash:   hash,
+		height: height,
+	})
+}
+
+func (b *batch) PutDKGPrivateKey(round, reset uint64, prv dkg.PrivateKey) {
+	b.ops = append(b.ops, batchOp{
+		opType: batchOpPutDKGPrivateKey,
+		round:  round,
+		reset:  reset,
+		prvKey: prv,
+	})
+}
+
+func (b *batch) PutOrUpdateDKGProtocol(dkgProtocol DKGProtocolInfo) {
+	b.ops = append(b.ops, batchOp{
+		opType:      batchOpPutOrUpdateDKGProtocol,
+		dkgProtocol: dkgProtocol,
+	})
+}
+
+func (b *batch) Commit() error {
+	if b.committed {
+		return ErrBatchCommitted
+	}
+	if err := b.commit(b.ops); err != nil {
+		return err
+	}
+	b.committed = true
+	return nil
+}
+
+// batchState is the state of a database needed to check operations in a
+// batch.
+type batchState interface {
+	// getBlockHeight returns the height of a block in database.
+	getBlockHeight(hash common.Hash) (height uint64, exists bool, err error)
+	getCompactionChainTipInfo() (compactionChainTipInfo, error)
+	// getDKGPrivateKeyReset returns the reset of the DKG private key of a
+	// round in database.
+	getDKGPrivateKeyReset(round uint64) (reset uint64, exists bool, err error)
+}
+
+// batchChecker checks operations in a batch with the state of a database and
+// the operations before them in the same batch, the errors returned are the
+// same as the ones returned by Writer methods.
+type batchChecker struct {
+	state     batchState
+	blocks    map[common.Hash]uint64
+	tip       *compactionChainTipInfo
+	dkgResets map[uint64]uint64
+}
+
+func newBatchChecker(state batchState) *batchChecker {
+	return &batchChecker{
+		state:     state,
+		blocks:    make(map[common.Hash]uint64),
+		dkgResets: make(map[uint64]uint64),
+	}
+}
+
+func (c *batchChecker) getBlockHeight(hash common.Hash) (
+	uint64, bool, error) {
+	if height, exists := c.blocks[hash]; exists {
+		return height, true, nil
+	}
+	return c.state.getBlockHeight(hash)
+}
+
+// check checks an operation, the height of the block before updated is
+// returned for batchOpUpdateBlock.
+func (c *batchChecker) check(op *batchOp) (oldHeight uint64, err error) {
+	switch op.opType {
+	case batchOpPutBlock:
+		var exists bool
+		if _, exists, err = c.getBlockHeight(op.block.Hash); err != nil {
+			return
+		}
+		if exists {
+			err = ErrBlockExists
+			return
+		}
+		c.blocks[op.block.Hash] = op.block.Position.Height
+	case batchOpUpdateBlock:
+		var exists bool
+		oldHeight, exists, err = c.getBlockHeight(op.block.Hash)
+		if err != nil {
+			return
+		}
+		if !exists {
+			err = ErrBlockDoesNotExist
+			return
+		}
+		c.blocks[op.block.Hash] = op.block.Position.Height
+	case batchOpPutCompactionChainTipInfo:
+		tip := c.tip
+		if tip == nil {
+			var info compactionChainTipInfo
+			if info, err = c.state.getCompactionChainTipInfo(); err != nil {
+				return
+			}
+			tip = &info
+		}
+		if tip.Height+1 != op.height {
+			err = ErrInvalidCompactionChainTipHeight
+			return
+		}
+		c.tip = &compactionChainTipInfo{Hash: op.hash, Height: op.height}
+	case batchOpPutDKGPrivateKey:
+		reset, exists := c.dkgResets[op.round]
+		if !exists {
+			reset, exists, err = c.state.getDKGPrivateKeyReset(op.round)
+			if err != nil {
+				return
+			}
+		}
+		if exists && reset == op.reset {
+			err = ErrDKGPrivateKeyExists
+			return
+		}
+		c.dkgResets[op.round] = op.reset
+	}
+	return
+}
@@ -0,0 +1,86 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package db_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/dexon-foundation/dexon-consensus/core/db"
+	"github.com/dexon-foundation/dexon-consensus/core/db/dbtest"
+)
+
+// newDirBackedSuite creates a conformance suite for backends opened from a
+// directory.
+func newDirBackedSuite(
+	open func(string) (db.Database, error)) *dbtest.Suite {
+	dirs := make(map[db.Database]string)
+	return &dbtest.Suite{
+		New: func() (db.Database, error) {
+			dir, err := ioutil.TempDir("", "test-db-conformance")
+			if err != nil {
+				return nil, err
+			}
+			dbInst, err := open(dir)
+			if err != nil {
+				os.RemoveAll(dir)
+				return nil, err
+			}
+			dirs[dbInst] = dir
+			return dbInst, nil
+		},
+		Reopen: func(dbInst db.Database) (db.Database, error) {
+			dir := dirs[dbInst]
+			delete(dirs, dbInst)
+			if err := dbInst.Close(); err != nil {
+				return nil, err
+			}
+			reopened, err := open(dir)
+			if err != nil {
+				return nil, err
+			}
+			dirs[reopened] = dir
+			return reopened, nil
+		},
+		Cleanup: func(dbInst db.Database) {
+			os.RemoveAll(dirs[dbInst])
+			delete(dirs, dbInst)
+		},
+	}
+}
+
+func TestLevelDBConformance(t *testing.T) {
+	dbtest.Run(t, newDirBackedSuite(func(dir string) (db.Database, error) {
+		return db.NewLevelDBBackedDB(dir)
+	}))
+}
+
+func TestLogDBConformance(t *testing.T) {
+	dbtest.Run(t, newDirBackedSuite(func(dir string) (db.Database, error) {
+		return db.NewLogBackedDB(dir)
+	}))
+}
+
+func TestMemBackedDBConformance(t *testing.T) {
+	dbtest.Run(t, &dbtest.Suite{
+		New: func() (db.Database, error) {
+			return db.NewMemBackedDB()
+		},
+	})
+}
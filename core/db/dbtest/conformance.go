@@ -0,0 +1,661 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package dbtest provides a conformance test suite for db.Database
+// implementations. The expected behaviors are the ones of
+// db.LevelDBBackedDB.
+//
+// A backend could be tested by:
+//
+//	func TestConformance(t *testing.T) {
+//	    dbtest.Run(t, &dbtest.Suite{New: newMyDB, Reopen: reopenMyDB})
+//	}
+package dbtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/db"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// Suite is the conformance test suite for db.Database implementations.
+type Suite struct {
+	suite.Suite
+
+	// New creates an empty database for each test case.
+	New func() (db.Database, error)
+	// Reopen closes the database and opens it again from the same storage.
+	// Persistence is not tested when it's nil.
+	Reopen func(db.Database) (db.Database, error)
+	// Cleanup releases the storage of a closed database, it could be nil.
+	Cleanup func(db.Database)
+
+	dbInst db.Database
+}
+
+// Run runs the conformance test suite.
+func Run(t *testing.T, s *Suite) {
+	suite.Run(t, s)
+}
+
+// SetupTest implements suite.SetupTestSuite interface.
+func (s *Suite) SetupTest() {
+	s.Require().NotNil(s.New)
+	dbInst, err := s.New()
+	s.Require().NoError(err)
+	s.Require().NotNil(dbInst)
+	s.dbInst = dbInst
+}
+
+// TearDownTest implements suite.TearDownTestSuite interface.
+func (s *Suite) TearDownTest() {
+	s.NoError(s.dbInst.Close())
+	if s.Cleanup != nil {
+		s.Cleanup(s.dbInst)
+	}
+	s.dbInst = nil
+}
+
+func (s *Suite) newBlock(height uint64) types.Block {
+	return types.Block{
+		ProposerID: types.NodeID{Hash: common.NewRandomHash()},
+		ParentHash: common.NewRandomHash(),
+		Hash:       common.NewRandomHash(),
+		Position:   types.Position{Round: 1, Height: height},
+		Timestamp:  time.Now().UTC(),
+		Payload:    []byte{1, 2, 3},
+		Witness: types.Witness{
+			Height: height,
+			Data:   []byte{4, 5, 6},
+		},
+		Randomness: []byte{7, 8, 9},
+	}
+}
+
+func (s *Suite) requireSameBlock(expected, actual types.Block) {
+	s.Require().Equal(expected.Hash, actual.Hash)
+	s.Require().Equal(expected.ProposerID, actual.ProposerID)
+	s.Require().Equal(expected.ParentHash, actual.ParentHash)
+	s.Require().Equal(expected.Position, actual.Position)
+	s.Require().True(expected.Timestamp.Equal(actual.Timestamp))
+	s.Require().Equal(expected.Payload, actual.Payload)
+	s.Require().Equal(expected.Witness.Height, actual.Witness.Height)
+	s.Require().Equal(expected.Witness.Data, actual.Witness.Data)
+	s.Require().Equal(expected.Randomness, actual.Randomness)
+}
+
+func (s *Suite) reopen() {
+	dbInst, err := s.Reopen(s.dbInst)
+	s.Require().NoError(err)
+	s.Require().NotNil(dbInst)
+	s.dbInst = dbInst
+}
+
+// TestBlock checks reading and writing blocks.
+func (s *Suite) TestBlock() {
+	block := s.newBlock(1)
+	// Queried something from an empty database.
+	s.Require().False(s.dbInst.HasBlock(block.Hash))
+	_, err := s.dbInst.GetBlock(block.Hash)
+	s.Require().Equal(db.ErrBlockDoesNotExist, err)
+	// Update on an empty database should not success.
+	s.Require().Equal(db.ErrBlockDoesNotExist, s.dbInst.UpdateBlock(block))
+	// Put to create a new record should just work fine.
+	s.Require().NoError(s.dbInst.PutBlock(block))
+	s.Require().True(s.dbInst.HasBlock(block.Hash))
+	// Put again should not success, and the block should not be changed.
+	modified := block
+	modified.Payload = []byte{3, 2, 1}
+	s.Require().Equal(db.ErrBlockExists, s.dbInst.PutBlock(modified))
+	queried, err := s.dbInst.GetBlock(block.Hash)
+	s.Require().NoError(err)
+	s.requireSameBlock(block, queried)
+	// Update should work fine.
+	s.Require().NoError(s.dbInst.UpdateBlock(modified))
+	queried, err = s.dbInst.GetBlock(block.Hash)
+	s.Require().NoError(err)
+	s.requireSameBlock(modified, queried)
+	// Other blocks should not be affected.
+	s.Require().False(s.dbInst.HasBlock(common.NewRandomHash()))
+}
+
+// TestGetAllBlocks checks iterating all blocks, it's skipped when the
+// database returns db.ErrNotImplemented.
+func (s *Suite) TestGetAllBlocks() {
+	blocks := make(map[common.Hash]types.Block)
+	for i := uint64(1); i <= 5; i++ {
+		b := s.newBlock(i)
+		s.Require().NoError(s.dbInst.PutBlock(b))
+		blocks[b.Hash] = b
+	}
+	iter, err := s.dbInst.GetAllBlocks()
+	if err == db.ErrNotImplemented {
+		s.T().Skip("GetAllBlocks is not implemented")
+	}
+	s.Require().NoError(err)
+	for {
+		b, err := iter.NextBlock()
+		if err == db.ErrIterationFinished {
+			break
+		}
+		s.Require().NoError(err)
+		expected, exists := blocks[b.Hash]
+		s.Require().True(exists)
+		s.requireSameBlock(expected, b)
+		delete(blocks, b.Hash)
+	}
+	s.Require().Empty(blocks)
+}
+
+// TestBlocksByHeight checks querying blocks by height.
+func (s *Suite) TestBlocksByHeight() {
+	requireHeights := func(blocks []types.Block, heights ...uint64) {
+		s.Require().Len(blocks, len(heights))
+		for i, b := range blocks {
+			s.Require().Equal(heights[i], b.Position.Height)
+		}
+	}
+	iterHeights := func(from uint64) (heights []uint64) {
+		iter, err := s.dbInst.GetBlocksFromHeight(from)
+		s.Require().NoError(err)
+		for {
+			b, err := iter.NextBlock()
+			if err == db.ErrIterationFinished {
+				break
+			}
+			s.Require().NoError(err)
+			heights = append(heights, b.Position.Height)
+		}
+		// It should keep returning db.ErrIterationFinished.
+		_, err = iter.NextBlock()
+		s.Require().Equal(db.ErrIterationFinished, err)
+		return
+	}
+	// Nothing in an empty database.
+	_, err := s.dbInst.GetBlockByHeight(1)
+	s.Require().Equal(db.ErrBlockDoesNotExist, err)
+	blocks, err := s.dbInst.GetBlocksInRange(0, 10)
+	s.Require().NoError(err)
+	s.Require().Empty(blocks)
+	s.Require().Empty(iterHeights(0))
+	// Put blocks in the order different from height, and skip height 4.
+	byHeight := make(map[uint64]types.Block)
+	for _, h := range []uint64{3, 1, 5, 2, 6} {
+		b := s.newBlock(h)
+		s.Require().NoError(s.dbInst.PutBlock(b))
+		byHeight[h] = b
+	}
+	for h, b := range byHeight {
+		queried, err := s.dbInst.GetBlockByHeight(h)
+		s.Require().NoError(err)
+		s.requireSameBlock(b, queried)
+	}
+	_, err = s.dbInst.GetBlockByHeight(4)
+	s.Require().Equal(db.ErrBlockDoesNotExist, err)
+	// Range queries.
+	blocks, err = s.dbInst.GetBlocksInRange(1, 4)
+	s.Require().NoError(err)
+	requireHeights(blocks, 1, 2, 3)
+	for i, b := range blocks {
+		s.requireSameBlock(byHeight[uint64(i+1)], b)
+	}
+	blocks, err = s.dbInst.GetBlocksInRange(2, 100)
+	s.Require().NoError(err)
+	requireHeights(blocks, 2, 3, 5, 6)
+	blocks, err = s.dbInst.GetBlocksInRange(4, 5)
+	s.Require().NoError(err)
+	s.Require().Empty(blocks)
+	blocks, err = s.dbInst.GetBlocksInRange(5, 2)
+	s.Require().NoError(err)
+	s.Require().Empty(blocks)
+	// Iterate in the order of height.
+	s.Require().Equal([]uint64{1, 2, 3, 5, 6}, iterHeights(0))
+	s.Require().Equal([]uint64{5, 6}, iterHeights(4))
+	s.Require().Empty(iterHeights(7))
+	// Blocks put during iterating should be visible.
+	iter, err := s.dbInst.GetBlocksFromHeight(6)
+	s.Require().NoError(err)
+	b, err := iter.NextBlock()
+	s.Require().NoError(err)
+	s.Require().Equal(uint64(6), b.Position.Height)
+	s.Require().NoError(s.dbInst.PutBlock(s.newBlock(7)))
+	b, err = iter.NextBlock()
+	s.Require().NoError(err)
+	s.Require().Equal(uint64(7), b.Position.Height)
+	// Updating a block should keep it indexed.
+	updated := byHeight[3]
+	updated.Payload = []byte{9, 9, 9}
+	s.Require().NoError(s.dbInst.UpdateBlock(updated))
+	queried, err := s.dbInst.GetBlockByHeight(3)
+	s.Require().NoError(err)
+	s.requireSameBlock(updated, queried)
+	if s.Reopen == nil {
+		return
+	}
+	// The index should be kept after reopened.
+	s.reopen()
+	queried, err = s.dbInst.GetBlockByHeight(3)
+	s.Require().NoError(err)
+	s.requireSameBlock(updated, queried)
+	s.Require().Equal([]uint64{1, 2, 3, 5, 6, 7}, iterHeights(0))
+}
+
+// TestCompactionChainTipInfo checks the height of compaction chain tip
+// should be incremented by one.
+func (s *Suite) TestCompactionChainTipInfo() {
+	// The tip is empty at first.
+	hash, height := s.dbInst.GetCompactionChainTipInfo()
+	s.Require().Equal(common.Hash{}, hash)
+	s.Require().Equal(uint64(0), height)
+	// Save some tip info.
+	hash1 := common.NewRandomHash()
+	s.Require().NoError(s.dbInst.PutCompactionChainTipInfo(hash1, 1))
+	hash, height = s.dbInst.GetCompactionChainTipInfo()
+	s.Require().Equal(hash1, hash)
+	s.Require().Equal(uint64(1), height)
+	// Unable to put compaction chain tip info with lower or the same height.
+	hash2 := common.NewRandomHash()
+	s.Require().Equal(db.ErrInvalidCompactionChainTipHeight,
+		s.dbInst.PutCompactionChainTipInfo(hash2, 0))
+	s.Require().Equal(db.ErrInvalidCompactionChainTipHeight,
+		s.dbInst.PutCompactionChainTipInfo(hash2, 1))
+	// Unable to put compaction chain tip info with height not incremental
+	// by 1.
+	s.Require().Equal(db.ErrInvalidCompactionChainTipHeight,
+		s.dbInst.PutCompactionChainTipInfo(hash2, 3))
+	// The tip should not be changed by failed attempts.
+	hash, height = s.dbInst.GetCompactionChainTipInfo()
+	s.Require().Equal(hash1, hash)
+	s.Require().Equal(uint64(1), height)
+	// It's OK to put compaction chain tip info with height incremental by 1.
+	s.Require().NoError(s.dbInst.PutCompactionChainTipInfo(hash2, 2))
+	hash, height = s.dbInst.GetCompactionChainTipInfo()
+	s.Require().Equal(hash2, hash)
+	s.Require().Equal(uint64(2), height)
+}
+
+// TestDKGPrivateKey checks DKG private keys are identified by round and
+// reset, and only the one of the latest reset is kept for a round.
+func (s *Suite) TestDKGPrivateKey() {
+	p := dkg.NewPrivateKey()
+	// We should be unable to get it.
+	_, err := s.dbInst.GetDKGPrivateKey(1, 0)
+	s.Require().Equal(db.ErrDKGPrivateKeyDoesNotExist, err)
+	// Put it.
+	s.Require().NoError(s.dbInst.PutDKGPrivateKey(1, 0, *p))
+	// We should be unable to get it because reset or round is different.
+	_, err = s.dbInst.GetDKGPrivateKey(1, 1)
+	s.Require().Equal(db.ErrDKGPrivateKeyDoesNotExist, err)
+	_, err = s.dbInst.GetDKGPrivateKey(2, 0)
+	s.Require().Equal(db.ErrDKGPrivateKeyDoesNotExist, err)
+	// Put it again, should not success.
+	s.Require().Equal(db.ErrDKGPrivateKeyExists,
+		s.dbInst.PutDKGPrivateKey(1, 0, *p))
+	// Get it back.
+	prv, err := s.dbInst.GetDKGPrivateKey(1, 0)
+	s.Require().NoError(err)
+	s.Require().Equal(p.Bytes(), prv.Bytes())
+	// Put it at different reset, it replaces the previous one.
+	p2 := dkg.NewPrivateKey()
+	s.Require().NoError(s.dbInst.PutDKGPrivateKey(1, 1, *p2))
+	_, err = s.dbInst.GetDKGPrivateKey(1, 0)
+	s.Require().Equal(db.ErrDKGPrivateKeyDoesNotExist, err)
+	prv, err = s.dbInst.GetDKGPrivateKey(1, 1)
+	s.Require().NoError(err)
+	s.Require().Equal(p2.Bytes(), prv.Bytes())
+	// Keys of other rounds should not be affected.
+	p3 := dkg.NewPrivateKey()
+	s.Require().NoError(s.dbInst.PutDKGPrivateKey(2, 0, *p3))
+	prv, err = s.dbInst.GetDKGPrivateKey(1, 1)
+	s.Require().NoError(err)
+	s.Require().Equal(p2.Bytes(), prv.Bytes())
+}
+
+func newDKGProtocolInfo(round uint64) db.DKGProtocolInfo {
+	return db.DKGProtocolInfo{
+		ID:        types.NodeID{Hash: common.Hash{0x11}},
+		Round:     round,
+		Threshold: 10,
+		IDMap: db.NodeIDToDKGID{
+			types.NodeID{Hash: common.Hash{0x01}}: dkg.ID{},
+			types.NodeID{Hash: common.Hash{0x02}}: dkg.ID{},
+		},
+		MpkMap: db.NodeIDToPubShares{
+			types.NodeID{Hash: common.Hash{0x01}}: dkg.NewEmptyPublicKeyShares(),
+			types.NodeID{Hash: common.Hash{0x02}}: dkg.NewEmptyPublicKeyShares(),
+		},
+		MasterPrivateShare:        *dkg.NewEmptyPrivateKeyShares(),
+		IsMasterPrivateShareEmpty: true,
+		PrvShares:                 *dkg.NewEmptyPrivateKeyShares(),
+		IsPrvSharesEmpty:          true,
+		PrvSharesReceived: db.NodeID{
+			types.NodeID{Hash: common.Hash{0x01}}: struct{}{},
+		},
+		NodeComplained: db.NodeID{
+			types.NodeID{Hash: common.Hash{0x02}}: struct{}{},
+		},
+		AntiComplaintReceived: db.NodeIDToNodeIDs{
+			types.NodeID{Hash: common.Hash{0x01}}: map[types.NodeID]struct{}{
+				types.NodeID{Hash: common.Hash{0x02}}: {},
+			},
+			types.NodeID{Hash: common.Hash{0x03}}: map[types.NodeID]struct{}{
+				types.NodeID{Hash: common.Hash{0x04}}: {},
+			},
+		},
+		Step:  3,
+		Reset: 1,
+	}
+}
+
+// TestDKGProtocol checks DKGProtocolInfo round-trip, and only the latest one
+// is kept.
+func (s *Suite) TestDKGProtocol() {
+	_, err := s.dbInst.GetDKGProtocol()
+	s.Require().Equal(db.ErrDKGProtocolDoesNotExist, err)
+	info := newDKGProtocolInfo(5)
+	s.Require().NoError(s.dbInst.PutOrUpdateDKGProtocol(info))
+	queried, err := s.dbInst.GetDKGProtocol()
+	s.Require().NoError(err)
+	s.Require().True(info.Equal(&queried))
+	// Update it.
+	info2 := newDKGProtocolInfo(6)
+	s.Require().NoError(s.dbInst.PutOrUpdateDKGProtocol(info2))
+	queried, err = s.dbInst.GetDKGProtocol()
+	s.Require().NoError(err)
+	s.Require().True(info2.Equal(&queried))
+	s.Require().False(info.Equal(&queried))
+}
+
+func newAgreementState(height uint64) db.AgreementState {
+	state := db.AgreementState{
+		Position:  types.Position{Round: 1, Height: height},
+		Period:    3,
+		LockValue: common.NewRandomHash(),
+		LockIter:  2,
+	}
+	for period := uint64(1); period <= 2; period++ {
+		v := types.NewVote(types.VotePreCom, common.NewRandomHash(), period)
+		v.ProposerID = types.NodeID{Hash: common.NewRandomHash()}
+		v.Position = state.Position
+		v.PartialSignature.Type = "bls"
+		v.PartialSignature.Signature = []byte{1, 2, 3}
+		v.Signature.Type = "ecdsa"
+		v.Signature.Signature = []byte{4, 5, 6}
+		state.Votes = append(state.Votes, *v)
+	}
+	return state
+}
+
+// TestAgreementState checks agreement state round-trip, and only the latest
+// one is kept.
+func (s *Suite) TestAgreementState() {
+	_, err := s.dbInst.GetAgreementState()
+	s.Require().Equal(db.ErrAgreementStateDoesNotExist, err)
+	state := newAgreementState(10)
+	s.Require().NoError(s.dbInst.PutOrUpdateAgreementState(state))
+	queried, err := s.dbInst.GetAgreementState()
+	s.Require().NoError(err)
+	s.Require().Equal(state, queried)
+	// Update it.
+	state2 := newAgreementState(11)
+	s.Require().NoError(s.dbInst.PutOrUpdateAgreementState(state2))
+	queried, err = s.dbInst.GetAgreementState()
+	s.Require().NoError(err)
+	s.Require().Equal(state2, queried)
+}
+
+func newEvidenceRecord() db.EvidenceRecord {
+	return db.EvidenceRecord{
+		Hash: common.NewRandomHash(),
+		Evidence: types.Evidence{
+			ProposerID: types.NodeID{Hash: common.NewRandomHash()},
+			Type:       types.EvidenceForkVote,
+			Offender:   types.NodeID{Hash: common.NewRandomHash()},
+			Payload:    []byte{1, 2, 3},
+			Signature: crypto.Signature{
+				Type:      "ecdsa",
+				Signature: []byte{4, 5, 6},
+			},
+		},
+	}
+}
+
+// TestEvidence checks evidence records are identified by their hashes, and
+// could be updated and deleted.
+func (s *Suite) TestEvidence() {
+	records, err := s.dbInst.GetAllEvidences()
+	s.Require().NoError(err)
+	s.Require().Empty(records)
+	record1, record2 := newEvidenceRecord(), newEvidenceRecord()
+	_, err = s.dbInst.GetEvidence(record1.Hash)
+	s.Require().Equal(db.ErrEvidenceDoesNotExist, err)
+	s.Require().NoError(s.dbInst.PutOrUpdateEvidence(record1))
+	s.Require().NoError(s.dbInst.PutOrUpdateEvidence(record2))
+	queried, err := s.dbInst.GetEvidence(record1.Hash)
+	s.Require().NoError(err)
+	s.Require().Equal(record1, queried)
+	// Update it.
+	record1.Submitted = true
+	s.Require().NoError(s.dbInst.PutOrUpdateEvidence(record1))
+	queried, err = s.dbInst.GetEvidence(record1.Hash)
+	s.Require().NoError(err)
+	s.Require().Equal(record1, queried)
+	records, err = s.dbInst.GetAllEvidences()
+	s.Require().NoError(err)
+	s.Require().Len(records, 2)
+	s.Require().Contains(records, record1)
+	s.Require().Contains(records, record2)
+	// Delete it.
+	s.Require().NoError(s.dbInst.DeleteEvidence(record1.Hash))
+	_, err = s.dbInst.GetEvidence(record1.Hash)
+	s.Require().Equal(db.ErrEvidenceDoesNotExist, err)
+	s.Require().NoError(s.dbInst.DeleteEvidence(record1.Hash))
+	records, err = s.dbInst.GetAllEvidences()
+	s.Require().NoError(err)
+	s.Require().Equal([]db.EvidenceRecord{record2}, records)
+}
+
+// TestPersistence checks everything is kept after reopening the database.
+func (s *Suite) TestPersistence() {
+	if s.Reopen == nil {
+		s.T().Skip("Reopen is not provided")
+	}
+	block := s.newBlock(1)
+	s.Require().NoError(s.dbInst.PutBlock(block))
+	s.Require().NoError(s.dbInst.PutCompactionChainTipInfo(block.Hash, 1))
+	p := dkg.NewPrivateKey()
+	s.Require().NoError(s.dbInst.PutDKGPrivateKey(1, 2, *p))
+	info := newDKGProtocolInfo(1)
+	s.Require().NoError(s.dbInst.PutOrUpdateDKGProtocol(info))
+	state := newAgreementState(1)
+	s.Require().NoError(s.dbInst.PutOrUpdateAgreementState(state))
+	record := newEvidenceRecord()
+	s.Require().NoError(s.dbInst.PutOrUpdateEvidence(record))
+	s.reopen()
+	queriedBlock, err := s.dbInst.GetBlock(block.Hash)
+	s.Require().NoError(err)
+	s.requireSameBlock(block, queriedBlock)
+	hash, height := s.dbInst.GetCompactionChainTipInfo()
+	s.Require().Equal(block.Hash, hash)
+	s.Require().Equal(uint64(1), height)
+	prv, err := s.dbInst.GetDKGPrivateKey(1, 2)
+	s.Require().NoError(err)
+	s.Require().Equal(p.Bytes(), prv.Bytes())
+	queriedInfo, err := s.dbInst.GetDKGProtocol()
+	s.Require().NoError(err)
+	s.Require().True(info.Equal(&queriedInfo))
+	queriedState, err := s.dbInst.GetAgreementState()
+	s.Require().NoError(err)
+	s.Require().Equal(state, queriedState)
+	queriedRecord, err := s.dbInst.GetEvidence(record.Hash)
+	s.Require().NoError(err)
+	s.Require().Equal(record, queriedRecord)
+	// Constraints should still be applied after reopened.
+	s.Require().Equal(db.ErrBlockExists, s.dbInst.PutBlock(block))
+	s.Require().Equal(db.ErrInvalidCompactionChainTipHeight,
+		s.dbInst.PutCompactionChainTipInfo(block.Hash, 1))
+	s.Require().Equal(db.ErrDKGPrivateKeyExists,
+		s.dbInst.PutDKGPrivateKey(1, 2, *p))
+}
+
+// TestBatch checks writes in a batch are applied together, and are checked
+// with the ones before them in the same batch.
+func (s *Suite) TestBatch() {
+	block1 := s.newBlock(1)
+	block2 := s.newBlock(2)
+	batch := s.dbInst.NewBatch()
+	batch.PutBlock(block1)
+	batch.PutCompactionChainTipInfo(block1.Hash, 1)
+	batch.PutBlock(block2)
+	batch.PutCompactionChainTipInfo(block2.Hash, 2)
+	// Nothing is written before committed.
+	s.Require().False(s.dbInst.HasBlock(block1.Hash))
+	s.Require().NoError(batch.Commit())
+	s.Require().Equal(db.ErrBatchCommitted, batch.Commit())
+	for _, b := range []types.Block{block1, block2} {
+		queried, err := s.dbInst.GetBlockByHeight(b.Position.Height)
+		s.Require().NoError(err)
+		s.requireSameBlock(b, queried)
+	}
+	hash, height := s.dbInst.GetCompactionChainTipInfo()
+	s.Require().Equal(block2.Hash, hash)
+	s.Require().Equal(uint64(2), height)
+	// Update a block put in the same batch.
+	block3 := s.newBlock(3)
+	modified := block3
+	modified.Position.Height = 4
+	batch = s.dbInst.NewBatch()
+	batch.PutBlock(block3)
+	batch.UpdateBlock(modified)
+	s.Require().NoError(batch.Commit())
+	queried, err := s.dbInst.GetBlock(block3.Hash)
+	s.Require().NoError(err)
+	s.requireSameBlock(modified, queried)
+	_, err = s.dbInst.GetBlockByHeight(3)
+	s.Require().Equal(db.ErrBlockDoesNotExist, err)
+	// An empty batch could be committed.
+	s.Require().NoError(s.dbInst.NewBatch().Commit())
+}
+
+// TestBatchAtomicity checks nothing in a batch is written when any write
+// fails.
+func (s *Suite) TestBatchAtomicity() {
+	block1 := s.newBlock(1)
+	s.Require().NoError(s.dbInst.PutBlock(block1))
+	block2 := s.newBlock(2)
+	modified := block1
+	modified.Payload = []byte{3, 2, 1}
+	check := func(expected error, fill func(db.Batch)) {
+		batch := s.dbInst.NewBatch()
+		fill(batch)
+		s.Require().Equal(expected, batch.Commit())
+		s.Require().False(s.dbInst.HasBlock(block2.Hash))
+		queried, err := s.dbInst.GetBlock(block1.Hash)
+		s.Require().NoError(err)
+		s.requireSameBlock(block1, queried)
+		hash, height := s.dbInst.GetCompactionChainTipInfo()
+		s.Require().Equal(common.Hash{}, hash)
+		s.Require().Equal(uint64(0), height)
+	}
+	check(db.ErrBlockExists, func(batch db.Batch) {
+		batch.UpdateBlock(modified)
+		batch.PutBlock(block2)
+		batch.PutBlock(block1)
+	})
+	check(db.ErrBlockDoesNotExist, func(batch db.Batch) {
+		batch.PutCompactionChainTipInfo(block1.Hash, 1)
+		batch.UpdateBlock(s.newBlock(3))
+	})
+	check(db.ErrInvalidCompactionChainTipHeight, func(batch db.Batch) {
+		batch.PutBlock(block2)
+		batch.PutCompactionChainTipInfo(block1.Hash, 1)
+		batch.PutCompactionChainTipInfo(block2.Hash, 1)
+	})
+	// A failed batch could not be committed again successfully.
+	batch := s.dbInst.NewBatch()
+	batch.PutBlock(block1)
+	s.Require().Equal(db.ErrBlockExists, batch.Commit())
+	s.Require().Equal(db.ErrBlockExists, batch.Commit())
+}
+
+// TestBatchDKG checks DKG private keys and protocol info in a batch.
+func (s *Suite) TestBatchDKG() {
+	p := dkg.NewPrivateKey()
+	info := newDKGProtocolInfo(1)
+	batch := s.dbInst.NewBatch()
+	batch.PutDKGPrivateKey(1, 0, *p)
+	batch.PutOrUpdateDKGProtocol(info)
+	s.Require().NoError(batch.Commit())
+	prv, err := s.dbInst.GetDKGPrivateKey(1, 0)
+	s.Require().NoError(err)
+	s.Require().Equal(p.Bytes(), prv.Bytes())
+	queried, err := s.dbInst.GetDKGProtocol()
+	s.Require().NoError(err)
+	s.Require().True(info.Equal(&queried))
+	// The key of the same round and reset exists.
+	info2 := newDKGProtocolInfo(2)
+	batch = s.dbInst.NewBatch()
+	batch.PutOrUpdateDKGProtocol(info2)
+	batch.PutDKGPrivateKey(1, 0, *dkg.NewPrivateKey())
+	s.Require().Equal(db.ErrDKGPrivateKeyExists, batch.Commit())
+	queried, err = s.dbInst.GetDKGProtocol()
+	s.Require().NoError(err)
+	s.Require().True(info.Equal(&queried))
+	// Keys of the same round and reset in one batch.
+	batch = s.dbInst.NewBatch()
+	batch.PutDKGPrivateKey(2, 0, *p)
+	batch.PutDKGPrivateKey(2, 0, *p)
+	s.Require().Equal(db.ErrDKGPrivateKeyExists, batch.Commit())
+	_, err = s.dbInst.GetDKGPrivateKey(2, 0)
+	s.Require().Equal(db.ErrDKGPrivateKeyDoesNotExist, err)
+}
+
+// TestBatchPersistence checks writes in a batch are kept after reopening the
+// database.
+func (s *Suite) TestBatchPersistence() {
+	if s.Reopen == nil {
+		s.T().Skip("Reopen is not provided")
+	}
+	block1 := s.newBlock(1)
+	s.Require().NoError(s.dbInst.PutBlock(block1))
+	block2 := s.newBlock(2)
+	modified := block1
+	modified.Payload = []byte{3, 2, 1}
+	batch := s.dbInst.NewBatch()
+	batch.UpdateBlock(modified)
+	batch.PutBlock(block2)
+	batch.PutCompactionChainTipInfo(block1.Hash, 1)
+	batch.PutCompactionChainTipInfo(block2.Hash, 2)
+	s.Require().NoError(batch.Commit())
+	s.reopen()
+	queried, err := s.dbInst.GetBlock(block1.Hash)
+	s.Require().NoError(err)
+	s.requireSameBlock(modified, queried)
+	queried, err = s.dbInst.GetBlockByHeight(2)
+	s.Require().NoError(err)
+	s.requireSameBlock(block2, queried)
+	hash, height := s.dbInst.GetCompactionChainTipInfo()
+	s.Require().Equal(block2.Hash, hash)
+	s.Require().Equal(uint64(2), height)
+}
@@ -0,0 +1,124 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"math"
+	"sort"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// heightIndex is an in-memory index from block height to block hash.
+type heightIndex struct {
+	heights []uint64
+	hashes  map[uint64]common.Hash
+}
+
+func newHeightIndex() *heightIndex {
+	return &heightIndex{hashes: make(map[uint64]common.Hash)}
+}
+
+// search returns the index of the lowest height not less than the given one.
+func (idx *heightIndex) search(height uint64) int {
+	return sort.Search(len(idx.heights), func(i int) bool {
+		return idx.heights[i] >= height
+	})
+}
+
+func (idx *heightIndex) put(height uint64, hash common.Hash) {
+	if _, exists := idx.hashes[height]; !exists {
+		// Blocks are usually indexed with incremental heights.
+		if len(idx.heights) == 0 || idx.heights[len(idx.heights)-1] < height {
+			idx.heights = append(idx.heights, height)
+		} else {
+			i := idx.search(height)
+			idx.heights = append(idx.heights, 0)
+			copy(idx.heights[i+1:], idx.heights[i:])
+			idx.heights[i] = height
+		}
+	}
+	idx.hashes[height] = hash
+}
+
+// remove removes the height from index if it's indexed to the hash.
+func (idx *heightIndex) remove(height uint64, hash common.Hash) {
+	if indexed, exists := idx.hashes[height]; !exists || indexed != hash {
+		return
+	}
+	delete(idx.hashes, height)
+	i := idx.search(height)
+	idx.heights = append(idx.heights[:i], idx.heights[i+1:]...)
+}
+
+func (idx *heightIndex) get(height uint64) (hash common.Hash, exists bool) {
+	hash, exists = idx.hashes[height]
+	return
+}
+
+// getFrom returns the hash of the block with the lowest height not less than
+// the given one.
+func (idx *heightIndex) getFrom(height uint64) (
+	hash common.Hash, exists bool) {
+	i := idx.search(height)
+	if i == len(idx.heights) {
+		return
+	}
+	return idx.get(idx.heights[i])
+}
+
+// getRange returns hashes of blocks with heights in [from, to).
+func (idx *heightIndex) getRange(from, to uint64) (hashes common.Hashes) {
+	for i := idx.search(from); i < len(idx.heights); i++ {
+		if idx.heights[i] >= to {
+			break
+		}
+		hashes = append(hashes, idx.hashes[idx.heights[i]])
+	}
+	return
+}
+
+// blockHeightIterator iterates blocks in the order of height.
+type blockHeightIterator struct {
+	nextHeight uint64
+	finished   bool
+	// getFrom returns the block with the lowest height not less than the
+	// given one, or ErrIterationFinished if there is no such block.
+	getFrom func(height uint64) (types.Block, error)
+}
+
+// NextBlock implements BlockIterator.NextBlock method.
+func (iter *blockHeightIterator) NextBlock() (b types.Block, err error) {
+	if iter.finished {
+		err = ErrIterationFinished
+		return
+	}
+	if b, err = iter.getFrom(iter.nextHeight); err != nil {
+		if err == ErrIterationFinished {
+			iter.finished = true
+		}
+		return
+	}
+	if b.Position.Height == math.MaxUint64 {
+		iter.finished = true
+	} else {
+		iter.nextHeight = b.Position.Height + 1
+	}
+	return
+}
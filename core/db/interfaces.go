@@ -56,6 +56,14 @@ var (
 	// ErrDKGProtocolDoesNotExist raised when the DKG protocol of the
 	// requested round does not exists.
 	ErrDKGProtocolDoesNotExist = errors.New("dkg protocol does not exists")
+	// ErrAgreementStateDoesNotExist raised when no agreement state is saved.
+	ErrAgreementStateDoesNotExist = errors.New(
+		"agreement state does not exists")
+	// ErrEvidenceDoesNotExist raised when the requested evidence does not
+	// exist.
+	ErrEvidenceDoesNotExist = errors.New("evidence does not exist")
+	// ErrBatchCommitted raised when committing a batch more than once.
+	ErrBatchCommitted = errors.New("batch committed")
 )
 
 // Database is the interface for a Database.
@@ -74,6 +82,16 @@ type Reader interface {
 	GetBlock(hash common.Hash) (types.Block, error)
 	GetAllBlocks() (BlockIterator, error)
 
+	// GetBlockByHeight returns the block at the height, ErrBlockDoesNotExist
+	// is returned if there is no such block.
+	GetBlockByHeight(height uint64) (types.Block, error)
+	// GetBlocksInRange returns blocks with heights in [from, to) in the order
+	// of height, heights without blocks are skipped.
+	GetBlocksInRange(from, to uint64) ([]types.Block, error)
+	// GetBlocksFromHeight returns an iterator on blocks with heights not less
+	// than the given one in the order of height.
+	GetBlocksFromHeight(from uint64) (BlockIterator, error)
+
 	// GetCompactionChainTipInfo returns the block hash and finalization height
 	// of the tip block of compaction chain. Empty hash and zero height means
 	// the compaction chain is empty.
@@ -82,6 +100,14 @@ type Reader interface {
 	// DKG Private Key related methods.
 	GetDKGPrivateKey(round, reset uint64) (dkg.PrivateKey, error)
 	GetDKGProtocol() (dkgProtocol DKGProtocolInfo, err error)
+
+	// GetAgreementState returns the latest agreement state saved.
+	GetAgreementState() (AgreementState, error)
+
+	// GetEvidence returns the evidence record identified by the hash.
+	GetEvidence(hash common.Hash) (EvidenceRecord, error)
+	// GetAllEvidences returns all evidence records saved.
+	GetAllEvidences() ([]EvidenceRecord, error)
 }
 
 // Writer defines the interface for writing blocks into DB.
@@ -91,6 +117,35 @@ type Writer interface {
 	PutCompactionChainTipInfo(common.Hash, uint64) error
 	PutDKGPrivateKey(round, reset uint64, pk dkg.PrivateKey) error
 	PutOrUpdateDKGProtocol(dkgProtocol DKGProtocolInfo) error
+
+	// PutOrUpdateAgreementState should be called before any vote in the state
+	// is sent, so the node is able to recover from it after crashed.
+	PutOrUpdateAgreementState(state AgreementState) error
+
+	// PutOrUpdateEvidence saves an evidence record, the write is synced to
+	// disk before returning.
+	PutOrUpdateEvidence(record EvidenceRecord) error
+	// DeleteEvidence removes an evidence record, deleting a record not
+	// existing is not an error.
+	DeleteEvidence(hash common.Hash) error
+
+	// NewBatch creates a batch whose writes are applied atomically.
+	NewBatch() Batch
+}
+
+// Batch collects writes and applies them to the database atomically when
+// committed. Either all writes in a batch are applied, or none of them is
+// applied and the error of the first failed one is returned by Commit.
+type Batch interface {
+	UpdateBlock(block types.Block)
+	PutBlock(block types.Block)
+	PutCompactionChainTipInfo(common.Hash, uint64)
+	PutDKGPrivateKey(round, reset uint64, pk dkg.PrivateKey)
+	PutOrUpdateDKGProtocol(dkgProtocol DKGProtocolInfo)
+
+	// Commit applies writes in the batch, a batch can only be committed
+	// once.
+	Commit() error
 }
 
 // BlockIterator defines an iterator on blocks hold
@@ -98,3 +153,46 @@ type Writer interface {
 type BlockIterator interface {
 	NextBlock() (types.Block, error)
 }
+
+// AgreementState is the write-ahead log of the agreement of a position, which
+// keeps votes signed by the node itself and its lock state. It's used to
+// avoid signing conflicting votes after restarted.
+type AgreementState struct {
+	Position  types.Position
+	Period    uint64
+	LockValue common.Hash
+	LockIter  uint64
+	Votes     []types.Vote
+}
+
+// Clone returns a deep copy of an agreement state.
+func (s *AgreementState) Clone() *AgreementState {
+	n := &AgreementState{
+		Position:  s.Position,
+		Period:    s.Period,
+		LockValue: s.LockValue,
+		LockIter:  s.LockIter,
+		Votes:     make([]types.Vote, 0, len(s.Votes)),
+	}
+	for _, v := range s.Votes {
+		n.Votes = append(n.Votes, *v.Clone())
+	}
+	return n
+}
+
+// EvidenceRecord is an evidence of misbehaviour kept by the node with its
+// submission state.
+type EvidenceRecord struct {
+	Hash      common.Hash
+	Evidence  types.Evidence
+	Submitted bool
+}
+
+// Clone returns a deep copy of an evidence record.
+func (r *EvidenceRecord) Clone() *EvidenceRecord {
+	return &EvidenceRecord{
+		Hash:      r.Hash,
+		Evidence:  *r.Evidence.Clone(),
+		Submitted: r.Submitted,
+	}
+}
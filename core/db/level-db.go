@@ -22,6 +22,8 @@ import (
 	"io"
 
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 
 	"github.com/dexon-foundation/dexon-consensus/common"
 	"github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
@@ -31,9 +33,13 @@ import (
 
 var (
 	blockKeyPrefix            = []byte("b-")
+	blockHeightKeyPrefix      = []byte("bh-")
+	blockHeightIndexedKey     = []byte("height-indexed")
 	compactionChainTipInfoKey = []byte("cc-tip")
 	dkgPrivateKeyKeyPrefix    = []byte("dkg-prvs")
 	dkgProtocolInfoKeyPrefix  = []byte("dkg-protocol-info")
+	agreementStateKey         = []byte("agreement-state")
+	evidenceKeyPrefix         = []byte("evidence-")
 )
 
 type compactionChainTipInfo struct {
@@ -355,9 +361,37 @@ func NewLevelDBBackedDB(
 		return
 	}
 	lvl = &LevelDBBackedDB{db: dbInst}
+	if err = lvl.buildHeightIndex(); err != nil {
+		dbInst.Close()
+		lvl = nil
+	}
 	return
 }
 
+// buildHeightIndex builds the height index for blocks saved by versions
+// without that index.
+func (lvl *LevelDBBackedDB) buildHeightIndex() error {
+	indexed, err := lvl.db.Has(blockHeightIndexedKey, nil)
+	if err != nil || indexed {
+		return err
+	}
+	batch := new(leveldb.Batch)
+	iter := lvl.db.NewIterator(util.BytesPrefix(blockKeyPrefix), nil)
+	defer iter.Release()
+	for iter.Next() {
+		var block types.Block
+		if err = rlp.DecodeBytes(iter.Value(), &block); err != nil {
+			return err
+		}
+		batch.Put(lvl.getBlockHeightKey(block.Position.Height), block.Hash[:])
+	}
+	if err = iter.Error(); err != nil {
+		return err
+	}
+	batch.Put(blockHeightIndexedKey, []byte{})
+	return lvl.db.Write(batch, nil)
+}
+
 // Close implement Closer interface, which would release allocated resource.
 func (lvl *LevelDBBackedDB) Close() error {
 	return lvl.db.Close()
@@ -398,16 +432,25 @@ func (lvl *LevelDBBackedDB) UpdateBlock(block types.Block) (err error) {
 	if err != nil {
 		return
 	}
-	blockKey := lvl.getBlockKey(block.Hash)
-	exists, err := lvl.internalHasBlock(blockKey)
+	old, err := lvl.GetBlock(block.Hash)
 	if err != nil {
 		return
 	}
-	if !exists {
-		err = ErrBlockDoesNotExist
-		return
+	batch := new(leveldb.Batch)
+	batch.Put(lvl.getBlockKey(block.Hash), marshaled)
+	if old.Position.Height != block.Position.Height {
+		oldKey := lvl.getBlockHeightKey(old.Position.Height)
+		indexed, err := lvl.db.Get(oldKey, nil)
+		if err != nil && err != leveldb.ErrNotFound {
+			return err
+		}
+		if err == nil && bytesToHash(indexed) == block.Hash {
+			batch.Delete(oldKey)
+		}
+		batch.Put(
+			lvl.getBlockHeightKey(block.Position.Height), block.Hash[:])
 	}
-	err = lvl.db.Put(blockKey, marshaled, nil)
+	err = lvl.db.Write(batch, nil)
 	return
 }
 
@@ -426,10 +469,74 @@ func (lvl *LevelDBBackedDB) PutBlock(block types.Block) (err error) {
 		err = ErrBlockExists
 		return
 	}
-	err = lvl.db.Put(blockKey, marshaled, nil)
+	batch := new(leveldb.Batch)
+	batch.Put(blockKey, marshaled)
+	batch.Put(lvl.getBlockHeightKey(block.Position.Height), block.Hash[:])
+	err = lvl.db.Write(batch, nil)
+	return
+}
+
+// GetBlockByHeight implements the Reader.GetBlockByHeight method.
+func (lvl *LevelDBBackedDB) GetBlockByHeight(
+	height uint64) (block types.Block, err error) {
+	queried, err := lvl.db.Get(lvl.getBlockHeightKey(height), nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			err = ErrBlockDoesNotExist
+		}
+		return
+	}
+	return lvl.GetBlock(bytesToHash(queried))
+}
+
+// GetBlocksInRange implements the Reader.GetBlocksInRange method.
+func (lvl *LevelDBBackedDB) GetBlocksInRange(from, to uint64) (
+	blocks []types.Block, err error) {
+	if from >= to {
+		return
+	}
+	iter := lvl.db.NewIterator(&util.Range{
+		Start: lvl.getBlockHeightKey(from),
+		Limit: lvl.getBlockHeightKey(to),
+	}, nil)
+	defer iter.Release()
+	for iter.Next() {
+		var block types.Block
+		if block, err = lvl.GetBlock(
+			bytesToHash(iter.Value())); err != nil {
+			return
+		}
+		blocks = append(blocks, block)
+	}
+	err = iter.Error()
 	return
 }
 
+// GetBlocksFromHeight implements the Reader.GetBlocksFromHeight method.
+func (lvl *LevelDBBackedDB) GetBlocksFromHeight(from uint64) (
+	BlockIterator, error) {
+	return &blockHeightIterator{
+		nextHeight: from,
+		getFrom:    lvl.getBlockFromHeight,
+	}, nil
+}
+
+func (lvl *LevelDBBackedDB) getBlockFromHeight(
+	height uint64) (block types.Block, err error) {
+	iter := lvl.db.NewIterator(&util.Range{
+		Start: lvl.getBlockHeightKey(height),
+		Limit: util.BytesPrefix(blockHeightKeyPrefix).Limit,
+	}, nil)
+	defer iter.Release()
+	if !iter.First() {
+		if err = iter.Error(); err == nil {
+			err = ErrIterationFinished
+		}
+		return
+	}
+	return lvl.GetBlock(bytesToHash(iter.Value()))
+}
+
 // GetAllBlocks implements Reader.GetAllBlocks method, which allows callers
 // to retrieve all blocks in DB.
 func (lvl *LevelDBBackedDB) GetAllBlocks() (BlockIterator, error) {
@@ -550,6 +657,190 @@ func (lvl *LevelDBBackedDB) PutOrUpdateDKGProtocol(info DKGProtocolInfo) error {
 	return lvl.db.Put(lvl.getDKGProtocolInfoKey(), marshaled, nil)
 }
 
+// GetAgreementState get the latest agreement state.
+func (lvl *LevelDBBackedDB) GetAgreementState() (
+	state AgreementState, err error) {
+	queried, err := lvl.db.Get(agreementStateKey, nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			err = ErrAgreementStateDoesNotExist
+		}
+		return
+	}
+	err = rlp.DecodeBytes(queried, &state)
+	return
+}
+
+// PutOrUpdateAgreementState save the agreement state, the write is synced
+// to disk before returning.
+func (lvl *LevelDBBackedDB) PutOrUpdateAgreementState(
+	state AgreementState) error {
+	marshaled, err := rlp.EncodeToBytes(&state)
+	if err != nil {
+		return err
+	}
+	return lvl.db.Put(agreementStateKey, marshaled, &opt.WriteOptions{
+		Sync: true,
+	})
+}
+
+// NewBatch implements the Writer.NewBatch method.
+func (lvl *LevelDBBackedDB) NewBatch() Batch {
+	return newBatch(lvl.commitBatch)
+}
+
+func (lvl *LevelDBBackedDB) commitBatch(ops []batchOp) error {
+	var (
+		checker = newBatchChecker(lvl)
+		batch   = new(leveldb.Batch)
+		// heights keeps the height index changed by operations in this
+		// batch.
+		heights = make(map[uint64]common.Hash)
+	)
+	for i := range ops {
+		op := &ops[i]
+		oldHeight, err := checker.check(op)
+		if err != nil {
+			return err
+		}
+		switch op.opType {
+		case batchOpPutBlock, batchOpUpdateBlock:
+			marshaled, err := rlp.EncodeToBytes(&op.block)
+			if err != nil {
+				return err
+			}
+			batch.Put(lvl.getBlockKey(op.block.Hash), marshaled)
+			height := op.block.Position.Height
+			if op.opType == batchOpUpdateBlock {
+				if oldHeight == height {
+					break
+				}
+				indexed, exists := heights[oldHeight]
+				if !exists {
+					queried, err := lvl.db.Get(
+						lvl.getBlockHeightKey(oldHeight), nil)
+					if err != nil && err != leveldb.ErrNotFound {
+						return err
+					}
+					indexed = bytesToHash(queried)
+				}
+				if indexed == op.block.Hash {
+					batch.Delete(lvl.getBlockHeightKey(oldHeight))
+					heights[oldHeight] = common.Hash{}
+				}
+			}
+			batch.Put(lvl.getBlockHeightKey(height), op.block.Hash[:])
+			heights[height] = op.block.Hash
+		case batchOpPutCompactionChainTipInfo:
+			marshaled, err := rlp.EncodeToBytes(&compactionChainTipInfo{
+				Hash:   op.hash,
+				Height: op.height,
+			})
+			if err != nil {
+				return err
+			}
+			batch.Put(compactionChainTipInfoKey, marshaled)
+		case batchOpPutDKGPrivateKey:
+			marshaled, err := rlp.EncodeToBytes(&dkgPrivateKey{
+				PK:    op.prvKey,
+				Reset: op.reset,
+			})
+			if err != nil {
+				return err
+			}
+			batch.Put(lvl.getDKGPrivateKeyKey(op.round), marshaled)
+		case batchOpPutOrUpdateDKGProtocol:
+			marshaled, err := rlp.EncodeToBytes(&op.dkgProtocol)
+			if err != nil {
+				return err
+			}
+			batch.Put(lvl.getDKGProtocolInfoKey(), marshaled)
+		}
+	}
+	return lvl.db.Write(batch, nil)
+}
+
+func (lvl *LevelDBBackedDB) getBlockHeight(hash common.Hash) (
+	height uint64, exists bool, err error) {
+	block, err := lvl.GetBlock(hash)
+	if err != nil {
+		if err == ErrBlockDoesNotExist {
+			err = nil
+		}
+		return
+	}
+	return block.Position.Height, true, nil
+}
+
+func (lvl *LevelDBBackedDB) getCompactionChainTipInfo() (
+	compactionChainTipInfo, error) {
+	return lvl.internalGetCompactionChainTipInfo()
+}
+
+func (lvl *LevelDBBackedDB) getDKGPrivateKeyReset(round uint64) (
+	reset uint64, exists bool, err error) {
+	queried, err := lvl.db.Get(lvl.getDKGPrivateKeyKey(round), nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			err = nil
+		}
+		return
+	}
+	pk := dkgPrivateKey{}
+	if err = rlp.DecodeBytes(queried, &pk); err != nil {
+		return
+	}
+	return pk.Reset, true, nil
+}
+
+// GetEvidence get the evidence record identified by the hash.
+func (lvl *LevelDBBackedDB) GetEvidence(
+	hash common.Hash) (record EvidenceRecord, err error) {
+	queried, err := lvl.db.Get(lvl.getEvidenceKey(hash), nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			err = ErrEvidenceDoesNotExist
+		}
+		return
+	}
+	err = rlp.DecodeBytes(queried, &record)
+	return
+}
+
+// GetAllEvidences get all evidence records.
+func (lvl *LevelDBBackedDB) GetAllEvidences() (
+	records []EvidenceRecord, err error) {
+	iter := lvl.db.NewIterator(util.BytesPrefix(evidenceKeyPrefix), nil)
+	defer iter.Release()
+	for iter.Next() {
+		var record EvidenceRecord
+		if err = rlp.DecodeBytes(iter.Value(), &record); err != nil {
+			return
+		}
+		records = append(records, record)
+	}
+	err = iter.Error()
+	return
+}
+
+// PutOrUpdateEvidence save the evidence record, the write is synced to disk
+// before returning.
+func (lvl *LevelDBBackedDB) PutOrUpdateEvidence(record EvidenceRecord) error {
+	marshaled, err := rlp.EncodeToBytes(&record)
+	if err != nil {
+		return err
+	}
+	return lvl.db.Put(lvl.getEvidenceKey(record.Hash), marshaled,
+		&opt.WriteOptions{Sync: true})
+}
+
+// DeleteEvidence removes the evidence record, the write is synced to disk
+// before returning.
+func (lvl *LevelDBBackedDB) DeleteEvidence(hash common.Hash) error {
+	return lvl.db.Delete(lvl.getEvidenceKey(hash),
+		&opt.WriteOptions{Sync: true})
+}
+
 func (lvl *LevelDBBackedDB) getBlockKey(hash common.Hash) (ret []byte) {
 	ret = make([]byte, len(blockKeyPrefix)+len(hash[:]))
 	copy(ret, blockKeyPrefix)
@@ -557,6 +848,27 @@ func (lvl *LevelDBBackedDB) getBlockKey(hash common.Hash) (ret []byte) {
 	return
 }
 
+// getBlockHeightKey encodes height in big-endian to keep keys in the order
+// of height.
+func (lvl *LevelDBBackedDB) getBlockHeightKey(height uint64) (ret []byte) {
+	ret = make([]byte, len(blockHeightKeyPrefix)+8)
+	copy(ret, blockHeightKeyPrefix)
+	binary.BigEndian.PutUint64(ret[len(blockHeightKeyPrefix):], height)
+	return
+}
+
+func (lvl *LevelDBBackedDB) getEvidenceKey(hash common.Hash) (ret []byte) {
+	ret = make([]byte, len(evidenceKeyPrefix)+len(hash[:]))
+	copy(ret, evidenceKeyPrefix)
+	copy(ret[len(evidenceKeyPrefix):], hash[:])
+	return
+}
+
+func bytesToHash(b []byte) (hash common.Hash) {
+	copy(hash[:], b)
+	return
+}
+
 func (lvl *LevelDBBackedDB) getDKGPrivateKeyKey(
 	round uint64) (ret []byte) {
 	ret = make([]byte, len(dkgPrivateKeyKeyPrefix)+8)
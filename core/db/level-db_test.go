@@ -18,7 +18,6 @@
 package db
 
 import (
-	"bytes"
 	"fmt"
 	"reflect"
 	"testing"
@@ -131,86 +130,33 @@ func (s *LevelDBTestSuite) TestSyncIndex() {
 	}
 }
 
-func (s *LevelDBTestSuite) TestCompactionChainTipInfo() {
-	dbName := fmt.Sprintf("test-db-%v-cc-tip.db", time.Now().UTC())
+func (s *LevelDBTestSuite) TestBuildHeightIndex() {
+	dbName := fmt.Sprintf("test-db-%v-height-index.db", time.Now().UTC())
 	dbInst, err := NewLevelDBBackedDB(dbName)
 	s.Require().NoError(err)
 	defer func(dbName string) {
-		err = dbInst.Close()
-		s.NoError(err)
 		err = os.RemoveAll(dbName)
 		s.NoError(err)
 	}(dbName)
-	// Save some tip info.
-	hash := common.NewRandomHash()
-	s.Require().NoError(dbInst.PutCompactionChainTipInfo(hash, 1))
-	// Get it back to check.
-	hashBack, height := dbInst.GetCompactionChainTipInfo()
-	s.Require().Equal(hash, hashBack)
-	s.Require().Equal(height, uint64(1))
-	// Unable to put compaction chain tip info with lower height.
-	err = dbInst.PutCompactionChainTipInfo(hash, 0)
-	s.Require().Equal(err.Error(), ErrInvalidCompactionChainTipHeight.Error())
-	// Unable to put compaction chain tip info with height not incremental by 1.
-	err = dbInst.PutCompactionChainTipInfo(hash, 3)
-	s.Require().Equal(err.Error(), ErrInvalidCompactionChainTipHeight.Error())
-	// It's OK to put compaction chain tip info with height incremental by 1.
-	s.Require().NoError(dbInst.PutCompactionChainTipInfo(hash, 2))
-}
-
-func (s *LevelDBTestSuite) TestDKGPrivateKey() {
-	dbName := fmt.Sprintf("test-db-%v-dkg-prv.db", time.Now().UTC())
-	dbInst, err := NewLevelDBBackedDB(dbName)
-	s.Require().NoError(err)
-	defer func(dbName string) {
-		err = dbInst.Close()
-		s.NoError(err)
-		err = os.RemoveAll(dbName)
-		s.NoError(err)
-	}(dbName)
-	p := dkg.NewPrivateKey()
-	// We should be unable to get it.
-	_, err = dbInst.GetDKGPrivateKey(1, 0)
-	s.Require().Equal(err.Error(), ErrDKGPrivateKeyDoesNotExist.Error())
-	// Put it.
-	s.Require().NoError(dbInst.PutDKGPrivateKey(1, 0, *p))
-	// We should be unable to get it because reset is different.
-	_, err = dbInst.GetDKGPrivateKey(1, 1)
-	s.Require().Equal(err.Error(), ErrDKGPrivateKeyDoesNotExist.Error())
-	// Put it again, should not success.
-	err = dbInst.PutDKGPrivateKey(1, 0, *p)
-	s.Require().Equal(err.Error(), ErrDKGPrivateKeyExists.Error())
-	// Get it back.
-	tmpPrv, err := dbInst.GetDKGPrivateKey(1, 0)
-	s.Require().NoError(err)
-	s.Require().Equal(bytes.Compare(p.Bytes(), tmpPrv.Bytes()), 0)
-	// Put it at different reset.
-	p2 := dkg.NewPrivateKey()
-	s.Require().NoError(dbInst.PutDKGPrivateKey(1, 1, *p2))
-	// We should be unable to get it because reset is different.
-	_, err = dbInst.GetDKGPrivateKey(1, 0)
-	// Get it back.
-	tmpPrv, err = dbInst.GetDKGPrivateKey(1, 1)
+	block := types.Block{
+		Hash:     common.NewRandomHash(),
+		Position: types.Position{Height: 10},
+	}
+	s.Require().NoError(dbInst.PutBlock(block))
+	// Simulate a database created without height index.
+	s.Require().NoError(dbInst.db.Delete(blockHeightIndexedKey, nil))
+	s.Require().NoError(dbInst.db.Delete(
+		dbInst.getBlockHeightKey(block.Position.Height), nil))
+	_, err = dbInst.GetBlockByHeight(block.Position.Height)
+	s.Require().Equal(ErrBlockDoesNotExist, err)
+	s.Require().NoError(dbInst.Close())
+	// The index should be built when opened.
+	dbInst, err = NewLevelDBBackedDB(dbName)
 	s.Require().NoError(err)
-	s.Require().Equal(bytes.Compare(p2.Bytes(), tmpPrv.Bytes()), 0)
-	s.Require().NotEqual(bytes.Compare(p2.Bytes(), p.Bytes()), 0)
-}
-
-func (s *LevelDBTestSuite) TestDKGProtocol() {
-	dbName := fmt.Sprintf("test-db-%v-dkg-master-prv-shares.db", time.Now().UTC())
-	dbInst, err := NewLevelDBBackedDB(dbName)
+	queried, err := dbInst.GetBlockByHeight(block.Position.Height)
 	s.Require().NoError(err)
-	defer func(dbName string) {
-		err = dbInst.Close()
-		s.NoError(err)
-		err = os.RemoveAll(dbName)
-		s.NoError(err)
-	}(dbName)
-
-	_, err = dbInst.GetDKGProtocol()
-	s.Require().Equal(err.Error(), ErrDKGProtocolDoesNotExist.Error())
-
-	s.Require().NoError(dbInst.PutOrUpdateDKGProtocol(DKGProtocolInfo{}))
+	s.Require().Equal(block.Hash, queried.Hash)
+	s.Require().NoError(dbInst.Close())
 }
 
 func (s *LevelDBTestSuite) TestDKGProtocolInfoRLPEncodeDecode() {
@@ -0,0 +1,912 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	"github.com/dexon-foundation/dexon/rlp"
+)
+
+// Errors for log-structured database.
+var (
+	ErrLogRecordCorrupted = errors.New("log record corrupted")
+	ErrLogRecordTooLarge  = errors.New("log record too large")
+)
+
+const (
+	logFileName        = "data.log"
+	logCompactFileName = "data.log.compact"
+	// The header of each record is 4 bytes of payload size, 4 bytes of
+	// checksum and 1 byte of record type.
+	logRecordHeaderSize = 9
+	logRecordMaxSize    = 64 * 1024 * 1024
+	// The payload of block records starts with block hash and height.
+	logBlockPrefixSize = common.HashLength + 8
+	// The log is compacted when the size of stale records exceeds this
+	// threshold and the size of live records.
+	logCompactThreshold = 16 * 1024 * 1024
+)
+
+var logCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+type logRecordType byte
+
+const (
+	logRecordBlock logRecordType = iota + 1
+	logRecordCompactionChainTip
+	logRecordDKGPrivateKey
+	logRecordDKGProtocol
+	logRecordAgreementState
+	// The payload of a batch record is encoded records written in the
+	// batch, they are indexed as individual records.
+	logRecordBatch
+	// The payload of evidence records starts with the evidence hash.
+	logRecordEvidence
+	// The payload of evidence deletion records is the evidence hash.
+	logRecordEvidenceDeleted
+)
+
+// logRecordLocation is the location of a record in the log file, including
+// its header.
+type logRecordLocation struct {
+	offset int64
+	size   int64
+}
+
+type logDKGPrivateKey struct {
+	reset uint64
+	loc   logRecordLocation
+}
+
+type logBlockSeqIterator struct {
+	idx int
+	db  *LogBackedDB
+}
+
+// NextBlock implements BlockIterator.NextBlock method.
+func (seq *logBlockSeqIterator) NextBlock() (types.Block, error) {
+	curIdx := seq.idx
+	seq.idx++
+	return seq.db.getBlockByIndex(curIdx)
+}
+
+// LogBackedDB is a database implementation backed by an append-only log
+// file. Only locations of records are kept in memory, and records are read
+// from the file on demand. Updated records are appended to the log and the
+// stale ones are dropped when the log is compacted.
+//
+// Blocks are not synced to disk until the next write of other records, ex.
+// the tip of compaction chain, which is synced before returning.
+type LogBackedDB struct {
+	lock              sync.RWMutex
+	path              string
+	file              *os.File
+	size              int64
+	staleSize         int64
+	blockHashSequence common.Hashes
+	blocks            map[common.Hash]logRecordLocation
+	blocksByHeight    *heightIndex
+	tipInfo           compactionChainTipInfo
+	tipLoc            *logRecordLocation
+	dkgPrivateKeys    map[uint64]logDKGPrivateKey
+	dkgProtocolLoc    *logRecordLocation
+	agreementStateLoc *logRecordLocation
+	evidences         map[common.Hash]logRecordLocation
+	compactErr        error
+}
+
+// NewLogBackedDB opens a log-structured database under the directory. The
+// log is replayed to rebuild the index, and the incomplete record at the end
+// of the log, which is caused by crashing during writing, is truncated.
+// An error is returned if any other record is broken.
+func NewLogBackedDB(path string) (dbInst *LogBackedDB, err error) {
+	if err = os.MkdirAll(path, 0755); err != nil {
+		return
+	}
+	// A compaction is interrupted, the original log is still intact.
+	err = os.Remove(filepath.Join(path, logCompactFileName))
+	if err != nil && !os.IsNotExist(err) {
+		return
+	}
+	file, err := os.OpenFile(
+		filepath.Join(path, logFileName), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return
+	}
+	dbInst = &LogBackedDB{path: path, file: file}
+	dbInst.resetIndex()
+	if err = dbInst.replay(); err != nil {
+		file.Close()
+		dbInst = nil
+		return
+	}
+	return
+}
+
+func (l *LogBackedDB) resetIndex() {
+	l.size = 0
+	l.staleSize = 0
+	l.blockHashSequence = common.Hashes{}
+	l.blocks = make(map[common.Hash]logRecordLocation)
+	l.blocksByHeight = newHeightIndex()
+	l.tipInfo = compactionChainTipInfo{}
+	l.tipLoc = nil
+	l.dkgPrivateKeys = make(map[uint64]logDKGPrivateKey)
+	l.dkgProtocolLoc = nil
+	l.agreementStateLoc = nil
+	l.evidences = make(map[common.Hash]logRecordLocation)
+}
+
+// replay rebuilds the index from the log file.
+func (l *LogBackedDB) replay() error {
+	if _, err := l.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	info, err := l.file.Stat()
+	if err != nil {
+		return err
+	}
+	reader := bufio.NewReader(l.file)
+	for {
+		recType, payload, size, err := readLogRecord(reader)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			switch err {
+			case io.ErrUnexpectedEOF:
+			case ErrLogRecordCorrupted, ErrLogRecordTooLarge:
+				// Only the last record could be torn by a crash when
+				// appending, records after a broken one can't be trusted.
+				if l.size+size < info.Size() {
+					return err
+				}
+			default:
+				return err
+			}
+			// Drop the torn tail of the log.
+			return l.file.Truncate(l.size)
+		}
+		loc := logRecordLocation{offset: l.size, size: size}
+		if err = l.index(recType, payload, loc); err != nil {
+			return err
+		}
+		l.size += size
+	}
+	return nil
+}
+
+// index updates the in-memory index with a record appended to the log.
+func (l *LogBackedDB) index(
+	recType logRecordType, payload []byte, loc logRecordLocation) error {
+	replace := func(old *logRecordLocation) *logRecordLocation {
+		if old != nil {
+			l.staleSize += old.size
+		}
+		return &loc
+	}
+	switch recType {
+	case logRecordBlock:
+		if len(payload) < logBlockPrefixSize {
+			return ErrLogRecordCorrupted
+		}
+		hash := bytesToHash(payload[:common.HashLength])
+		height := binary.LittleEndian.Uint64(payload[common.HashLength:])
+		if old, exists := l.blocks[hash]; exists {
+			l.staleSize += old.size
+			oldPayload, err := l.readRecord(old)
+			if err != nil {
+				return err
+			}
+			l.blocksByHeight.remove(binary.LittleEndian.Uint64(
+				oldPayload[common.HashLength:]), hash)
+		} else {
+			l.blockHashSequence = append(l.blockHashSequence, hash)
+		}
+		l.blocks[hash] = loc
+		l.blocksByHeight.put(height, hash)
+	case logRecordCompactionChainTip:
+		if err := rlp.DecodeBytes(payload, &l.tipInfo); err != nil {
+			return err
+		}
+		l.tipLoc = replace(l.tipLoc)
+	case logRecordDKGPrivateKey:
+		if len(payload) < 8 {
+			return ErrLogRecordCorrupted
+		}
+		round := binary.LittleEndian.Uint64(payload)
+		pk := dkgPrivateKey{}
+		if err := rlp.DecodeBytes(payload[8:], &pk); err != nil {
+			return err
+		}
+		if old, exists := l.dkgPrivateKeys[round]; exists {
+			l.staleSize += old.loc.size
+		}
+		l.dkgPrivateKeys[round] = logDKGPrivateKey{reset: pk.Reset, loc: loc}
+	case logRecordDKGProtocol:
+		l.dkgProtocolLoc = replace(l.dkgProtocolLoc)
+	case logRecordAgreementState:
+		l.agreementStateLoc = replace(l.agreementStateLoc)
+	case logRecordEvidence:
+		if len(payload) < common.HashLength {
+			return ErrLogRecordCorrupted
+		}
+		hash := bytesToHash(payload[:common.HashLength])
+		if old, exists := l.evidences[hash]; exists {
+			l.staleSize += old.size
+		}
+		l.evidences[hash] = loc
+	case logRecordEvidenceDeleted:
+		if len(payload) != common.HashLength {
+			return ErrLogRecordCorrupted
+		}
+		hash := bytesToHash(payload)
+		if old, exists := l.evidences[hash]; exists {
+			l.staleSize += old.size
+			delete(l.evidences, hash)
+		}
+		// The deletion record itself is useless after indexed.
+		l.staleSize += loc.size
+	case logRecordBatch:
+		// Only the header of a batch record is stale after indexed.
+		l.staleSize += logRecordHeaderSize
+		for offset := 0; offset < len(payload); {
+			if len(payload)-offset < logRecordHeaderSize {
+				return ErrLogRecordCorrupted
+			}
+			size := logRecordHeaderSize +
+				int(binary.LittleEndian.Uint32(payload[offset:]))
+			if len(payload)-offset < size {
+				return ErrLogRecordCorrupted
+			}
+			subType, subPayload, err := decodeLogRecord(
+				payload[offset : offset+size])
+			if err != nil {
+				return err
+			}
+			if subType == logRecordBatch {
+				return ErrLogRecordCorrupted
+			}
+			if err = l.index(subType, subPayload, logRecordLocation{
+				offset: loc.offset + logRecordHeaderSize + int64(offset),
+				size:   int64(size),
+			}); err != nil {
+				return err
+			}
+			offset += size
+		}
+	default:
+		return ErrLogRecordCorrupted
+	}
+	return nil
+}
+
+func encodeLogRecord(recType logRecordType, payload []byte) []byte {
+	buf := make([]byte, logRecordHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(buf, uint32(len(payload)))
+	buf[8] = byte(recType)
+	copy(buf[logRecordHeaderSize:], payload)
+	binary.LittleEndian.PutUint32(
+		buf[4:], crc32.Checksum(buf[8:], logCRCTable))
+	return buf
+}
+
+func decodeLogRecord(buf []byte) (
+	recType logRecordType, payload []byte, err error) {
+	if len(buf) < logRecordHeaderSize ||
+		int(binary.LittleEndian.Uint32(buf)) != len(buf)-logRecordHeaderSize {
+		err = ErrLogRecordCorrupted
+		return
+	}
+	if crc32.Checksum(buf[8:], logCRCTable) !=
+		binary.LittleEndian.Uint32(buf[4:]) {
+		err = ErrLogRecordCorrupted
+		return
+	}
+	recType, payload = logRecordType(buf[8]), buf[logRecordHeaderSize:]
+	return
+}
+
+func readLogRecord(r io.Reader) (
+	recType logRecordType, payload []byte, size int64, err error) {
+	header := make([]byte, logRecordHeaderSize)
+	n, err := io.ReadFull(r, header)
+	if err != nil {
+		if err == io.EOF && n == 0 {
+			return
+		}
+		err = io.ErrUnexpectedEOF
+		return
+	}
+	payloadSize := binary.LittleEndian.Uint32(header)
+	if payloadSize > logRecordMaxSize {
+		size = logRecordHeaderSize + int64(payloadSize)
+		err = ErrLogRecordTooLarge
+		return
+	}
+	buf := make([]byte, logRecordHeaderSize+int(payloadSize))
+	copy(buf, header)
+	if _, err = io.ReadFull(r, buf[logRecordHeaderSize:]); err != nil {
+		err = io.ErrUnexpectedEOF
+		return
+	}
+	recType, payload, err = decodeLogRecord(buf)
+	size = int64(len(buf))
+	return
+}
+
+// readRecord reads a record at the location, it should be called with
+// l.lock held.
+func (l *LogBackedDB) readRecord(loc logRecordLocation) ([]byte, error) {
+	buf := make([]byte, loc.size)
+	if _, err := l.file.ReadAt(buf, loc.offset); err != nil {
+		return nil, err
+	}
+	_, payload, err := decodeLogRecord(buf)
+	return payload, err
+}
+
+// appendRecord appends a record to the log and updates the index, it should
+// be called with l.lock held.
+func (l *LogBackedDB) appendRecord(
+	recType logRecordType, payload []byte, syncWrite bool) (err error) {
+	if len(payload) > logRecordMaxSize {
+		return ErrLogRecordTooLarge
+	}
+	buf := encodeLogRecord(recType, payload)
+	if _, err = l.file.WriteAt(buf, l.size); err != nil {
+		// Drop the partially written record.
+		l.file.Truncate(l.size)
+		return
+	}
+	if syncWrite {
+		if err = l.file.Sync(); err != nil {
+			return
+		}
+	}
+	loc := logRecordLocation{offset: l.size, size: int64(len(buf))}
+	if err = l.index(recType, payload, loc); err != nil {
+		return
+	}
+	l.size += loc.size
+	// The record is stored, a failed compaction doesn't fail this write and
+	// is kept for CompactError.
+	if l.compactErr == nil && l.staleSize > logCompactThreshold &&
+		l.staleSize > l.size-l.staleSize {
+		l.compactErr = l.compact()
+	}
+	return
+}
+
+// Compact rewrites the log with live records only.
+func (l *LogBackedDB) Compact() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.compactErr = l.compact()
+	return l.compactErr
+}
+
+// CompactError returns the error of the last compaction. Automatic
+// compaction is suspended after a failure until Compact succeeds.
+func (l *LogBackedDB) CompactError() error {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.compactErr
+}
+
+func (l *LogBackedDB) compact() (err error) {
+	compactPath := filepath.Join(l.path, logCompactFileName)
+	file, err := os.OpenFile(
+		compactPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil && file != nil {
+			file.Close()
+			os.Remove(compactPath)
+		}
+	}()
+	// Copy live records, blocks are kept in the order of insertion.
+	locs := []logRecordLocation{}
+	if l.tipLoc != nil {
+		locs = append(locs, *l.tipLoc)
+	}
+	for _, pk := range l.dkgPrivateKeys {
+		locs = append(locs, pk.loc)
+	}
+	if l.dkgProtocolLoc != nil {
+		locs = append(locs, *l.dkgProtocolLoc)
+	}
+	if l.agreementStateLoc != nil {
+		locs = append(locs, *l.agreementStateLoc)
+	}
+	for _, loc := range l.evidences {
+		locs = append(locs, loc)
+	}
+	for _, hash := range l.blockHashSequence {
+		locs = append(locs, l.blocks[hash])
+	}
+	writer := bufio.NewWriter(file)
+	for _, loc := range locs {
+		buf := make([]byte, loc.size)
+		if _, err = l.file.ReadAt(buf, loc.offset); err != nil {
+			return
+		}
+		if _, err = writer.Write(buf); err != nil {
+			return
+		}
+	}
+	if err = writer.Flush(); err != nil {
+		return
+	}
+	if err = file.Sync(); err != nil {
+		return
+	}
+	logPath := filepath.Join(l.path, logFileName)
+	if err = os.Rename(compactPath, logPath); err != nil {
+		return
+	}
+	// Switch to the compacted log before anything else could fail, or later
+	// writes would go to the unlinked one.
+	l.file.Close()
+	l.file, file = file, nil
+	l.resetIndex()
+	if err = l.replay(); err != nil {
+		return
+	}
+	return syncDir(l.path)
+}
+
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+// Close implement Closer interface, which would release allocated resource.
+func (l *LogBackedDB) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if err := l.file.Sync(); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
+
+// HasBlock implements the Reader.Has method.
+func (l *LogBackedDB) HasBlock(hash common.Hash) bool {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	_, exists := l.blocks[hash]
+	return exists
+}
+
+// GetBlock implements the Reader.GetBlock method.
+func (l *LogBackedDB) GetBlock(hash common.Hash) (types.Block, error) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.internalGetBlock(hash)
+}
+
+func (l *LogBackedDB) internalGetBlock(
+	hash common.Hash) (block types.Block, err error) {
+	loc, exists := l.blocks[hash]
+	if !exists {
+		err = ErrBlockDoesNotExist
+		return
+	}
+	payload, err := l.readRecord(loc)
+	if err != nil {
+		return
+	}
+	err = rlp.DecodeBytes(payload[logBlockPrefixSize:], &block)
+	return
+}
+
+// UpdateBlock implements the Writer.UpdateBlock method.
+func (l *LogBackedDB) UpdateBlock(block types.Block) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if _, exists := l.blocks[block.Hash]; !exists {
+		return ErrBlockDoesNotExist
+	}
+	return l.putBlock(block)
+}
+
+// PutBlock implements the Writer.PutBlock method.
+func (l *LogBackedDB) PutBlock(block types.Block) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if _, exists := l.blocks[block.Hash]; exists {
+		return ErrBlockExists
+	}
+	return l.putBlock(block)
+}
+
+func (l *LogBackedDB) putBlock(block types.Block) error {
+	payload, err := encodeLogBlock(&block)
+	if err != nil {
+		return err
+	}
+	return l.appendRecord(logRecordBlock, payload, false)
+}
+
+func encodeLogBlock(block *types.Block) ([]byte, error) {
+	marshaled, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		return nil, err
+	}
+	payload := make([]byte, logBlockPrefixSize+len(marshaled))
+	copy(payload, block.Hash[:])
+	binary.LittleEndian.PutUint64(
+		payload[common.HashLength:], block.Position.Height)
+	copy(payload[logBlockPrefixSize:], marshaled)
+	return payload, nil
+}
+
+// GetBlockByHeight implements the Reader.GetBlockByHeight method.
+func (l *LogBackedDB) GetBlockByHeight(height uint64) (types.Block, error) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	hash, exists := l.blocksByHeight.get(height)
+	if !exists {
+		return types.Block{}, ErrBlockDoesNotExist
+	}
+	return l.internalGetBlock(hash)
+}
+
+// GetBlocksInRange implements the Reader.GetBlocksInRange method.
+func (l *LogBackedDB) GetBlocksInRange(from, to uint64) (
+	blocks []types.Block, err error) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	for _, hash := range l.blocksByHeight.getRange(from, to) {
+		var block types.Block
+		if block, err = l.internalGetBlock(hash); err != nil {
+			return
+		}
+		blocks = append(blocks, block)
+	}
+	return
+}
+
+// GetBlocksFromHeight implements the Reader.GetBlocksFromHeight method.
+func (l *LogBackedDB) GetBlocksFromHeight(from uint64) (
+	BlockIterator, error) {
+	return &blockHeightIterator{
+		nextHeight: from,
+		getFrom:    l.getBlockFromHeight,
+	}, nil
+}
+
+func (l *LogBackedDB) getBlockFromHeight(height uint64) (types.Block, error) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	hash, exists := l.blocksByHeight.getFrom(height)
+	if !exists {
+		return types.Block{}, ErrIterationFinished
+	}
+	return l.internalGetBlock(hash)
+}
+
+func (l *LogBackedDB) getBlockByIndex(idx int) (types.Block, error) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	if idx >= len(l.blockHashSequence) {
+		return types.Block{}, ErrIterationFinished
+	}
+	return l.internalGetBlock(l.blockHashSequence[idx])
+}
+
+// GetAllBlocks implements Reader.GetAllBlocks method, which allows callers
+// to retrieve all blocks in DB in the order of insertion.
+func (l *LogBackedDB) GetAllBlocks() (BlockIterator, error) {
+	return &logBlockSeqIterator{db: l}, nil
+}
+
+// PutCompactionChainTipInfo saves tip of compaction chain into the database.
+func (l *LogBackedDB) PutCompactionChainTipInfo(
+	blockHash common.Hash, height uint64) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.tipInfo.Height+1 != height {
+		return ErrInvalidCompactionChainTipHeight
+	}
+	marshaled, err := rlp.EncodeToBytes(&compactionChainTipInfo{
+		Hash:   blockHash,
+		Height: height,
+	})
+	if err != nil {
+		return err
+	}
+	return l.appendRecord(logRecordCompactionChainTip, marshaled, true)
+}
+
+// GetCompactionChainTipInfo get the tip info of compaction chain into the
+// database.
+func (l *LogBackedDB) GetCompactionChainTipInfo() (
+	hash common.Hash, height uint64) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.tipInfo.Hash, l.tipInfo.Height
+}
+
+// GetDKGPrivateKey get DKG private key of one round.
+func (l *LogBackedDB) GetDKGPrivateKey(round, reset uint64) (
+	prv dkg.PrivateKey, err error) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	entry, exists := l.dkgPrivateKeys[round]
+	if !exists || entry.reset != reset {
+		err = ErrDKGPrivateKeyDoesNotExist
+		return
+	}
+	payload, err := l.readRecord(entry.loc)
+	if err != nil {
+		return
+	}
+	pk := dkgPrivateKey{}
+	if err = rlp.DecodeBytes(payload[8:], &pk); err != nil {
+		return
+	}
+	prv = pk.PK
+	return
+}
+
+// PutDKGPrivateKey save DKG private key of one round.
+func (l *LogBackedDB) PutDKGPrivateKey(
+	round, reset uint64, prv dkg.PrivateKey) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if entry, exists := l.dkgPrivateKeys[round]; exists &&
+		entry.reset == reset {
+		return ErrDKGPrivateKeyExists
+	}
+	payload, err := encodeLogDKGPrivateKey(round, reset, prv)
+	if err != nil {
+		return err
+	}
+	return l.appendRecord(logRecordDKGPrivateKey, payload, true)
+}
+
+func encodeLogDKGPrivateKey(
+	round, reset uint64, prv dkg.PrivateKey) ([]byte, error) {
+	marshaled, err := rlp.EncodeToBytes(&dkgPrivateKey{
+		PK:    prv,
+		Reset: reset,
+	})
+	if err != nil {
+		return nil, err
+	}
+	payload := make([]byte, 8+len(marshaled))
+	binary.LittleEndian.PutUint64(payload, round)
+	copy(payload[8:], marshaled)
+	return payload, nil
+}
+
+// GetDKGProtocol get DKG protocol.
+func (l *LogBackedDB) GetDKGProtocol() (info DKGProtocolInfo, err error) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	if l.dkgProtocolLoc == nil {
+		err = ErrDKGProtocolDoesNotExist
+		return
+	}
+	payload, err := l.readRecord(*l.dkgProtocolLoc)
+	if err != nil {
+		return
+	}
+	err = rlp.DecodeBytes(payload, &info)
+	return
+}
+
+// PutOrUpdateDKGProtocol save DKG protocol.
+func (l *LogBackedDB) PutOrUpdateDKGProtocol(info DKGProtocolInfo) error {
+	marshaled, err := rlp.EncodeToBytes(&info)
+	if err != nil {
+		return err
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.appendRecord(logRecordDKGProtocol, marshaled, true)
+}
+
+// GetAgreementState get the latest agreement state.
+func (l *LogBackedDB) GetAgreementState() (
+	state AgreementState, err error) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	if l.agreementStateLoc == nil {
+		err = ErrAgreementStateDoesNotExist
+		return
+	}
+	payload, err := l.readRecord(*l.agreementStateLoc)
+	if err != nil {
+		return
+	}
+	err = rlp.DecodeBytes(payload, &state)
+	return
+}
+
+// PutOrUpdateAgreementState save the agreement state, the write is synced
+// to disk before returning.
+func (l *LogBackedDB) PutOrUpdateAgreementState(state AgreementState) error {
+	marshaled, err := rlp.EncodeToBytes(&state)
+	if err != nil {
+		return err
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.appendRecord(logRecordAgreementState, marshaled, true)
+}
+
+// GetEvidence get the evidence record identified by the hash.
+func (l *LogBackedDB) GetEvidence(hash common.Hash) (EvidenceRecord, error) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	loc, exists := l.evidences[hash]
+	if !exists {
+		return EvidenceRecord{}, ErrEvidenceDoesNotExist
+	}
+	return l.readEvidence(loc)
+}
+
+// GetAllEvidences get all evidence records.
+func (l *LogBackedDB) GetAllEvidences() ([]EvidenceRecord, error) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	records := make([]EvidenceRecord, 0, len(l.evidences))
+	for _, loc := range l.evidences {
+		record, err := l.readEvidence(loc)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (l *LogBackedDB) readEvidence(
+	loc logRecordLocation) (record EvidenceRecord, err error) {
+	payload, err := l.readRecord(loc)
+	if err != nil {
+		return
+	}
+	err = rlp.DecodeBytes(payload[common.HashLength:], &record)
+	return
+}
+
+// PutOrUpdateEvidence save the evidence record, the write is synced to disk
+// before returning.
+func (l *LogBackedDB) PutOrUpdateEvidence(record EvidenceRecord) error {
+	marshaled, err := rlp.EncodeToBytes(&record)
+	if err != nil {
+		return err
+	}
+	payload := make([]byte, common.HashLength+len(marshaled))
+	copy(payload, record.Hash[:])
+	copy(payload[common.HashLength:], marshaled)
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.appendRecord(logRecordEvidence, payload, true)
+}
+
+// DeleteEvidence removes the evidence record, the write is synced to disk
+// before returning.
+func (l *LogBackedDB) DeleteEvidence(hash common.Hash) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if _, exists := l.evidences[hash]; !exists {
+		return nil
+	}
+	return l.appendRecord(logRecordEvidenceDeleted, hash[:], true)
+}
+
+// NewBatch implements the Writer.NewBatch method.
+func (l *LogBackedDB) NewBatch() Batch {
+	return newBatch(l.commitBatch)
+}
+
+func (l *LogBackedDB) commitBatch(ops []batchOp) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	var (
+		checker   = newBatchChecker(l)
+		payload   []byte
+		syncWrite bool
+	)
+	for i := range ops {
+		op := &ops[i]
+		if _, err := checker.check(op); err != nil {
+			return err
+		}
+		var (
+			recType logRecordType
+			encoded []byte
+			err     error
+		)
+		switch op.opType {
+		case batchOpPutBlock, batchOpUpdateBlock:
+			recType = logRecordBlock
+			encoded, err = encodeLogBlock(&op.block)
+		case batchOpPutCompactionChainTipInfo:
+			recType = logRecordCompactionChainTip
+			encoded, err = rlp.EncodeToBytes(&compactionChainTipInfo{
+				Hash:   op.hash,
+				Height: op.height,
+			})
+		case batchOpPutDKGPrivateKey:
+			recType = logRecordDKGPrivateKey
+			encoded, err = encodeLogDKGPrivateKey(op.round, op.reset, op.prvKey)
+		case batchOpPutOrUpdateDKGProtocol:
+			recType = logRecordDKGProtocol
+			encoded, err = rlp.EncodeToBytes(&op.dkgProtocol)
+		}
+		if err != nil {
+			return err
+		}
+		syncWrite = syncWrite || recType != logRecordBlock
+		payload = append(payload, encodeLogRecord(recType, encoded)...)
+	}
+	if len(payload) == 0 {
+		return nil
+	}
+	return l.appendRecord(logRecordBatch, payload, syncWrite)
+}
+
+func (l *LogBackedDB) getBlockHeight(hash common.Hash) (
+	height uint64, exists bool, err error) {
+	loc, exists := l.blocks[hash]
+	if !exists {
+		return
+	}
+	payload, err := l.readRecord(loc)
+	if err != nil {
+		return
+	}
+	height = binary.LittleEndian.Uint64(payload[common.HashLength:])
+	return
+}
+
+func (l *LogBackedDB) getCompactionChainTipInfo() (
+	compactionChainTipInfo, error) {
+	return l.tipInfo, nil
+}
+
+func (l *LogBackedDB) getDKGPrivateKeyReset(round uint64) (
+	reset uint64, exists bool, err error) {
+	entry, exists := l.dkgPrivateKeys[round]
+	reset = entry.reset
+	return
+}
@@ -0,0 +1,244 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type LogDBTestSuite struct {
+	suite.Suite
+
+	dir string
+}
+
+func (s *LogDBTestSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "test-log-db")
+	s.Require().NoError(err)
+	s.dir = dir
+}
+
+func (s *LogDBTestSuite) TearDownTest() {
+	s.Require().NoError(os.RemoveAll(s.dir))
+}
+
+func (s *LogDBTestSuite) newBlock(height uint64) types.Block {
+	return types.Block{
+		Hash:      common.NewRandomHash(),
+		Position:  types.Position{Height: height},
+		Payload:   []byte(fmt.Sprintf("payload-%d", height)),
+		Timestamp: time.Now().UTC(),
+	}
+}
+
+func (s *LogDBTestSuite) TestIteration() {
+	dbInst, err := NewLogBackedDB(s.dir)
+	s.Require().NoError(err)
+	defer dbInst.Close()
+	blocks := []types.Block{}
+	for i := uint64(1); i <= 3; i++ {
+		b := s.newBlock(i)
+		s.Require().NoError(dbInst.PutBlock(b))
+		blocks = append(blocks, b)
+	}
+	// Updating a block should not change the order.
+	s.Require().NoError(dbInst.UpdateBlock(blocks[0]))
+	iter, err := dbInst.GetAllBlocks()
+	s.Require().NoError(err)
+	for _, b := range blocks {
+		queried, err := iter.NextBlock()
+		s.Require().NoError(err)
+		s.Equal(b.Hash, queried.Hash)
+	}
+	_, err = iter.NextBlock()
+	s.Equal(ErrIterationFinished, err)
+}
+
+func (s *LogDBTestSuite) TestRecoverFromBrokenTail() {
+	dbInst, err := NewLogBackedDB(s.dir)
+	s.Require().NoError(err)
+	block1 := s.newBlock(1)
+	block2 := s.newBlock(2)
+	s.Require().NoError(dbInst.PutBlock(block1))
+	s.Require().NoError(dbInst.PutBlock(block2))
+	s.Require().NoError(dbInst.Close())
+	// Simulate a crash during writing the last record.
+	logPath := filepath.Join(s.dir, logFileName)
+	info, err := os.Stat(logPath)
+	s.Require().NoError(err)
+	s.Require().NoError(os.Truncate(logPath, info.Size()-3))
+	dbInst, err = NewLogBackedDB(s.dir)
+	s.Require().NoError(err)
+	s.True(dbInst.HasBlock(block1.Hash))
+	s.False(dbInst.HasBlock(block2.Hash))
+	// The log should be writable after recovered.
+	s.Require().NoError(dbInst.PutBlock(block2))
+	s.Require().NoError(dbInst.Close())
+	// Simulate a corrupted record at the end of the log.
+	buf, err := ioutil.ReadFile(logPath)
+	s.Require().NoError(err)
+	buf[len(buf)-1] ^= 0xff
+	s.Require().NoError(ioutil.WriteFile(logPath, buf, 0644))
+	dbInst, err = NewLogBackedDB(s.dir)
+	s.Require().NoError(err)
+	defer dbInst.Close()
+	s.True(dbInst.HasBlock(block1.Hash))
+	s.False(dbInst.HasBlock(block2.Hash))
+}
+
+func (s *LogDBTestSuite) TestCorruptedRecordInMiddle() {
+	dbInst, err := NewLogBackedDB(s.dir)
+	s.Require().NoError(err)
+	s.Require().NoError(dbInst.PutBlock(s.newBlock(1)))
+	s.Require().NoError(dbInst.PutBlock(s.newBlock(2)))
+	s.Require().NoError(dbInst.Close())
+	// Corrupt the payload of the first record.
+	logPath := filepath.Join(s.dir, logFileName)
+	buf, err := ioutil.ReadFile(logPath)
+	s.Require().NoError(err)
+	buf[logRecordHeaderSize] ^= 0xff
+	s.Require().NoError(ioutil.WriteFile(logPath, buf, 0644))
+	_, err = NewLogBackedDB(s.dir)
+	s.Require().Equal(ErrLogRecordCorrupted, err)
+	// Records after the corrupted one should not be dropped.
+	info, err := os.Stat(logPath)
+	s.Require().NoError(err)
+	s.Require().Equal(int64(len(buf)), info.Size())
+}
+
+func (s *LogDBTestSuite) TestCompact() {
+	dbInst, err := NewLogBackedDB(s.dir)
+	s.Require().NoError(err)
+	blocks := []types.Block{}
+	for i := uint64(1); i <= 3; i++ {
+		b := s.newBlock(i)
+		s.Require().NoError(dbInst.PutBlock(b))
+		blocks = append(blocks, b)
+	}
+	for i := 0; i < 10; i++ {
+		s.Require().NoError(dbInst.UpdateBlock(blocks[1]))
+		s.Require().NoError(dbInst.PutOrUpdateAgreementState(
+			AgreementState{Period: uint64(i)}))
+	}
+	s.Require().NoError(dbInst.PutCompactionChainTipInfo(blocks[0].Hash, 1))
+	record := EvidenceRecord{Hash: common.NewRandomHash()}
+	s.Require().NoError(dbInst.PutOrUpdateEvidence(record))
+	record.Submitted = true
+	s.Require().NoError(dbInst.PutOrUpdateEvidence(record))
+	deleted := EvidenceRecord{Hash: common.NewRandomHash()}
+	s.Require().NoError(dbInst.PutOrUpdateEvidence(deleted))
+	s.Require().NoError(dbInst.DeleteEvidence(deleted.Hash))
+	logPath := filepath.Join(s.dir, logFileName)
+	before, err := os.Stat(logPath)
+	s.Require().NoError(err)
+	s.Require().NoError(dbInst.Compact())
+	after, err := os.Stat(logPath)
+	s.Require().NoError(err)
+	s.True(after.Size() < before.Size())
+	// Check the content after compaction and reopening.
+	for i := 0; i < 2; i++ {
+		iter, err := dbInst.GetAllBlocks()
+		s.Require().NoError(err)
+		for _, b := range blocks {
+			queried, err := iter.NextBlock()
+			s.Require().NoError(err)
+			s.Equal(b.Hash, queried.Hash)
+		}
+		state, err := dbInst.GetAgreementState()
+		s.Require().NoError(err)
+		s.Equal(uint64(9), state.Period)
+		hash, height := dbInst.GetCompactionChainTipInfo()
+		s.Equal(blocks[0].Hash, hash)
+		s.Equal(uint64(1), height)
+		queried, err := dbInst.GetEvidence(record.Hash)
+		s.Require().NoError(err)
+		s.True(queried.Submitted)
+		_, err = dbInst.GetEvidence(deleted.Hash)
+		s.Equal(ErrEvidenceDoesNotExist, err)
+		s.Require().NoError(dbInst.Close())
+		dbInst, err = NewLogBackedDB(s.dir)
+		s.Require().NoError(err)
+	}
+	s.Require().NoError(dbInst.Close())
+}
+
+func (s *LogDBTestSuite) TestCompactBatch() {
+	dbInst, err := NewLogBackedDB(s.dir)
+	s.Require().NoError(err)
+	block1, block2 := s.newBlock(1), s.newBlock(2)
+	batch := dbInst.NewBatch()
+	batch.PutBlock(block1)
+	batch.PutBlock(block2)
+	batch.PutCompactionChainTipInfo(block1.Hash, 1)
+	s.Require().NoError(batch.Commit())
+	// Make one record in the batch stale.
+	s.Require().NoError(dbInst.UpdateBlock(block1))
+	s.Require().NoError(dbInst.Compact())
+	// Records in the batch are compacted individually.
+	for i := 0; i < 2; i++ {
+		for _, b := range []types.Block{block1, block2} {
+			queried, err := dbInst.GetBlockByHeight(b.Position.Height)
+			s.Require().NoError(err)
+			s.Equal(b.Hash, queried.Hash)
+		}
+		hash, height := dbInst.GetCompactionChainTipInfo()
+		s.Equal(block1.Hash, hash)
+		s.Equal(uint64(1), height)
+		s.Require().NoError(dbInst.Close())
+		dbInst, err = NewLogBackedDB(s.dir)
+		s.Require().NoError(err)
+	}
+	s.Require().NoError(dbInst.Close())
+}
+
+func (s *LogDBTestSuite) TestCompactFail() {
+	dbInst, err := NewLogBackedDB(s.dir)
+	s.Require().NoError(err)
+	defer dbInst.Close()
+	block1, block2 := s.newBlock(1), s.newBlock(2)
+	s.Require().NoError(dbInst.PutBlock(block1))
+	// Make compaction fail by occupying the path of the compacted log.
+	compactPath := filepath.Join(s.dir, logCompactFileName)
+	s.Require().NoError(os.Mkdir(compactPath, 0755))
+	// Writes triggering compaction should succeed even if it fails.
+	dbInst.staleSize = logCompactThreshold + 1
+	s.Require().NoError(dbInst.PutBlock(block2))
+	s.Require().Error(dbInst.CompactError())
+	s.True(dbInst.HasBlock(block2.Hash))
+	s.Require().Error(dbInst.Compact())
+	s.Require().NoError(os.Remove(compactPath))
+	s.Require().NoError(dbInst.Compact())
+	s.Require().NoError(dbInst.CompactError())
+	s.Require().NoError(dbInst.PutBlock(s.newBlock(3)))
+	s.True(dbInst.HasBlock(block1.Hash))
+	s.True(dbInst.HasBlock(block2.Hash))
+}
+
+func TestLogDB(t *testing.T) {
+	suite.Run(t, new(LogDBTestSuite))
+}
@@ -45,6 +45,7 @@ type MemBackedDB struct {
 	blocksLock               sync.RWMutex
 	blockHashSequence        common.Hashes
 	blocksByHash             map[common.Hash]*types.Block
+	blocksByHeight           *heightIndex
 	compactionChainTipLock   sync.RWMutex
 	compactionChainTipHash   common.Hash
 	compactionChainTipHeight uint64
@@ -52,6 +53,10 @@ type MemBackedDB struct {
 	dkgPrivateKeys           map[uint64]*dkgPrivateKey
 	dkgProtocolLock          sync.RWMutex
 	dkgProtocolInfo          *DKGProtocolInfo
+	agreementStateLock       sync.RWMutex
+	agreementState           *AgreementState
+	evidencesLock            sync.RWMutex
+	evidences                map[common.Hash]*EvidenceRecord
 	persistantFilePath       string
 }
 
@@ -61,7 +66,9 @@ func NewMemBackedDB(persistantFilePath ...string) (
 	dbInst = &MemBackedDB{
 		blockHashSequence: common.Hashes{},
 		blocksByHash:      make(map[common.Hash]*types.Block),
+		blocksByHeight:    newHeightIndex(),
 		dkgPrivateKeys:    make(map[uint64]*dkgPrivateKey),
+		evidences:         make(map[common.Hash]*EvidenceRecord),
 	}
 	if len(persistantFilePath) == 0 || len(persistantFilePath[0]) == 0 {
 		return
@@ -91,6 +98,9 @@ func NewMemBackedDB(persistantFilePath ...string) (
 	}
 	dbInst.blockHashSequence = toLoad.Sequence
 	dbInst.blocksByHash = toLoad.ByHash
+	for _, b := range dbInst.blocksByHash {
+		dbInst.blocksByHeight.put(b.Position.Height, b.Hash)
+	}
 	return
 }
 
@@ -130,6 +140,7 @@ func (m *MemBackedDB) PutBlock(block types.Block) error {
 
 	m.blockHashSequence = append(m.blockHashSequence, block.Hash)
 	m.blocksByHash[block.Hash] = &block
+	m.blocksByHeight.put(block.Position.Height, block.Hash)
 	return nil
 }
 
@@ -142,10 +153,57 @@ func (m *MemBackedDB) UpdateBlock(block types.Block) error {
 	m.blocksLock.Lock()
 	defer m.blocksLock.Unlock()
 
+	old := m.blocksByHash[block.Hash]
+	m.blocksByHeight.remove(old.Position.Height, old.Hash)
 	m.blocksByHash[block.Hash] = &block
+	m.blocksByHeight.put(block.Position.Height, block.Hash)
 	return nil
 }
 
+// GetBlockByHeight returns the block at the height.
+func (m *MemBackedDB) GetBlockByHeight(height uint64) (types.Block, error) {
+	m.blocksLock.RLock()
+	defer m.blocksLock.RUnlock()
+
+	hash, exists := m.blocksByHeight.get(height)
+	if !exists {
+		return types.Block{}, ErrBlockDoesNotExist
+	}
+	return m.internalGetBlock(hash)
+}
+
+// GetBlocksInRange returns blocks with heights in [from, to).
+func (m *MemBackedDB) GetBlocksInRange(from, to uint64) (
+	blocks []types.Block, err error) {
+	m.blocksLock.RLock()
+	defer m.blocksLock.RUnlock()
+
+	for _, hash := range m.blocksByHeight.getRange(from, to) {
+		blocks = append(blocks, *m.blocksByHash[hash])
+	}
+	return
+}
+
+// GetBlocksFromHeight returns an iterator on blocks in the order of height.
+func (m *MemBackedDB) GetBlocksFromHeight(from uint64) (
+	BlockIterator, error) {
+	return &blockHeightIterator{
+		nextHeight: from,
+		getFrom:    m.getBlockFromHeight,
+	}, nil
+}
+
+func (m *MemBackedDB) getBlockFromHeight(height uint64) (types.Block, error) {
+	m.blocksLock.RLock()
+	defer m.blocksLock.RUnlock()
+
+	hash, exists := m.blocksByHeight.getFrom(height)
+	if !exists {
+		return types.Block{}, ErrIterationFinished
+	}
+	return m.internalGetBlock(hash)
+}
+
 // PutCompactionChainTipInfo saves tip of compaction chain into the database.
 func (m *MemBackedDB) PutCompactionChainTipInfo(
 	blockHash common.Hash, height uint64) error {
@@ -214,6 +272,139 @@ func (m *MemBackedDB) PutOrUpdateDKGProtocol(dkgProtocol DKGProtocolInfo) error
 	return nil
 }
 
+// GetAgreementState get the latest agreement state.
+func (m *MemBackedDB) GetAgreementState() (AgreementState, error) {
+	m.agreementStateLock.RLock()
+	defer m.agreementStateLock.RUnlock()
+	if m.agreementState == nil {
+		return AgreementState{}, ErrAgreementStateDoesNotExist
+	}
+	return *m.agreementState.Clone(), nil
+}
+
+// PutOrUpdateAgreementState save the agreement state.
+func (m *MemBackedDB) PutOrUpdateAgreementState(state AgreementState) error {
+	m.agreementStateLock.Lock()
+	defer m.agreementStateLock.Unlock()
+	m.agreementState = state.Clone()
+	return nil
+}
+
+// GetEvidence get the evidence record identified by the hash.
+func (m *MemBackedDB) GetEvidence(hash common.Hash) (EvidenceRecord, error) {
+	m.evidencesLock.RLock()
+	defer m.evidencesLock.RUnlock()
+	record, exists := m.evidences[hash]
+	if !exists {
+		return EvidenceRecord{}, ErrEvidenceDoesNotExist
+	}
+	return *record.Clone(), nil
+}
+
+// GetAllEvidences get all evidence records.
+func (m *MemBackedDB) GetAllEvidences() ([]EvidenceRecord, error) {
+	m.evidencesLock.RLock()
+	defer m.evidencesLock.RUnlock()
+	records := make([]EvidenceRecord, 0, len(m.evidences))
+	for _, record := range m.evidences {
+		records = append(records, *record.Clone())
+	}
+	return records, nil
+}
+
+// PutOrUpdateEvidence save the evidence record.
+func (m *MemBackedDB) PutOrUpdateEvidence(record EvidenceRecord) error {
+	m.evidencesLock.Lock()
+	defer m.evidencesLock.Unlock()
+	m.evidences[record.Hash] = record.Clone()
+	return nil
+}
+
+// DeleteEvidence removes the evidence record.
+func (m *MemBackedDB) DeleteEvidence(hash common.Hash) error {
+	m.evidencesLock.Lock()
+	defer m.evidencesLock.Unlock()
+	delete(m.evidences, hash)
+	return nil
+}
+
+// NewBatch implements the Writer.NewBatch method.
+func (m *MemBackedDB) NewBatch() Batch {
+	return newBatch(m.commitBatch)
+}
+
+func (m *MemBackedDB) commitBatch(ops []batchOp) error {
+	m.blocksLock.Lock()
+	defer m.blocksLock.Unlock()
+	m.compactionChainTipLock.Lock()
+	defer m.compactionChainTipLock.Unlock()
+	m.dkgPrivateKeysLock.Lock()
+	defer m.dkgPrivateKeysLock.Unlock()
+	m.dkgProtocolLock.Lock()
+	defer m.dkgProtocolLock.Unlock()
+
+	checker := newBatchChecker(m)
+	for i := range ops {
+		if _, err := checker.check(&ops[i]); err != nil {
+			return err
+		}
+	}
+	for i := range ops {
+		op := ops[i]
+		switch op.opType {
+		case batchOpPutBlock:
+			m.blockHashSequence = append(m.blockHashSequence, op.block.Hash)
+			m.blocksByHash[op.block.Hash] = &op.block
+			m.blocksByHeight.put(op.block.Position.Height, op.block.Hash)
+		case batchOpUpdateBlock:
+			old := m.blocksByHash[op.block.Hash]
+			m.blocksByHeight.remove(old.Position.Height, old.Hash)
+			m.blocksByHash[op.block.Hash] = &op.block
+			m.blocksByHeight.put(op.block.Position.Height, op.block.Hash)
+		case batchOpPutCompactionChainTipInfo:
+			m.compactionChainTipHash = op.hash
+			m.compactionChainTipHeight = op.height
+		case batchOpPutDKGPrivateKey:
+			m.dkgPrivateKeys[op.round] = &dkgPrivateKey{
+				PK:    op.prvKey,
+				Reset: op.reset,
+			}
+		case batchOpPutOrUpdateDKGProtocol:
+			m.dkgProtocolInfo = &op.dkgProtocol
+		}
+	}
+	return nil
+}
+
+// getBlockHeight is called with blocksLock held.
+func (m *MemBackedDB) getBlockHeight(hash common.Hash) (
+	height uint64, exists bool, err error) {
+	b, exists := m.blocksByHash[hash]
+	if !exists {
+		return
+	}
+	return b.Position.Height, true, nil
+}
+
+// getCompactionChainTipInfo is called with compactionChainTipLock held.
+func (m *MemBackedDB) getCompactionChainTipInfo() (
+	compactionChainTipInfo, error) {
+	return compactionChainTipInfo{
+		Hash:   m.compactionChainTipHash,
+		Height: m.compactionChainTipHeight,
+	}, nil
+}
+
+// getDKGPrivateKeyReset is called with dkgPrivateKeysLock held.
+func (m *MemBackedDB) getDKGPrivateKeyReset(round uint64) (
+	reset uint64, exists bool, err error) {
+	prv, exists := m.dkgPrivateKeys[round]
+	if !exists {
+		return
+	}
+	return prv.Reset, true, nil
+}
+
 // Close implement Closer interface, which would release allocated resource.
 func (m *MemBackedDB) Close() (err error) {
 	// Save internal state to a pretty-print json file. It's a temporary way
@@ -18,12 +18,10 @@
 package db
 
 import (
-	"bytes"
 	"os"
 	"testing"
 
 	"github.com/dexon-foundation/dexon-consensus/common"
-	"github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
 	"github.com/dexon-foundation/dexon-consensus/core/types"
 	"github.com/stretchr/testify/suite"
 )
@@ -128,59 +126,6 @@ func (s *MemBackedDBTestSuite) TestIteration() {
 	s.Contains(touched, s.b02.Hash)
 }
 
-func (s *MemBackedDBTestSuite) TestCompactionChainTipInfo() {
-	dbInst, err := NewMemBackedDB()
-	s.Require().NoError(err)
-	s.Require().NotNil(dbInst)
-	// Save some tip info.
-	hash := common.NewRandomHash()
-	s.Require().NoError(dbInst.PutCompactionChainTipInfo(hash, 1))
-	// Get it back to check.
-	hashBack, height := dbInst.GetCompactionChainTipInfo()
-	s.Require().Equal(hash, hashBack)
-	s.Require().Equal(height, uint64(1))
-	// Unable to put compaction chain tip info with lower height.
-	err = dbInst.PutCompactionChainTipInfo(hash, 0)
-	s.Require().Equal(err.Error(), ErrInvalidCompactionChainTipHeight.Error())
-	// Unable to put compaction chain tip info with height not incremental by 1.
-	err = dbInst.PutCompactionChainTipInfo(hash, 3)
-	s.Require().Equal(err.Error(), ErrInvalidCompactionChainTipHeight.Error())
-	// It's OK to put compaction chain tip info with height incremental by 1.
-	s.Require().NoError(dbInst.PutCompactionChainTipInfo(hash, 2))
-}
-
-func (s *MemBackedDBTestSuite) TestDKGPrivateKey() {
-	dbInst, err := NewMemBackedDB()
-	s.Require().NoError(err)
-	s.Require().NotNil(dbInst)
-	p := dkg.NewPrivateKey()
-	// We should be unable to get it.
-	_, err = dbInst.GetDKGPrivateKey(1, 0)
-	s.Require().Equal(err.Error(), ErrDKGPrivateKeyDoesNotExist.Error())
-	// Put it.
-	s.Require().NoError(dbInst.PutDKGPrivateKey(1, 0, *p))
-	// We should be unable to get it because reset is different.
-	_, err = dbInst.GetDKGPrivateKey(1, 1)
-	s.Require().Equal(err.Error(), ErrDKGPrivateKeyDoesNotExist.Error())
-	// Put it again, should not success.
-	err = dbInst.PutDKGPrivateKey(1, 0, *p)
-	s.Require().Equal(err.Error(), ErrDKGPrivateKeyExists.Error())
-	// Get it back.
-	tmpPrv, err := dbInst.GetDKGPrivateKey(1, 0)
-	s.Require().NoError(err)
-	s.Require().Equal(bytes.Compare(p.Bytes(), tmpPrv.Bytes()), 0)
-	// Put it at different reset.
-	p2 := dkg.NewPrivateKey()
-	s.Require().NoError(dbInst.PutDKGPrivateKey(1, 1, *p2))
-	// We should be unable to get it because reset is different.
-	_, err = dbInst.GetDKGPrivateKey(1, 0)
-	// Get it back.
-	tmpPrv, err = dbInst.GetDKGPrivateKey(1, 1)
-	s.Require().NoError(err)
-	s.Require().Equal(bytes.Compare(p2.Bytes(), tmpPrv.Bytes()), 0)
-	s.Require().NotEqual(bytes.Compare(p2.Bytes(), p.Bytes()), 0)
-}
-
 func TestMemBackedDB(t *testing.T) {
 	suite.Run(t, new(MemBackedDBTestSuite))
 }
@@ -217,7 +217,11 @@ type tsigProtocol struct {
 	nodePublicKeys *typesDKG.NodePublicKeys
 	hash           common.Hash
 	sigs           map[dkg.ID]dkg.PartialSignature
-	threshold      int
+	// unverified are partial signatures added in batch, they're verified
+	// together by verifying the recovered signature.
+	unverified map[dkg.ID]*typesDKG.PartialSignature
+	sigCache   *utils.SignatureCache
+	threshold  int
 }
 
 func newDKGProtocol(
@@ -653,6 +657,7 @@ func newTSigProtocol(
 		nodePublicKeys: npks,
 		hash:           hash,
 		sigs:           make(map[dkg.ID]dkg.PartialSignature, npks.Threshold+1),
+		unverified:     make(map[dkg.ID]*typesDKG.PartialSignature),
 	}
 }
 
@@ -661,7 +666,7 @@ func (tsig *tsigProtocol) sanityCheck(psig *typesDKG.PartialSignature) error {
 	if !exist {
 		return ErrNotQualifyDKGParticipant
 	}
-	ok, err := utils.VerifyDKGPartialSignatureSignature(psig)
+	ok, err := tsig.sigCache.VerifyDKGPartialSignatureSignature(psig)
 	if err != nil {
 		return err
 	}
@@ -692,18 +697,77 @@ func (tsig *tsigProtocol) processPartialSignature(
 		return ErrIncorrectPartialSignature
 	}
 	tsig.sigs[id] = psig.PartialSignature
+	delete(tsig.unverified, id)
 	return nil
 }
 
+// processPartialSignatures adds partial signatures without verifying them one
+// by one. Instead, the signature recovered from them is verified by the group
+// public key, and they're verified one by one only when it fails. Errors are
+// returned for partial signatures failed to pass the sanity check.
+func (tsig *tsigProtocol) processPartialSignatures(
+	psigs []*typesDKG.PartialSignature) (errs []error) {
+	for _, psig := range psigs {
+		if psig.Round != tsig.nodePublicKeys.Round {
+			continue
+		}
+		id, exist := tsig.nodePublicKeys.IDMap[psig.ProposerID]
+		if !exist {
+			errs = append(errs, ErrNotQualifyDKGParticipant)
+			continue
+		}
+		if err := tsig.sanityCheck(psig); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if _, exist := tsig.sigs[id]; exist {
+			continue
+		}
+		tsig.unverified[id] = psig
+	}
+	return
+}
+
+// verifyPartialSignatures verifies unverified partial signatures one by one,
+// the incorrect ones are dropped.
+func (tsig *tsigProtocol) verifyPartialSignatures() {
+	for id, psig := range tsig.unverified {
+		pubKey := tsig.nodePublicKeys.PublicKeys[psig.ProposerID]
+		if pubKey.VerifySignature(
+			tsig.hash, crypto.Signature(psig.PartialSignature)) {
+			tsig.sigs[id] = psig.PartialSignature
+		}
+	}
+	tsig.unverified = make(map[dkg.ID]*typesDKG.PartialSignature)
+}
+
 func (tsig *tsigProtocol) signature() (crypto.Signature, error) {
-	if len(tsig.sigs) < tsig.nodePublicKeys.Threshold {
+	if len(tsig.sigs)+len(tsig.unverified) < tsig.nodePublicKeys.Threshold {
 		return crypto.Signature{}, ErrNotEnoughtPartialSignatures
 	}
-	ids := make(dkg.IDs, 0, len(tsig.sigs))
-	psigs := make([]dkg.PartialSignature, 0, len(tsig.sigs))
+	ids := make(dkg.IDs, 0, len(tsig.sigs)+len(tsig.unverified))
+	psigs := make(
+		[]dkg.PartialSignature, 0, len(tsig.sigs)+len(tsig.unverified))
 	for id, psig := range tsig.sigs {
 		ids = append(ids, id)
 		psigs = append(psigs, psig)
 	}
-	return dkg.RecoverSignature(psigs, ids)
+	if len(tsig.unverified) == 0 {
+		return dkg.RecoverSignature(psigs, ids)
+	}
+	for id, psig := range tsig.unverified {
+		ids = append(ids, id)
+		psigs = append(psigs, psig.PartialSignature)
+	}
+	sig, err := dkg.RecoverSignature(psigs, ids)
+	if err == nil && tsig.nodePublicKeys.GroupPublicKey != nil &&
+		tsig.nodePublicKeys.GroupPublicKey.VerifySignature(tsig.hash, sig) {
+		for id, psig := range tsig.unverified {
+			tsig.sigs[id] = psig.PartialSignature
+		}
+		tsig.unverified = make(map[dkg.ID]*typesDKG.PartialSignature)
+		return sig, nil
+	}
+	tsig.verifyPartialSignatures()
+	return tsig.signature()
 }
@@ -817,6 +817,7 @@ func (s *DKGTSIGProtocolTestSuite) TestPartialSignature() {
 	tsig := newTSigProtocol(npks, msgHash)
 	byzantineID2 := s.nIDs[1]
 	byzantineID3 := s.nIDs[2]
+	psigs := make([]*typesDKG.PartialSignature, 0, len(shareSecrets))
 	for nID, shareSecret := range shareSecrets {
 		psig := &typesDKG.PartialSignature{
 			ProposerID:       nID,
@@ -833,6 +834,7 @@ func (s *DKGTSIGProtocolTestSuite) TestPartialSignature() {
 		}
 		err := s.signers[nID].SignDKGPartialSignature(psig)
 		s.Require().NoError(err)
+		psigs = append(psigs, psig)
 		err = tsig.processPartialSignature(psig)
 		switch nID {
 		case byzantineID:
@@ -849,6 +851,21 @@ func (s *DKGTSIGProtocolTestSuite) TestPartialSignature() {
 	sig, err := tsig.signature()
 	s.Require().NoError(err)
 	s.True(gpk.VerifySignature(msgHash, sig))
+
+	// Partial signatures processed in batch.
+	tsig = newTSigProtocol(npks, msgHash)
+	errs := tsig.processPartialSignatures(psigs)
+	s.Require().Len(errs, 2)
+	s.Require().Contains(errs, ErrNotQualifyDKGParticipant)
+	s.Require().Contains(errs, ErrMismatchPartialSignatureHash)
+	s.Require().Empty(tsig.sigs)
+	batchSig, err := tsig.signature()
+	s.Require().NoError(err)
+	s.Require().Equal(sig, batchSig)
+	// The incorrect one is dropped when the recovered signature fails.
+	s.Require().Empty(tsig.unverified)
+	_, exist := tsig.sigs[npks.IDMap[byzantineID2]]
+	s.Require().False(exist)
 }
 
 func (s *DKGTSIGProtocolTestSuite) TestProposeReady() {
@@ -0,0 +1,260 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+)
+
+// EventType is the type of events emitted by Consensus.
+type EventType int
+
+// EventType enum.
+const (
+	EventBARestarted EventType = iota
+	EventVoteReceived
+	EventVoteProposed
+	EventPeriodAdvanced
+	EventForkVote
+	EventForkBlock
+	EventAgreementResult
+	EventDKGPhase
+	EventDKGReset
+	EventTSigCompleted
+	EventRound
+	// Please add new event types above this line.
+	maxEventType
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventBARestarted:
+		return "BARestarted"
+	case EventVoteReceived:
+		return "VoteReceived"
+	case EventVoteProposed:
+		return "VoteProposed"
+	case EventPeriodAdvanced:
+		return "PeriodAdvanced"
+	case EventForkVote:
+		return "ForkVote"
+	case EventForkBlock:
+		return "ForkBlock"
+	case EventAgreementResult:
+		return "AgreementResult"
+	case EventDKGPhase:
+		return "DKGPhase"
+	case EventDKGReset:
+		return "DKGReset"
+	case EventTSigCompleted:
+		return "TSigCompleted"
+	case EventRound:
+		return "Round"
+	}
+	return "Unknown"
+}
+
+// ConsensusEvent is the interface of events emitted by Consensus. Events are
+// shared among subscribers and should be treated as read-only.
+type ConsensusEvent interface {
+	Type() EventType
+}
+
+// BARestartedEvent is emitted when BA is restarted at a position.
+type BARestartedEvent struct {
+	Position types.Position
+	Leader   types.NodeID
+}
+
+// Type implements ConsensusEvent interface.
+func (*BARestartedEvent) Type() EventType { return EventBARestarted }
+
+// VoteReceivedEvent is emitted when a vote from network is processed.
+type VoteReceivedEvent struct {
+	Vote *types.Vote
+}
+
+// Type implements ConsensusEvent interface.
+func (*VoteReceivedEvent) Type() EventType { return EventVoteReceived }
+
+// VoteProposedEvent is emitted when this node proposes a vote.
+type VoteProposedEvent struct {
+	Vote *types.Vote
+}
+
+// Type implements ConsensusEvent interface.
+func (*VoteProposedEvent) Type() EventType { return EventVoteProposed }
+
+// PeriodAdvancedEvent is emitted when BA advances to a newer period.
+type PeriodAdvancedEvent struct {
+	Position types.Position
+	Period   uint64
+}
+
+// Type implements ConsensusEvent interface.
+func (*PeriodAdvancedEvent) Type() EventType { return EventPeriodAdvanced }
+
+// ForkVoteEvent is emitted when two conflicting votes from the same proposer
+// are detected.
+type ForkVoteEvent struct {
+	Vote1, Vote2 *types.Vote
+}
+
+// Type implements ConsensusEvent interface.
+func (*ForkVoteEvent) Type() EventType { return EventForkVote }
+
+// ForkBlockEvent is emitted when two conflicting blocks from the same proposer
+// are detected.
+type ForkBlockEvent struct {
+	Block1, Block2 *types.Block
+}
+
+// Type implements ConsensusEvent interface.
+func (*ForkBlockEvent) Type() EventType { return EventForkBlock }
+
+// AgreementResultEvent is emitted when this node produces an agreement
+// result.
+type AgreementResultEvent struct {
+	Result *types.AgreementResult
+}
+
+// Type implements ConsensusEvent interface.
+func (*AgreementResultEvent) Type() EventType { return EventAgreementResult }
+
+// DKGPhaseEvent is emitted when DKG protocol enters a phase.
+type DKGPhaseEvent struct {
+	Round uint64
+	Reset uint64
+	Phase int
+}
+
+// Type implements ConsensusEvent interface.
+func (*DKGPhaseEvent) Type() EventType { return EventDKGPhase }
+
+// DKGResetEvent is emitted when DKG of a round is reset.
+type DKGResetEvent struct {
+	Round uint64
+	Reset uint64
+}
+
+// Type implements ConsensusEvent interface.
+func (*DKGResetEvent) Type() EventType { return EventDKGReset }
+
+// TSigCompletedEvent is emitted when a threshold signature is recovered.
+type TSigCompletedEvent struct {
+	Round   uint64
+	Hash    common.Hash
+	Elapsed time.Duration
+}
+
+// Type implements ConsensusEvent interface.
+func (*TSigCompletedEvent) Type() EventType { return EventTSigCompleted }
+
+// RoundEvent is emitted when a round event is fired.
+type RoundEvent struct {
+	Param utils.RoundEventParam
+}
+
+// Type implements ConsensusEvent interface.
+func (*RoundEvent) Type() EventType { return EventRound }
+
+// Subscription receives events from Consensus. Events are dropped instead of
+// blocking Consensus when its channel is full.
+type Subscription struct {
+	// dropped is accessed atomically, keep it 64-bit aligned.
+	dropped uint64
+	feed    *eventFeed
+	ch      chan ConsensusEvent
+	filter  [maxEventType]bool
+	once    sync.Once
+}
+
+// Chan returns the channel to receive events, it's closed when unsubscribed.
+func (s *Subscription) Chan() <-chan ConsensusEvent {
+	return s.ch
+}
+
+// Dropped returns the count of events dropped due to a full channel.
+func (s *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Unsubscribe stops receiving events and closes the channel.
+func (s *Subscription) Unsubscribe() {
+	s.once.Do(func() {
+		s.feed.remove(s)
+		close(s.ch)
+	})
+}
+
+// eventFeed dispatches events to subscriptions.
+type eventFeed struct {
+	lock sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+func newEventFeed() *eventFeed {
+	return &eventFeed{subs: make(map[*Subscription]struct{})}
+}
+
+func (f *eventFeed) subscribe(
+	bufferSize int, eventTypes []EventType) *Subscription {
+	sub := &Subscription{
+		feed: f,
+		ch:   make(chan ConsensusEvent, bufferSize),
+	}
+	for i := range sub.filter {
+		sub.filter[i] = len(eventTypes) == 0
+	}
+	for _, t := range eventTypes {
+		if t >= 0 && t < maxEventType {
+			sub.filter[t] = true
+		}
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.subs[sub] = struct{}{}
+	return sub
+}
+
+func (f *eventFeed) remove(sub *Subscription) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	delete(f.subs, sub)
+}
+
+func (f *eventFeed) emit(e ConsensusEvent) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	for sub := range f.subs {
+		if !sub.filter[e.Type()] {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			atomic.AddUint64(&sub.dropped, 1)
+		}
+	}
+}
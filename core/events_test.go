@@ -0,0 +1,68 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type EventsTestSuite struct {
+	suite.Suite
+}
+
+func (s *EventsTestSuite) TestFilter() {
+	feed := newEventFeed()
+	all := feed.subscribe(10, nil)
+	dkg := feed.subscribe(10, []EventType{EventDKGPhase, EventDKGReset})
+	feed.emit(&DKGPhaseEvent{Round: 1, Phase: 2})
+	feed.emit(&BARestartedEvent{Position: types.Position{Height: 3}})
+	feed.emit(&DKGResetEvent{Round: 1, Reset: 1})
+	s.Require().Len(all.Chan(), 3)
+	s.Require().Len(dkg.Chan(), 2)
+	e := <-dkg.Chan()
+	s.Require().Equal(EventDKGPhase, e.Type())
+	s.Require().Equal(uint64(1), e.(*DKGPhaseEvent).Round)
+	s.Require().Equal(EventDKGReset, (<-dkg.Chan()).Type())
+	s.Require().Equal("BARestarted", EventBARestarted.String())
+}
+
+func (s *EventsTestSuite) TestDropAndUnsubscribe() {
+	feed := newEventFeed()
+	sub := feed.subscribe(1, []EventType{EventRound})
+	for i := 0; i < 3; i++ {
+		feed.emit(&RoundEvent{})
+	}
+	s.Require().Equal(uint64(2), sub.Dropped())
+	sub.Unsubscribe()
+	sub.Unsubscribe()
+	// Buffered event is still readable before the closed channel.
+	_, ok := <-sub.Chan()
+	s.Require().True(ok)
+	_, ok = <-sub.Chan()
+	s.Require().False(ok)
+	// Emitting after unsubscribing should not panic.
+	feed.emit(&RoundEvent{})
+}
+
+func TestEvents(t *testing.T) {
+	suite.Run(t, new(EventsTestSuite))
+}
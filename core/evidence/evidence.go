@@ -0,0 +1,247 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package evidence collects evidences of misbehaviour, ex. forking votes and
+// blocks or sending bad DKG private shares, and submits them to governance.
+package evidence
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/dexon-foundation/dexon/rlp"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+)
+
+// Errors for evidence.
+var (
+	ErrInvalidEvidence            = errors.New("invalid evidence")
+	ErrIncorrectEvidenceSignature = errors.New(
+		"incorrect evidence signature")
+	ErrUnknownEvidenceType = errors.New("unknown evidence type")
+	ErrEvidenceExists      = errors.New("evidence exists")
+)
+
+type forkVote struct {
+	Vote1 types.Vote
+	Vote2 types.Vote
+}
+
+type forkBlock struct {
+	Block1 *types.Block
+	Block2 *types.Block
+}
+
+type dkgPrivateShare struct {
+	Complaint       *typesDKG.Complaint
+	MasterPublicKey *typesDKG.MasterPublicKey
+}
+
+// NewForkVote creates an unsigned evidence of fork votes. Votes are sorted
+// by block hash, so the evidence is the same regardless of their order.
+func NewForkVote(vote1, vote2 *types.Vote) (*types.Evidence, error) {
+	if bytes.Compare(vote1.BlockHash[:], vote2.BlockHash[:]) > 0 {
+		vote1, vote2 = vote2, vote1
+	}
+	payload, err := rlp.EncodeToBytes(&forkVote{Vote1: *vote1, Vote2: *vote2})
+	if err != nil {
+		return nil, err
+	}
+	return &types.Evidence{
+		Type:     types.EvidenceForkVote,
+		Offender: vote1.ProposerID,
+		Payload:  payload,
+	}, nil
+}
+
+// NewForkBlock creates an unsigned evidence of fork blocks. Blocks are sorted
+// by hash, so the evidence is the same regardless of their order.
+func NewForkBlock(block1, block2 *types.Block) (*types.Evidence, error) {
+	if bytes.Compare(block1.Hash[:], block2.Hash[:]) > 0 {
+		block1, block2 = block2, block1
+	}
+	payload, err := rlp.EncodeToBytes(&forkBlock{
+		Block1: block1,
+		Block2: block2,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &types.Evidence{
+		Type:     types.EvidenceForkBlock,
+		Offender: block1.ProposerID,
+		Payload:  payload,
+	}, nil
+}
+
+// NewDKGPrivateShare creates an unsigned evidence of a bad DKG private share,
+// which is proved by the complaint and the master public key of the sender.
+func NewDKGPrivateShare(complaint *typesDKG.Complaint,
+	mpk *typesDKG.MasterPublicKey) (*types.Evidence, error) {
+	payload, err := rlp.EncodeToBytes(&dkgPrivateShare{
+		Complaint:       complaint,
+		MasterPublicKey: mpk,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &types.Evidence{
+		Type:     types.EvidenceDKGPrivateShare,
+		Offender: mpk.ProposerID,
+		Payload:  payload,
+	}, nil
+}
+
+// ForkVote decodes votes from an evidence of fork votes.
+func ForkVote(evidence *types.Evidence) (vote1, vote2 *types.Vote, err error) {
+	if evidence.Type != types.EvidenceForkVote {
+		err = ErrInvalidEvidence
+		return
+	}
+	dec := forkVote{}
+	if err = rlp.DecodeBytes(evidence.Payload, &dec); err != nil {
+		return
+	}
+	vote1, vote2 = &dec.Vote1, &dec.Vote2
+	return
+}
+
+// ForkBlock decodes blocks from an evidence of fork blocks.
+func ForkBlock(evidence *types.Evidence) (
+	block1, block2 *types.Block, err error) {
+	if evidence.Type != types.EvidenceForkBlock {
+		err = ErrInvalidEvidence
+		return
+	}
+	dec := forkBlock{}
+	if err = rlp.DecodeBytes(evidence.Payload, &dec); err != nil {
+		return
+	}
+	block1, block2 = dec.Block1, dec.Block2
+	return
+}
+
+// DKGPrivateShare decodes the complaint and the master public key from an
+// evidence of a bad DKG private share.
+func DKGPrivateShare(evidence *types.Evidence) (
+	complaint *typesDKG.Complaint, mpk *typesDKG.MasterPublicKey, err error) {
+	if evidence.Type != types.EvidenceDKGPrivateShare {
+		err = ErrInvalidEvidence
+		return
+	}
+	dec := dkgPrivateShare{
+		Complaint:       &typesDKG.Complaint{},
+		MasterPublicKey: typesDKG.NewMasterPublicKey(),
+	}
+	if err = rlp.DecodeBytes(evidence.Payload, &dec); err != nil {
+		return
+	}
+	complaint, mpk = dec.Complaint, dec.MasterPublicKey
+	return
+}
+
+// Round returns the round of the misbehaviour proved by an evidence.
+func Round(evidence *types.Evidence) (uint64, error) {
+	switch evidence.Type {
+	case types.EvidenceForkVote:
+		vote1, _, err := ForkVote(evidence)
+		if err != nil {
+			return 0, err
+		}
+		return vote1.Position.Round, nil
+	case types.EvidenceForkBlock:
+		block1, _, err := ForkBlock(evidence)
+		if err != nil {
+			return 0, err
+		}
+		return block1.Position.Round, nil
+	case types.EvidenceDKGPrivateShare:
+		complaint, _, err := DKGPrivateShare(evidence)
+		if err != nil {
+			return 0, err
+		}
+		return complaint.Round, nil
+	}
+	return 0, ErrUnknownEvidenceType
+}
+
+// Verify checks the signature of an evidence and the misbehaviour it proves.
+func Verify(evidence *types.Evidence) error {
+	ok, err := utils.VerifyEvidenceSignature(evidence)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrIncorrectEvidenceSignature
+	}
+	var expected *types.Evidence
+	switch evidence.Type {
+	case types.EvidenceForkVote:
+		var vote1, vote2 *types.Vote
+		if vote1, vote2, err = ForkVote(evidence); err != nil {
+			return err
+		}
+		if ok, err = utils.NeedPenaltyForkVote(vote1, vote2); err != nil {
+			return err
+		}
+		if ok {
+			expected, err = NewForkVote(vote1, vote2)
+		}
+	case types.EvidenceForkBlock:
+		var block1, block2 *types.Block
+		if block1, block2, err = ForkBlock(evidence); err != nil {
+			return err
+		}
+		if ok, err = utils.NeedPenaltyForkBlock(block1, block2); err != nil {
+			return err
+		}
+		if ok {
+			expected, err = NewForkBlock(block1, block2)
+		}
+	case types.EvidenceDKGPrivateShare:
+		var (
+			complaint *typesDKG.Complaint
+			mpk       *typesDKG.MasterPublicKey
+		)
+		if complaint, mpk, err = DKGPrivateShare(evidence); err != nil {
+			return err
+		}
+		if ok, err = utils.NeedPenaltyDKGPrivateShare(
+			complaint, mpk); err != nil {
+			return err
+		}
+		if ok {
+			expected, err = NewDKGPrivateShare(complaint, mpk)
+		}
+	default:
+		return ErrUnknownEvidenceType
+	}
+	if err != nil {
+		return err
+	}
+	// The evidence should be encoded in the same way as the one created by
+	// this node, or the same misbehaviour could be reported as different
+	// evidences.
+	if !ok || expected.Offender != evidence.Offender ||
+		!bytes.Equal(expected.Payload, evidence.Payload) {
+		return ErrInvalidEvidence
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package evidence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+	"github.com/dexon-foundation/dexon/rlp"
+)
+
+type evidenceTestBase struct {
+	suite.Suite
+}
+
+func (s *evidenceTestBase) newSigner() *utils.Signer {
+	prv, err := ecdsa.NewPrivateKey()
+	s.Require().NoError(err)
+	return utils.NewSigner(prv)
+}
+
+func (s *evidenceTestBase) newForkVotes(
+	signer *utils.Signer) (*types.Vote, *types.Vote) {
+	pos := types.Position{Round: 1, Height: 10}
+	vote1 := types.NewVote(types.VotePreCom, common.NewRandomHash(), 2)
+	vote1.Position = pos
+	s.Require().NoError(signer.SignVote(vote1))
+	vote2 := types.NewVote(types.VotePreCom, common.NewRandomHash(), 2)
+	vote2.Position = pos
+	s.Require().NoError(signer.SignVote(vote2))
+	return vote1, vote2
+}
+
+func (s *evidenceTestBase) newForkBlocks(
+	signer *utils.Signer) (*types.Block, *types.Block) {
+	pos := types.Position{Round: 1, Height: 10}
+	block1 := &types.Block{
+		ParentHash: common.NewRandomHash(),
+		Position:   pos,
+		Timestamp:  time.Now().UTC(),
+	}
+	s.Require().NoError(signer.SignBlock(block1))
+	block2 := &types.Block{
+		ParentHash: common.NewRandomHash(),
+		Position:   pos,
+		Timestamp:  time.Now().UTC(),
+	}
+	s.Require().NoError(signer.SignBlock(block2))
+	return block1, block2
+}
+
+type EvidenceTestSuite struct {
+	evidenceTestBase
+}
+
+func (s *EvidenceTestSuite) TestForkVote() {
+	offender, reporter := s.newSigner(), s.newSigner()
+	vote1, vote2 := s.newForkVotes(offender)
+	evidence, err := NewForkVote(vote1, vote2)
+	s.Require().NoError(err)
+	s.Require().Equal(vote1.ProposerID, evidence.Offender)
+	// The order of votes doesn't matter.
+	evidence2, err := NewForkVote(vote2, vote1)
+	s.Require().NoError(err)
+	s.Require().Equal(evidence.Payload, evidence2.Payload)
+	// Decode it.
+	decoded1, decoded2, err := ForkVote(evidence)
+	s.Require().NoError(err)
+	s.Require().ElementsMatch(
+		[]common.Hash{decoded1.BlockHash, decoded2.BlockHash},
+		[]common.Hash{vote1.BlockHash, vote2.BlockHash})
+	ok, err := utils.VerifyVoteSignature(decoded1)
+	s.Require().NoError(err)
+	s.Require().True(ok)
+	_, _, err = ForkBlock(evidence)
+	s.Require().Equal(ErrInvalidEvidence, err)
+	// Verify it.
+	s.Require().NoError(reporter.SignEvidence(evidence))
+	s.Require().NoError(Verify(evidence))
+	// Votes of different periods are not forked.
+	vote2.Period++
+	s.Require().NoError(offender.SignVote(vote2))
+	evidence, err = NewForkVote(vote1, vote2)
+	s.Require().NoError(err)
+	s.Require().NoError(reporter.SignEvidence(evidence))
+	s.Require().Equal(ErrInvalidEvidence, Verify(evidence))
+}
+
+func (s *EvidenceTestSuite) TestForkBlock() {
+	offender, reporter := s.newSigner(), s.newSigner()
+	block1, block2 := s.newForkBlocks(offender)
+	evidence, err := NewForkBlock(block1, block2)
+	s.Require().NoError(err)
+	s.Require().Equal(block1.ProposerID, evidence.Offender)
+	evidence2, err := NewForkBlock(block2, block1)
+	s.Require().NoError(err)
+	s.Require().Equal(evidence.Payload, evidence2.Payload)
+	decoded1, decoded2, err := ForkBlock(evidence)
+	s.Require().NoError(err)
+	s.Require().NoError(utils.VerifyBlockSignatureWithoutPayload(decoded1))
+	s.Require().NoError(utils.VerifyBlockSignatureWithoutPayload(decoded2))
+	s.Require().NoError(reporter.SignEvidence(evidence))
+	s.Require().NoError(Verify(evidence))
+}
+
+func (s *EvidenceTestSuite) TestVerify() {
+	offender, reporter := s.newSigner(), s.newSigner()
+	vote1, vote2 := s.newForkVotes(offender)
+	evidence, err := NewForkVote(vote1, vote2)
+	s.Require().NoError(err)
+	s.Require().NoError(reporter.SignEvidence(evidence))
+	// Incorrect signature.
+	modified := evidence.Clone()
+	modified.ProposerID = types.NodeID{Hash: common.NewRandomHash()}
+	s.Require().Equal(ErrIncorrectEvidenceSignature, Verify(modified))
+	// The offender is not the one signed the votes.
+	modified = evidence.Clone()
+	modified.Offender = modified.ProposerID
+	s.Require().NoError(reporter.SignEvidence(modified))
+	s.Require().Equal(ErrInvalidEvidence, Verify(modified))
+	// Unknown type.
+	modified = evidence.Clone()
+	modified.Type = types.MaxEvidenceType
+	s.Require().NoError(reporter.SignEvidence(modified))
+	s.Require().Equal(ErrUnknownEvidenceType, Verify(modified))
+	// Votes should be sorted by block hash.
+	if vote1.BlockHash.Less(vote2.BlockHash) {
+		vote1, vote2 = vote2, vote1
+	}
+	modified = evidence.Clone()
+	modified.Payload, err = rlp.EncodeToBytes(
+		&forkVote{Vote1: *vote1, Vote2: *vote2})
+	s.Require().NoError(err)
+	s.Require().NoError(reporter.SignEvidence(modified))
+	s.Require().Equal(ErrInvalidEvidence, Verify(modified))
+}
+
+func TestEvidence(t *testing.T) {
+	suite.Run(t, new(EvidenceTestSuite))
+}
@@ -0,0 +1,268 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package evidence
+
+import (
+	"sync"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/db"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+)
+
+// Governance is the part of core.Governance evidences are submitted to.
+type Governance interface {
+	ReportForkVote(vote1, vote2 *types.Vote)
+	ReportForkBlock(block1, block2 *types.Block)
+	AddDKGComplaint(complaint *typesDKG.Complaint)
+}
+
+// Network is the part of core.Network evidences are gossiped with, it's
+// core.EvidenceBroadcaster.
+type Network interface {
+	BroadcastEvidence(evidence *types.Evidence)
+}
+
+// Pool keeps evidences reported by this node and received from others in
+// database. Evidences reported by this node are gossiped and submitted to
+// governance once, the ones received from others are only kept to avoid
+// reporting the same misbehaviour again. Evidences of finished rounds are
+// removed by Prune.
+type Pool struct {
+	lock    sync.Mutex
+	nID     types.NodeID
+	signer  *utils.Signer
+	db      db.Database
+	gov     Governance
+	network Network
+	logger  common.Logger
+	// recorded maps hashes of evidences kept to their rounds.
+	recorded map[common.Hash]uint64
+	// Evidences of rounds before pruned are removed and treated as existing.
+	pruned uint64
+}
+
+// NewPool constructs a Pool instance, evidences reported by this node but
+// not yet submitted before restarted are gossiped and submitted again. The
+// network could be nil, evidences are only submitted to governance then.
+func NewPool(nID types.NodeID, signer *utils.Signer, dbInst db.Database,
+	gov Governance, network Network, logger common.Logger) (*Pool, error) {
+	records, err := dbInst.GetAllEvidences()
+	if err != nil {
+		return nil, err
+	}
+	p := &Pool{
+		nID:      nID,
+		signer:   signer,
+		db:       dbInst,
+		gov:      gov,
+		network:  network,
+		logger:   logger,
+		recorded: make(map[common.Hash]uint64),
+	}
+	for i := range records {
+		record := &records[i]
+		round, err := Round(&record.Evidence)
+		if err != nil {
+			return nil, err
+		}
+		p.recorded[record.Hash] = round
+		if record.Submitted || record.Evidence.ProposerID != nID {
+			continue
+		}
+		p.logger.Info("Resubmit evidence", "evidence", &record.Evidence)
+		p.broadcast(record.Evidence.Clone())
+		p.submit(record)
+	}
+	return p, nil
+}
+
+// AddForkVote reports fork votes.
+func (p *Pool) AddForkVote(vote1, vote2 *types.Vote) error {
+	ok, err := utils.NeedPenaltyForkVote(vote1, vote2)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidEvidence
+	}
+	evidence, err := NewForkVote(vote1, vote2)
+	if err != nil {
+		return err
+	}
+	return p.report(evidence)
+}
+
+// AddForkBlock reports fork blocks, payloads of blocks should be removed.
+func (p *Pool) AddForkBlock(block1, block2 *types.Block) error {
+	ok, err := utils.NeedPenaltyForkBlock(block1, block2)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidEvidence
+	}
+	evidence, err := NewForkBlock(block1, block2)
+	if err != nil {
+		return err
+	}
+	return p.report(evidence)
+}
+
+// AddDKGPrivateShare reports a bad DKG private share with the complaint
+// signed by this node, the complaint is submitted to governance as the
+// evidence.
+func (p *Pool) AddDKGPrivateShare(complaint *typesDKG.Complaint,
+	mpk *typesDKG.MasterPublicKey) error {
+	ok, err := utils.NeedPenaltyDKGPrivateShare(complaint, mpk)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidEvidence
+	}
+	evidence, err := NewDKGPrivateShare(complaint, mpk)
+	if err != nil {
+		return err
+	}
+	return p.report(evidence)
+}
+
+// ProcessEvidence keeps an evidence received from others.
+func (p *Pool) ProcessEvidence(evidence *types.Evidence) error {
+	if err := Verify(evidence); err != nil {
+		return err
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	_, err := p.add(evidence)
+	return err
+}
+
+// Has checks if the evidence is kept.
+func (p *Pool) Has(hash common.Hash) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	_, exists := p.recorded[hash]
+	return exists
+}
+
+func (p *Pool) report(evidence *types.Evidence) error {
+	if err := p.signer.SignEvidence(evidence); err != nil {
+		return err
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	record, err := p.add(evidence)
+	if err != nil {
+		return err
+	}
+	p.broadcast(evidence)
+	p.submit(record)
+	return nil
+}
+
+// Prune removes evidences of rounds before the given one.
+func (p *Pool) Prune(round uint64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if round > p.pruned {
+		p.pruned = round
+	}
+	for hash, r := range p.recorded {
+		if r >= round {
+			continue
+		}
+		if err := p.db.DeleteEvidence(hash); err != nil {
+			p.logger.Error("Failed to delete evidence",
+				"hash", hash,
+				"error", err)
+			continue
+		}
+		delete(p.recorded, hash)
+	}
+}
+
+func (p *Pool) broadcast(evidence *types.Evidence) {
+	if p.network != nil {
+		p.network.BroadcastEvidence(evidence)
+	}
+}
+
+// add saves an evidence, it should be called with p.lock held.
+func (p *Pool) add(evidence *types.Evidence) (*db.EvidenceRecord, error) {
+	hash := utils.HashEvidence(evidence)
+	if _, exists := p.recorded[hash]; exists {
+		return nil, ErrEvidenceExists
+	}
+	round, err := Round(evidence)
+	if err != nil {
+		return nil, err
+	}
+	if round < p.pruned {
+		return nil, ErrEvidenceExists
+	}
+	record := &db.EvidenceRecord{
+		Hash:     hash,
+		Evidence: *evidence.Clone(),
+	}
+	if err = p.db.PutOrUpdateEvidence(*record); err != nil {
+		return nil, err
+	}
+	p.recorded[hash] = round
+	return record, nil
+}
+
+// submit submits an evidence to governance and marks it submitted.
+func (p *Pool) submit(record *db.EvidenceRecord) {
+	evidence := &record.Evidence
+	var err error
+	switch evidence.Type {
+	case types.EvidenceForkVote:
+		var vote1, vote2 *types.Vote
+		if vote1, vote2, err = ForkVote(evidence); err == nil {
+			p.gov.ReportForkVote(vote1, vote2)
+		}
+	case types.EvidenceForkBlock:
+		var block1, block2 *types.Block
+		if block1, block2, err = ForkBlock(evidence); err == nil {
+			p.gov.ReportForkBlock(block1, block2)
+		}
+	case types.EvidenceDKGPrivateShare:
+		var complaint *typesDKG.Complaint
+		if complaint, _, err = DKGPrivateShare(evidence); err == nil {
+			p.gov.AddDKGComplaint(complaint)
+		}
+	default:
+		err = ErrUnknownEvidenceType
+	}
+	if err != nil {
+		p.logger.Error("Failed to submit evidence",
+			"evidence", evidence,
+			"error", err)
+		return
+	}
+	record.Submitted = true
+	if err = p.db.PutOrUpdateEvidence(*record); err != nil {
+		p.logger.Error("Failed to mark evidence submitted",
+			"evidence", evidence,
+			"error", err)
+	}
+}
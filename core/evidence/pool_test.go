@@ -0,0 +1,216 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package evidence
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
+	"github.com/dexon-foundation/dexon-consensus/core/db"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+)
+
+type testGov struct {
+	forkVotes  int
+	forkBlocks int
+	complaints int
+}
+
+func (g *testGov) ReportForkVote(vote1, vote2 *types.Vote) {
+	g.forkVotes++
+}
+
+func (g *testGov) ReportForkBlock(block1, block2 *types.Block) {
+	g.forkBlocks++
+}
+
+func (g *testGov) AddDKGComplaint(complaint *typesDKG.Complaint) {
+	g.complaints++
+}
+
+type testNetwork struct {
+	evidences []*types.Evidence
+}
+
+func (n *testNetwork) BroadcastEvidence(evidence *types.Evidence) {
+	n.evidences = append(n.evidences, evidence)
+}
+
+type PoolTestSuite struct {
+	evidenceTestBase
+
+	nID     types.NodeID
+	signer  *utils.Signer
+	db      db.Database
+	gov     *testGov
+	network *testNetwork
+}
+
+func (s *PoolTestSuite) SetupTest() {
+	prv, err := ecdsa.NewPrivateKey()
+	s.Require().NoError(err)
+	s.nID = types.NewNodeID(prv.PublicKey())
+	s.signer = utils.NewSigner(prv)
+	s.db, err = db.NewMemBackedDB()
+	s.Require().NoError(err)
+	s.gov = &testGov{}
+	s.network = &testNetwork{}
+}
+
+func (s *PoolTestSuite) newPool() *Pool {
+	p, err := NewPool(s.nID, s.signer, s.db, s.gov, s.network,
+		&common.NullLogger{})
+	s.Require().NoError(err)
+	return p
+}
+
+func (s *PoolTestSuite) TestReport() {
+	p := s.newPool()
+	offender := s.newSigner()
+	vote1, vote2 := s.newForkVotes(offender)
+	s.Require().NoError(p.AddForkVote(vote1, vote2))
+	s.Require().Equal(1, s.gov.forkVotes)
+	s.Require().Len(s.network.evidences, 1)
+	evidence := s.network.evidences[0]
+	s.Require().Equal(s.nID, evidence.ProposerID)
+	s.Require().NoError(Verify(evidence))
+	s.Require().True(p.Has(utils.HashEvidence(evidence)))
+	// Report it again in different order.
+	s.Require().Equal(ErrEvidenceExists, p.AddForkVote(vote2, vote1))
+	s.Require().Equal(1, s.gov.forkVotes)
+	s.Require().Len(s.network.evidences, 1)
+	// Report fork blocks.
+	block1, block2 := s.newForkBlocks(offender)
+	s.Require().NoError(p.AddForkBlock(block1, block2))
+	s.Require().Equal(1, s.gov.forkBlocks)
+	s.Require().Len(s.network.evidences, 2)
+	// Invalid evidences are not reported.
+	vote2.Period++
+	s.Require().NoError(offender.SignVote(vote2))
+	s.Require().Equal(ErrInvalidEvidence, p.AddForkVote(vote1, vote2))
+	s.Require().Equal(1, s.gov.forkVotes)
+	// Evidences are saved and marked submitted.
+	records, err := s.db.GetAllEvidences()
+	s.Require().NoError(err)
+	s.Require().Len(records, 2)
+	for _, record := range records {
+		s.Require().True(record.Submitted)
+	}
+}
+
+func (s *PoolTestSuite) TestProcessEvidence() {
+	p := s.newPool()
+	offender, reporter := s.newSigner(), s.newSigner()
+	vote1, vote2 := s.newForkVotes(offender)
+	evidence, err := NewForkVote(vote1, vote2)
+	s.Require().NoError(err)
+	s.Require().NoError(reporter.SignEvidence(evidence))
+	s.Require().NoError(p.ProcessEvidence(evidence))
+	s.Require().Equal(ErrEvidenceExists, p.ProcessEvidence(evidence))
+	// Evidences received are not gossiped or submitted by this node.
+	s.Require().Empty(s.network.evidences)
+	s.Require().Equal(0, s.gov.forkVotes)
+	// The same misbehaviour would not be reported again.
+	s.Require().Equal(ErrEvidenceExists, p.AddForkVote(vote1, vote2))
+	s.Require().Equal(0, s.gov.forkVotes)
+	// Invalid evidences are rejected.
+	evidence.Payload = evidence.Payload[1:]
+	s.Require().NoError(reporter.SignEvidence(evidence))
+	s.Require().Error(p.ProcessEvidence(evidence))
+}
+
+func (s *PoolTestSuite) TestResubmit() {
+	offender, reporter := s.newSigner(), s.newSigner()
+	// An evidence reported by this node but not submitted before crashed.
+	vote1, vote2 := s.newForkVotes(offender)
+	pending, err := NewForkVote(vote1, vote2)
+	s.Require().NoError(err)
+	s.Require().NoError(s.signer.SignEvidence(pending))
+	s.Require().NoError(s.db.PutOrUpdateEvidence(db.EvidenceRecord{
+		Hash:     utils.HashEvidence(pending),
+		Evidence: *pending,
+	}))
+	// An evidence received from others.
+	block1, block2 := s.newForkBlocks(offender)
+	received, err := NewForkBlock(block1, block2)
+	s.Require().NoError(err)
+	s.Require().NoError(reporter.SignEvidence(received))
+	s.Require().NoError(s.db.PutOrUpdateEvidence(db.EvidenceRecord{
+		Hash:     utils.HashEvidence(received),
+		Evidence: *received,
+	}))
+	p := s.newPool()
+	s.Require().Equal(1, s.gov.forkVotes)
+	s.Require().Equal(0, s.gov.forkBlocks)
+	s.Require().Len(s.network.evidences, 1)
+	s.Require().True(p.Has(utils.HashEvidence(received)))
+	record, err := s.db.GetEvidence(utils.HashEvidence(pending))
+	s.Require().NoError(err)
+	s.Require().True(record.Submitted)
+	// Nothing is submitted again.
+	s.newPool()
+	s.Require().Equal(1, s.gov.forkVotes)
+	s.Require().Len(s.network.evidences, 1)
+}
+
+func (s *PoolTestSuite) TestPrune() {
+	p := s.newPool()
+	offender, reporter := s.newSigner(), s.newSigner()
+	vote1, vote2 := s.newForkVotes(offender)
+	s.Require().NoError(p.AddForkVote(vote1, vote2))
+	hash := utils.HashEvidence(s.network.evidences[0])
+	// Evidences of round 1 are kept.
+	p.Prune(1)
+	s.Require().True(p.Has(hash))
+	_, err := s.db.GetEvidence(hash)
+	s.Require().NoError(err)
+	p.Prune(2)
+	s.Require().False(p.Has(hash))
+	_, err = s.db.GetEvidence(hash)
+	s.Require().Equal(db.ErrEvidenceDoesNotExist, err)
+	// Evidences of pruned rounds are treated as existing.
+	s.Require().Equal(ErrEvidenceExists, p.AddForkVote(vote1, vote2))
+	block1, block2 := s.newForkBlocks(offender)
+	evidence, err := NewForkBlock(block1, block2)
+	s.Require().NoError(err)
+	s.Require().NoError(reporter.SignEvidence(evidence))
+	s.Require().Equal(ErrEvidenceExists, p.ProcessEvidence(evidence))
+	records, err := s.db.GetAllEvidences()
+	s.Require().NoError(err)
+	s.Require().Empty(records)
+	s.Require().Equal(1, s.gov.forkVotes)
+	s.Require().Equal(0, s.gov.forkBlocks)
+}
+
+func (s *PoolTestSuite) TestWithoutNetwork() {
+	p, err := NewPool(s.nID, s.signer, s.db, s.gov, nil,
+		&common.NullLogger{})
+	s.Require().NoError(err)
+	vote1, vote2 := s.newForkVotes(s.newSigner())
+	s.Require().NoError(p.AddForkVote(vote1, vote2))
+	s.Require().Equal(1, s.gov.forkVotes)
+}
+
+func TestPool(t *testing.T) {
+	suite.Run(t, new(PoolTestSuite))
+}
@@ -0,0 +1,87 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sort"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/db"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+)
+
+// NewFinalityProof produces the finality proof of a block delivered to the
+// db, it works without a running Consensus instance.
+func NewFinalityProof(dbInst db.Database, gov Governance, hash common.Hash) (
+	*types.FinalityProof, error) {
+	return newFinalityProof(dbInst, utils.NewNodeSetCache(gov),
+		NewTSigVerifierCache(gov, 1), hash)
+}
+
+// FinalityProof produces the finality proof of a delivered block.
+func (con *Consensus) FinalityProof(hash common.Hash) (
+	*types.FinalityProof, error) {
+	return newFinalityProof(con.db, con.nodeSetCache, con.tsigVerifierCache,
+		hash)
+}
+
+func newFinalityProof(dbInst db.Database, nodeSetCache *utils.NodeSetCache,
+	tsigVerifierCache *TSigVerifierCache, hash common.Hash) (
+	*types.FinalityProof, error) {
+	b, err := dbInst.GetBlock(hash)
+	if err != nil {
+		return nil, err
+	}
+	_, tipHeight := dbInst.GetCompactionChainTipInfo()
+	if !b.IsFinalized() || b.Position.Height > tipHeight {
+		return nil, ErrBlockNotFinalized
+	}
+	if b.Position.Round < DKGDelayRound {
+		return nil, utils.ErrNoFinalityProof
+	}
+	v, ok, err := tsigVerifierCache.UpdateAndGet(b.Position.Round)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrTSigVerifierNotReady
+	}
+	gpk, ok := v.(*typesDKG.GroupPublicKey)
+	if !ok {
+		return nil, ErrTSigVerifierNotReady
+	}
+	notarySet, err := nodeSetCache.GetNotarySet(b.Position.Round)
+	if err != nil {
+		return nil, err
+	}
+	proof := &types.FinalityProof{
+		Header:         b,
+		GroupPublicKey: gpk.GroupPublicKey.Bytes(),
+		NotarySet:      make(types.NodeIDs, 0, len(notarySet)),
+	}
+	proof.Header.Payload = nil
+	for nID := range notarySet {
+		proof.NotarySet = append(proof.NotarySet, nID)
+	}
+	sort.Sort(proof.NotarySet)
+	proof.NodeSetCommitment = utils.HashNodeSetCommitment(b.Position.Round,
+		proof.NotarySet, proof.GroupPublicKey)
+	return proof, nil
+}
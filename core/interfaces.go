@@ -89,9 +89,30 @@ type Network interface {
 	ReportBadPeerChan() chan<- interface{}
 }
 
+// BadPeerReporter describes the network interface that receives reasons of
+// bad peers, a Network could optionally implement it. Bad peers are reported
+// through it instead of Network.ReportBadPeerChan when implemented.
+type BadPeerReporter interface {
+	// BadPeerReportChan returns a channel to report bad peer with the reason.
+	BadPeerReportChan() chan<- *types.BadPeerReport
+}
+
+// EvidenceBroadcaster describes the network interface that gossips evidences
+// of misbehaviour, a Network could optionally implement it. Evidences are
+// still submitted to governance when the network doesn't implement it.
+type EvidenceBroadcaster interface {
+	// BroadcastEvidence broadcasts evidence of misbehaviour to all nodes in
+	// DEXON network.
+	BroadcastEvidence(evidence *types.Evidence)
+}
+
 // Governance interface specifies interface to control the governance contract.
 // Note that there are a lot more methods in the governance contract, that this
 // interface only define those that are required to run the consensus algorithm.
+//
+// A Governance could optionally provide weights of nodes by implementing
+// "NodeWeights(round uint64) map[types.NodeID]uint64", notary sets would be
+// sampled by these weights and BA votes would be counted by weights.
 type Governance interface {
 	// Configuration returns the configuration at a given round.
 	// Return the genesis configuration if round == 0.
@@ -110,6 +131,9 @@ type Governance interface {
 
 	// NodeSet returns the node set at a given round.
 	// Return the genesis node set if round == 0.
+	//
+	// Nodes might leave, the node set of a round could be smaller than the
+	// one of its previous round.
 	NodeSet(round uint64) []crypto.PublicKey
 
 	// Get the begin height of a round.
@@ -180,3 +204,17 @@ type Recovery interface {
 	// Votes gets the number of votes of given height.
 	Votes(height uint64) (uint64, error)
 }
+
+// Clock provides the notion of time to consensus core, which makes it possible
+// to drive consensus by a virtual clock in tests.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// After waits for the duration to elapse and then sends the current time
+	// on the returned channel.
+	After(d time.Duration) <-chan time.Time
+
+	// Sleep pauses the current goroutine for at least the duration.
+	Sleep(d time.Duration)
+}
@@ -18,7 +18,9 @@
 package core
 
 import (
+	"encoding/binary"
 	"math/big"
+	"sort"
 	"sync"
 
 	"github.com/dexon-foundation/dexon-consensus/common"
@@ -28,6 +30,148 @@ import (
 
 type validLeaderFn func(block *types.Block, crs common.Hash) (bool, error)
 
+// LeaderSelector decides the leader of each position in BA. The leader node is
+// the one expected to propose in fast path, and the valid block with the lowest
+// rank is selected as the leader block. All nodes in the network should use
+// the same LeaderSelector.
+type LeaderSelector interface {
+	// Leader returns the leader node of a position among the notary set.
+	Leader(notarySet map[types.NodeID]struct{}, crs common.Hash,
+		pos types.Position) (types.NodeID, error)
+
+	// Rank returns the rank of a block, which should be less than 2^256-1.
+	// The block is never selected as the leader block when it's nil.
+	Rank(notarySet map[types.NodeID]struct{}, crs common.Hash,
+		block *types.Block) *big.Int
+}
+
+// CRSDistanceLeaderSelector selects the leader node by hashing the CRS with
+// the height, and ranks blocks by the distance between the CRS and the hash of
+// their CRS signatures.
+type CRSDistanceLeaderSelector struct{}
+
+// Leader implements LeaderSelector interface.
+func (CRSDistanceLeaderSelector) Leader(notarySet map[types.NodeID]struct{},
+	crs common.Hash, pos types.Position) (types.NodeID, error) {
+	nodeSet := types.NewNodeSetFromMap(notarySet)
+	leader := nodeSet.GetSubSet(1, types.NewNodeLeaderTarget(
+		crs, pos.Height))
+	for nID := range leader {
+		return nID, nil
+	}
+	return types.NodeID{}, ErrNoValidLeader
+}
+
+// Rank implements LeaderSelector interface.
+func (CRSDistanceLeaderSelector) Rank(_ map[types.NodeID]struct{},
+	crs common.Hash, block *types.Block) *big.Int {
+	return crsDistance(crs, block.CRSSignature)
+}
+
+// RoundRobinLeaderSelector takes turns to be the leader node in the order of
+// sorted notary set, blocks are ranked by how far their proposers are from the
+// leader in that order. It's predictable and meant for test networks only.
+type RoundRobinLeaderSelector struct{}
+
+// Leader implements LeaderSelector interface.
+func (RoundRobinLeaderSelector) Leader(notarySet map[types.NodeID]struct{},
+	_ common.Hash, pos types.Position) (types.NodeID, error) {
+	if len(notarySet) == 0 {
+		return types.NodeID{}, ErrNoValidLeader
+	}
+	nIDs := sortedNodeIDs(notarySet)
+	return nIDs[pos.Height%uint64(len(nIDs))], nil
+}
+
+// Rank implements LeaderSelector interface.
+func (RoundRobinLeaderSelector) Rank(notarySet map[types.NodeID]struct{},
+	_ common.Hash, block *types.Block) *big.Int {
+	if _, exist := notarySet[block.ProposerID]; !exist {
+		return nil
+	}
+	nIDs := sortedNodeIDs(notarySet)
+	idx := sort.Search(len(nIDs), func(i int) bool {
+		return !nIDs[i].Hash.Less(block.ProposerID.Hash)
+	})
+	n := uint64(len(nIDs))
+	offset := (uint64(idx) + n - block.Position.Height%n) % n
+	return new(big.Int).SetUint64(offset)
+}
+
+// StakesFn returns the stake of each node in a round.
+type StakesFn func(round uint64) map[types.NodeID]uint64
+
+// StakeWeightedLeaderSelector favors nodes with more stake. The leader node is
+// sampled with probability proportional to its stake, and blocks are ranked
+// by the CRS distance divided by the stake of proposers.
+type StakeWeightedLeaderSelector struct {
+	stakes StakesFn
+}
+
+// NewStakeWeightedLeaderSelector constructs a StakeWeightedLeaderSelector
+// instance, stakes returns the stake of each node in a round.
+func NewStakeWeightedLeaderSelector(
+	stakes StakesFn) *StakeWeightedLeaderSelector {
+	return &StakeWeightedLeaderSelector{stakes: stakes}
+}
+
+// Leader implements LeaderSelector interface.
+func (l *StakeWeightedLeaderSelector) Leader(
+	notarySet map[types.NodeID]struct{}, crs common.Hash,
+	pos types.Position) (types.NodeID, error) {
+	stakes := l.stakes(pos.Round)
+	total := big.NewInt(0)
+	nIDs := sortedNodeIDs(notarySet)
+	for _, nID := range nIDs {
+		total.Add(total, new(big.Int).SetUint64(stakes[nID]))
+	}
+	if total.Sign() == 0 {
+		return types.NodeID{}, ErrNoValidLeader
+	}
+	binaryHeight := make([]byte, 8)
+	binary.LittleEndian.PutUint64(binaryHeight, pos.Height)
+	hash := crypto.Keccak256Hash(crs[:], binaryHeight)
+	target := new(big.Int).SetBytes(hash[:])
+	target.Mod(target, total)
+	for _, nID := range nIDs {
+		target.Sub(target, new(big.Int).SetUint64(stakes[nID]))
+		if target.Sign() < 0 {
+			return nID, nil
+		}
+	}
+	// Should not happen.
+	return types.NodeID{}, ErrNoValidLeader
+}
+
+// Rank implements LeaderSelector interface.
+func (l *StakeWeightedLeaderSelector) Rank(_ map[types.NodeID]struct{},
+	crs common.Hash, block *types.Block) *big.Int {
+	stake := l.stakes(block.Position.Round)[block.ProposerID]
+	if stake == 0 {
+		return nil
+	}
+	dist := crsDistance(crs, block.CRSSignature)
+	return dist.Div(dist, new(big.Int).SetUint64(stake))
+}
+
+func sortedNodeIDs(nodeSet map[types.NodeID]struct{}) types.NodeIDs {
+	nIDs := make(types.NodeIDs, 0, len(nodeSet))
+	for nID := range nodeSet {
+		nIDs = append(nIDs, nID)
+	}
+	sort.Sort(nIDs)
+	return nIDs
+}
+
+func crsDistance(crs common.Hash, sig crypto.Signature) *big.Int {
+	numCRS := big.NewInt(0)
+	numCRS.SetBytes(crs[:])
+	hash := crypto.Keccak256Hash(sig.Signature[:])
+	num := big.NewInt(0)
+	num.SetBytes(hash[:])
+	return num.Abs(num.Sub(numCRS, num))
+}
+
 // Some constant value.
 var (
 	maxHash *big.Int
@@ -45,11 +189,12 @@ func init() {
 
 type leaderSelector struct {
 	hashCRS       common.Hash
-	numCRS        *big.Int
+	notarySet     map[types.NodeID]struct{}
 	minCRSBlock   *big.Int
 	minBlockHash  common.Hash
 	pendingBlocks map[common.Hash]*types.Block
 	validLeader   validLeaderFn
+	selector      LeaderSelector
 	lock          sync.Mutex
 	logger        common.Logger
 }
@@ -59,16 +204,13 @@ func newLeaderSelector(
 	return &leaderSelector{
 		minCRSBlock: maxHash,
 		validLeader: validLeader,
+		selector:    CRSDistanceLeaderSelector{},
 		logger:      logger,
 	}
 }
 
 func (l *leaderSelector) distance(sig crypto.Signature) *big.Int {
-	hash := crypto.Keccak256Hash(sig.Signature[:])
-	num := big.NewInt(0)
-	num.SetBytes(hash[:])
-	num.Abs(num.Sub(l.numCRS, num))
-	return num
+	return crsDistance(l.hashCRS, sig)
 }
 
 func (l *leaderSelector) probability(sig crypto.Signature) float64 {
@@ -78,13 +220,12 @@ func (l *leaderSelector) probability(sig crypto.Signature) float64 {
 	return p
 }
 
-func (l *leaderSelector) restart(crs common.Hash) {
-	numCRS := big.NewInt(0)
-	numCRS.SetBytes(crs[:])
+func (l *leaderSelector) restart(
+	crs common.Hash, notarySet map[types.NodeID]struct{}) {
 	l.lock.Lock()
 	defer l.lock.Unlock()
-	l.numCRS = numCRS
 	l.hashCRS = crs
+	l.notarySet = notarySet
 	l.minCRSBlock = maxHash
 	l.minBlockHash = types.NullBlockHash
 	l.pendingBlocks = make(map[common.Hash]*types.Block)
@@ -132,7 +273,10 @@ func (l *leaderSelector) processBlock(block *types.Block) error {
 }
 
 func (l *leaderSelector) potentialLeader(block *types.Block) (bool, *big.Int) {
-	dist := l.distance(block.CRSSignature)
+	dist := l.selector.Rank(l.notarySet, l.hashCRS, block)
+	if dist == nil {
+		return false, nil
+	}
 	cmp := l.minCRSBlock.Cmp(dist)
 	return (cmp > 0 || (cmp == 0 && block.Hash.Less(l.minBlockHash))), dist
 }
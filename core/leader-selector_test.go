@@ -48,7 +48,7 @@ func (s *LeaderSelectorTestSuite) SetupTest() {
 
 func (s *LeaderSelectorTestSuite) newLeader() *leaderSelector {
 	l := newLeaderSelector(s.mockValidLeader, &common.NullLogger{})
-	l.restart(common.NewRandomHash())
+	l.restart(common.NewRandomHash(), nil)
 	return l
 }
 
@@ -141,7 +141,7 @@ func (s *LeaderSelectorTestSuite) TestValidLeaderFn() {
 	blockHash := leader.leaderBlockHash()
 
 	s.mockValidLeaderDB[blockHash] = false
-	leader.restart(leader.hashCRS)
+	leader.restart(leader.hashCRS, nil)
 	for _, b := range blocks {
 		s.Require().NoError(leader.processBlock(b))
 	}
@@ -179,6 +179,106 @@ func (s *LeaderSelectorTestSuite) TestPotentialLeader() {
 	}
 }
 
+func (s *LeaderSelectorTestSuite) newNotarySet(count int) (
+	map[types.NodeID]*utils.Signer, map[types.NodeID]struct{}) {
+	signers := make(map[types.NodeID]*utils.Signer, count)
+	notarySet := make(map[types.NodeID]struct{}, count)
+	for i := 0; i < count; i++ {
+		prv, err := ecdsa.NewPrivateKey()
+		s.Require().NoError(err)
+		nID := types.NewNodeID(prv.PublicKey())
+		signers[nID] = utils.NewSigner(prv)
+		notarySet[nID] = struct{}{}
+	}
+	return signers, notarySet
+}
+
+func (s *LeaderSelectorTestSuite) TestCRSDistanceLeader() {
+	_, notarySet := s.newNotarySet(7)
+	crs := common.NewRandomHash()
+	pos := types.Position{Round: 1, Height: 10}
+	leader, err := CRSDistanceLeaderSelector{}.Leader(notarySet, crs, pos)
+	s.Require().NoError(err)
+	s.Require().Equal(types.NewNodeSetFromMap(notarySet).GetSubSet(
+		1, types.NewNodeLeaderTarget(crs, pos.Height)),
+		map[types.NodeID]struct{}{leader: struct{}{}})
+	_, err = CRSDistanceLeaderSelector{}.Leader(nil, crs, pos)
+	s.Require().Equal(ErrNoValidLeader, err)
+}
+
+func (s *LeaderSelectorTestSuite) TestRoundRobin() {
+	signers, notarySet := s.newNotarySet(4)
+	nIDs := sortedNodeIDs(notarySet)
+	selector := RoundRobinLeaderSelector{}
+	crs := common.NewRandomHash()
+	for h := uint64(0); h < 8; h++ {
+		leader, err := selector.Leader(
+			notarySet, crs, types.Position{Height: h})
+		s.Require().NoError(err)
+		s.Require().Equal(nIDs[h%4], leader)
+	}
+	// The block from the leader is selected.
+	l := newLeaderSelector(s.mockValidLeader, &common.NullLogger{})
+	l.selector = selector
+	l.restart(crs, notarySet)
+	pos := types.Position{Height: 6}
+	leader, err := selector.Leader(notarySet, crs, pos)
+	s.Require().NoError(err)
+	var leaderBlock common.Hash
+	for nID, signer := range signers {
+		b := &types.Block{
+			ProposerID: nID,
+			Position:   pos,
+			Hash:       common.NewRandomHash(),
+		}
+		s.Require().NoError(signer.SignCRS(b, crs))
+		if nID == leader {
+			leaderBlock = b.Hash
+			s.Require().Equal(int64(0), selector.Rank(notarySet, crs, b).Int64())
+		}
+		s.Require().NoError(l.processBlock(b))
+	}
+	s.Require().Equal(leaderBlock, l.leaderBlockHash())
+	// Blocks from nodes outside the notary set are never selected.
+	b := &types.Block{ProposerID: types.NodeID{Hash: common.NewRandomHash()}}
+	s.Require().Nil(selector.Rank(notarySet, crs, b))
+}
+
+func (s *LeaderSelectorTestSuite) TestStakeWeighted() {
+	_, notarySet := s.newNotarySet(4)
+	nIDs := sortedNodeIDs(notarySet)
+	// Only the first node has stake.
+	stakes := map[types.NodeID]uint64{nIDs[0]: 100}
+	selector := NewStakeWeightedLeaderSelector(
+		func(uint64) map[types.NodeID]uint64 { return stakes })
+	for h := uint64(0); h < 10; h++ {
+		leader, err := selector.Leader(
+			notarySet, common.NewRandomHash(), types.Position{Height: h})
+		s.Require().NoError(err)
+		s.Require().Equal(nIDs[0], leader)
+	}
+	b := &types.Block{ProposerID: nIDs[1]}
+	s.Require().Nil(selector.Rank(notarySet, common.NewRandomHash(), b))
+	// Nodes with more stake are selected more often.
+	stakes = map[types.NodeID]uint64{
+		nIDs[0]: 1, nIDs[1]: 1, nIDs[2]: 1, nIDs[3]: 7}
+	counts := make(map[types.NodeID]int)
+	for h := uint64(0); h < 1000; h++ {
+		leader, err := selector.Leader(
+			notarySet, common.NewRandomHash(), types.Position{Height: h})
+		s.Require().NoError(err)
+		counts[leader]++
+	}
+	for _, nID := range nIDs[:3] {
+		s.Require().True(counts[nIDs[3]] > counts[nID]*3)
+	}
+	// Leader is not available without stakes.
+	stakes = nil
+	_, err := selector.Leader(
+		notarySet, common.NewRandomHash(), types.Position{})
+	s.Require().Equal(ErrNoValidLeader, err)
+}
+
 func TestLeaderSelector(t *testing.T) {
 	suite.Run(t, new(LeaderSelectorTestSuite))
 }
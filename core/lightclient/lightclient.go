@@ -0,0 +1,436 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package lightclient verifies blocks delivered by DEXON consensus without
+// running a full node, it tracks CRS, node sets and DKG results of each round
+// from a trusted genesis round. Information of each round is trusted only when
+// it's signed by the notary set of the previous round.
+package lightclient
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sync"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+)
+
+var (
+	// ErrRoundNotReady is reported when information of the round is not
+	// added yet.
+	ErrRoundNotReady = errors.New("round is not ready")
+	// ErrInvalidRoundOrder is reported when rounds are not added one by one.
+	ErrInvalidRoundOrder = errors.New("invalid round order")
+	// ErrInvalidRoundInfo is reported when configuration or CRS is missing.
+	ErrInvalidRoundInfo = errors.New("invalid round info")
+	// ErrIncorrectCRS is reported when the CRS is not derived from the
+	// previous round.
+	ErrIncorrectCRS = errors.New("incorrect CRS")
+	// ErrRoundInfoNotSigned is reported when the round information is not
+	// signed by enough nodes in the notary set of the previous round.
+	ErrRoundInfoNotSigned = errors.New("round info not signed")
+	// ErrInvalidMasterPublicKey is reported when a DKG master public key is
+	// not proposed by the notary set of the round.
+	ErrInvalidMasterPublicKey = errors.New("invalid DKG master public key")
+	// ErrInvalidComplaint is reported when a DKG complaint is not proposed
+	// by the notary set of the round.
+	ErrInvalidComplaint = errors.New("invalid DKG complaint")
+	// ErrDKGNotValid is reported when qualified nodes of DKG are not enough.
+	ErrDKGNotValid = errors.New("DKG is not valid")
+	// ErrNotInNotarySet is reported when the proposer of a block is not in
+	// the notary set.
+	ErrNotInNotarySet = errors.New("proposer not in notary set")
+	// ErrIncorrectCRSSignature is reported when the CRS signature of a block
+	// is incorrect.
+	ErrIncorrectCRSSignature = errors.New("incorrect CRS signature")
+	// ErrIncorrectBlockRandomness is reported when the randomness of a block
+	// is not signed by DKG of the round.
+	ErrIncorrectBlockRandomness = errors.New("incorrect block randomness")
+	// ErrInvalidHeaderOrder is reported when headers are not following the
+	// tip.
+	ErrInvalidHeaderOrder = errors.New("invalid header order")
+)
+
+// RoundInfo is the information of a round to verify blocks in it. Except for
+// the genesis round, it's bound to the previous round by signatures from its
+// notary set, and the CRS is also verified against the previous round.
+type RoundInfo struct {
+	Round uint64
+	// Reset is the count of DKG resets of this round.
+	Reset   uint64
+	Config  *types.Config
+	NodeSet []crypto.PublicKey
+	CRS     common.Hash
+	// CRSSignature is the threshold signature by DKG of the previous round
+	// which CRS is hashed from, it's not used before DKGDelayRound.
+	CRSSignature []byte
+	// DKG results of this round, they're not used before DKGDelayRound.
+	MasterPublicKeys []*typesDKG.MasterPublicKey
+	Complaints       []*typesDKG.Complaint
+	// Signatures are signed on HashRoundInfo of this round by nodes in the
+	// notary set of the previous round, at least the BA threshold of them are
+	// required.
+	Signatures []crypto.Signature
+}
+
+// HashRoundInfo returns the hash signed by the notary set of the previous
+// round, it covers everything in the round information except signatures.
+// DKG messages are covered by their signatures, which are verified against
+// their content.
+func HashRoundInfo(info *RoundInfo) common.Hash {
+	encodeUint64 := func(v uint64) []byte {
+		b := make([]byte, 8)
+		binary.LittleEndian.PutUint64(b, v)
+		return b
+	}
+	data := [][]byte{
+		encodeUint64(info.Round),
+		encodeUint64(info.Reset),
+		info.CRS[:],
+		encodeUint64(uint64(len(info.CRSSignature))),
+		info.CRSSignature,
+	}
+	if info.Config != nil {
+		data = append(data, info.Config.Bytes())
+	}
+	data = append(data, encodeUint64(uint64(len(info.NodeSet))))
+	for _, key := range info.NodeSet {
+		data = append(data, key.Bytes())
+	}
+	data = append(data, encodeUint64(uint64(len(info.MasterPublicKeys))))
+	for _, mpk := range info.MasterPublicKeys {
+		data = append(data, mpk.Signature.Signature)
+	}
+	data = append(data, encodeUint64(uint64(len(info.Complaints))))
+	for _, complaint := range info.Complaints {
+		data = append(data, complaint.Signature.Signature)
+	}
+	return crypto.Keccak256Hash(data...)
+}
+
+type round struct {
+	info      *RoundInfo
+	notarySet map[types.NodeID]struct{}
+	gpk       *typesDKG.GroupPublicKey
+	npks      *typesDKG.NodePublicKeys
+}
+
+func newRound(info *RoundInfo) (*round, error) {
+	if info.Config == nil || (info.CRS == common.Hash{}) {
+		return nil, ErrInvalidRoundInfo
+	}
+	nodeSet := types.NewNodeSet()
+	for _, key := range info.NodeSet {
+		nodeSet.Add(types.NewNodeID(key))
+	}
+	r := &round{
+		info: info,
+		notarySet: nodeSet.GetSubSet(int(info.Config.NotarySetSize),
+			types.NewNotarySetTarget(info.CRS)),
+	}
+	if info.Round < core.DKGDelayRound {
+		return r, nil
+	}
+	for _, mpk := range info.MasterPublicKeys {
+		if mpk.Round != info.Round || mpk.Reset != info.Reset {
+			return nil, ErrInvalidMasterPublicKey
+		}
+		if _, exist := r.notarySet[mpk.ProposerID]; !exist {
+			return nil, ErrInvalidMasterPublicKey
+		}
+		ok, err := utils.VerifyDKGMasterPublicKeySignature(mpk)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrInvalidMasterPublicKey
+		}
+	}
+	for _, complaint := range info.Complaints {
+		if complaint.Round != info.Round || complaint.Reset != info.Reset {
+			return nil, ErrInvalidComplaint
+		}
+		if _, exist := r.notarySet[complaint.ProposerID]; !exist {
+			return nil, ErrInvalidComplaint
+		}
+		ok, err := utils.VerifyDKGComplaintSignature(complaint)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrInvalidComplaint
+		}
+	}
+	threshold := utils.GetDKGThreshold(info.Config)
+	var err error
+	if r.gpk, err = typesDKG.NewGroupPublicKey(info.Round,
+		info.MasterPublicKeys, info.Complaints, threshold); err != nil {
+		return nil, err
+	}
+	if len(r.gpk.QualifyNodeIDs) < utils.GetDKGValidThreshold(info.Config) {
+		return nil, ErrDKGNotValid
+	}
+	if r.npks, err = typesDKG.NewNodePublicKeys(info.Round,
+		info.MasterPublicKeys, info.Complaints, threshold); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// verifyNextCRS checks if the CRS of the next round is derived from this
+// round. CRS before DKGDelayRound are chained by hashing, and the later ones
+// are hashed from the threshold signature on the CRS of this round.
+func (r *round) verifyNextCRS(info *RoundInfo) error {
+	if info.Round <= core.DKGDelayRound {
+		if info.CRS != crypto.Keccak256Hash(r.info.CRS[:]) {
+			return ErrIncorrectCRS
+		}
+		return nil
+	}
+	if crypto.Keccak256Hash(info.CRSSignature) != info.CRS {
+		return ErrIncorrectCRS
+	}
+	// The CRS is rehashed before signing when DKG of next round is reset.
+	hash := utils.Rehash(r.info.CRS, uint(info.Reset))
+	if !r.gpk.VerifySignature(hash, crypto.Signature{
+		Type:      "bls",
+		Signature: info.CRSSignature,
+	}) {
+		return ErrIncorrectCRS
+	}
+	return nil
+}
+
+// verifyNextRoundInfo checks if the information of the next round is signed
+// by enough nodes in the notary set of this round.
+func (r *round) verifyNextRoundInfo(info *RoundInfo) error {
+	hash := HashRoundInfo(info)
+	signers := make(map[types.NodeID]struct{})
+	for _, sig := range info.Signatures {
+		pubKey, err := crypto.SigToPub(hash, sig)
+		if err != nil {
+			return ErrRoundInfoNotSigned
+		}
+		nID := types.NewNodeID(pubKey)
+		if _, exist := r.notarySet[nID]; exist {
+			signers[nID] = struct{}{}
+		}
+	}
+	if len(signers) < utils.GetBAThreshold(r.info.Config) {
+		return ErrRoundInfoNotSigned
+	}
+	return nil
+}
+
+func (r *round) verifyRandomness(hash common.Hash, randomness []byte) bool {
+	if r.info.Round < core.DKGDelayRound {
+		return bytes.Equal(randomness, core.NoRand)
+	}
+	return r.gpk.VerifySignature(hash, crypto.Signature{
+		Type:      "bls",
+		Signature: randomness,
+	})
+}
+
+// Client verifies blocks and follows the compaction chain by headers, which
+// are blocks without payloads.
+type Client struct {
+	lock   sync.RWMutex
+	rounds []*round
+	tip    *types.Block
+	logger common.Logger
+}
+
+// NewClient constructs a Client instance from the trusted genesis round.
+func NewClient(genesis *RoundInfo, logger common.Logger) (*Client, error) {
+	if genesis.Round != 0 {
+		return nil, ErrInvalidRoundOrder
+	}
+	r, err := newRound(genesis)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		rounds: []*round{r},
+		logger: logger,
+	}, nil
+}
+
+// AddRound verifies and tracks information of the round following the last
+// one added. The information should be signed by the notary set of the last
+// round.
+func (c *Client) AddRound(info *RoundInfo) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if info.Round != uint64(len(c.rounds)) {
+		return ErrInvalidRoundOrder
+	}
+	last := c.rounds[len(c.rounds)-1]
+	if err := last.verifyNextRoundInfo(info); err != nil {
+		return err
+	}
+	if err := last.verifyNextCRS(info); err != nil {
+		return err
+	}
+	r, err := newRound(info)
+	if err != nil {
+		return err
+	}
+	c.rounds = append(c.rounds, r)
+	c.logger.Info("Round added", "round", info.Round, "crs", info.CRS)
+	return nil
+}
+
+// LastRound returns the last round added.
+func (c *Client) LastRound() uint64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return uint64(len(c.rounds) - 1)
+}
+
+// NotarySet returns the notary set of a round.
+func (c *Client) NotarySet(round uint64) (map[types.NodeID]struct{}, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if round >= uint64(len(c.rounds)) {
+		return nil, ErrRoundNotReady
+	}
+	notarySet := make(map[types.NodeID]struct{})
+	for nID := range c.rounds[round].notarySet {
+		notarySet[nID] = struct{}{}
+	}
+	return notarySet, nil
+}
+
+// NodeSetCommitment returns the node set commitment of a round, it's trusted
+// to verify finality proofs by utils.VerifyFinalityProof.
+func (c *Client) NodeSetCommitment(round uint64) (common.Hash, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if round >= uint64(len(c.rounds)) {
+		return common.Hash{}, ErrRoundNotReady
+	}
+	if round < core.DKGDelayRound {
+		return common.Hash{}, utils.ErrNoFinalityProof
+	}
+	r := c.rounds[round]
+	notarySet := make(types.NodeIDs, 0, len(r.notarySet))
+	for nID := range r.notarySet {
+		notarySet = append(notarySet, nID)
+	}
+	return utils.HashNodeSetCommitment(round, notarySet,
+		r.gpk.GroupPublicKey.Bytes()), nil
+}
+
+// VerifyBlock verifies a finalized block including its payload.
+func (c *Client) VerifyBlock(b *types.Block) error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.verify(b, true)
+}
+
+// VerifyHeader verifies a finalized block without its payload.
+func (c *Client) VerifyHeader(b *types.Block) error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.verify(b, false)
+}
+
+func (c *Client) verify(b *types.Block, withPayload bool) error {
+	if b.Position.Round >= uint64(len(c.rounds)) {
+		return ErrRoundNotReady
+	}
+	r := c.rounds[b.Position.Round]
+	if b.IsEmpty() {
+		// Empty blocks are neither signed nor carry payloads.
+		hash, err := utils.HashBlock(b)
+		if err != nil {
+			return err
+		}
+		if hash != b.Hash {
+			return utils.ErrIncorrectHash
+		}
+	} else {
+		if _, exist := r.notarySet[b.ProposerID]; !exist {
+			return ErrNotInNotarySet
+		}
+		var err error
+		if withPayload {
+			err = utils.VerifyBlockSignature(b)
+		} else {
+			err = utils.VerifyBlockSignatureWithoutPayload(b)
+		}
+		if err != nil {
+			return err
+		}
+		if !utils.VerifyCRSSignature(b, r.info.CRS, r.npks) {
+			return ErrIncorrectCRSSignature
+		}
+	}
+	if !r.verifyRandomness(b.Hash, b.Randomness) {
+		return ErrIncorrectBlockRandomness
+	}
+	return nil
+}
+
+// SyncHeaders verifies headers following the tip in order and moves the tip
+// to the last one. Headers before the first invalid one are still synced when
+// an error is returned.
+func (c *Client) SyncHeaders(headers ...*types.Block) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, h := range headers {
+		if c.tip == nil {
+			if h.Position.Round != 0 ||
+				h.Position.Height != types.GenesisHeight {
+				return ErrInvalidHeaderOrder
+			}
+		} else {
+			if h.Position.Height != c.tip.Position.Height+1 ||
+				h.ParentHash != c.tip.Hash {
+				return ErrInvalidHeaderOrder
+			}
+			// Rounds are advanced one by one.
+			if h.Position.Round < c.tip.Position.Round ||
+				h.Position.Round > c.tip.Position.Round+1 {
+				return ErrInvalidHeaderOrder
+			}
+		}
+		if err := c.verify(h, false); err != nil {
+			return err
+		}
+		c.tip = h.Clone()
+		c.tip.Payload = nil
+	}
+	return nil
+}
+
+// Tip returns the last synced header, nil is returned when nothing is synced.
+func (c *Client) Tip() *types.Block {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if c.tip == nil {
+		return nil
+	}
+	return c.tip.Clone()
+}
@@ -0,0 +1,440 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package lightclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	cryptoDKG "github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
+	"github.com/dexon-foundation/dexon-consensus/core/test"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+)
+
+type LightClientTestSuite struct {
+	suite.Suite
+
+	prvKeys []crypto.PrivateKey
+	pubKeys []crypto.PublicKey
+	signers map[types.NodeID]*utils.Signer
+	config  *types.Config
+	// Private key shares recovered in DKG of each round.
+	shares map[uint64]map[types.NodeID]*cryptoDKG.PrivateKey
+}
+
+func (s *LightClientTestSuite) SetupTest() {
+	prvKeys, pubKeys, err := test.NewKeys(4)
+	s.Require().NoError(err)
+	s.prvKeys = prvKeys
+	s.pubKeys = pubKeys
+	s.signers = make(map[types.NodeID]*utils.Signer)
+	for _, prvKey := range prvKeys {
+		s.signers[types.NewNodeID(prvKey.PublicKey())] =
+			utils.NewSigner(prvKey)
+	}
+	s.config = &types.Config{
+		LambdaBA:         100 * time.Millisecond,
+		LambdaDKG:        time.Second,
+		NotarySetSize:    uint32(len(prvKeys)),
+		RoundLength:      100,
+		MinBlockInterval: time.Millisecond,
+	}
+	s.shares = make(map[uint64]map[types.NodeID]*cryptoDKG.PrivateKey)
+}
+
+// runDKG runs DKG among all nodes without network.
+func (s *LightClientTestSuite) runDKG(
+	round, reset uint64) []*typesDKG.MasterPublicKey {
+	IDs := make(cryptoDKG.IDs, 0, len(s.signers))
+	for nID := range s.signers {
+		IDs = append(IDs, typesDKG.NewID(nID))
+	}
+	received := make(map[types.NodeID]*cryptoDKG.PrivateKeyShares)
+	for nID := range s.signers {
+		received[nID] = cryptoDKG.NewEmptyPrivateKeyShares()
+	}
+	mpks := make([]*typesDKG.MasterPublicKey, 0, len(s.signers))
+	for nID, signer := range s.signers {
+		prvShares, pubShares := cryptoDKG.NewPrivateKeyShares(
+			utils.GetDKGThreshold(s.config))
+		prvShares.SetParticipants(IDs)
+		mpk := &typesDKG.MasterPublicKey{
+			Round:           round,
+			Reset:           reset,
+			DKGID:           typesDKG.NewID(nID),
+			PublicKeyShares: *pubShares.Move(),
+		}
+		s.Require().NoError(signer.SignDKGMasterPublicKey(mpk))
+		mpks = append(mpks, mpk)
+		for receiver := range s.signers {
+			share, exist := prvShares.Share(typesDKG.NewID(receiver))
+			s.Require().True(exist)
+			s.Require().NoError(
+				received[receiver].AddShare(typesDKG.NewID(nID), share))
+		}
+	}
+	shares := make(map[types.NodeID]*cryptoDKG.PrivateKey)
+	for nID, prvShares := range received {
+		share, err := prvShares.RecoverPrivateKey(IDs)
+		s.Require().NoError(err)
+		shares[nID] = share
+	}
+	s.shares[round] = shares
+	return mpks
+}
+
+// tsign signs a hash by threshold signature of DKG in a round.
+func (s *LightClientTestSuite) tsign(round uint64, hash common.Hash) []byte {
+	threshold := utils.GetDKGThreshold(s.config)
+	IDs := make(cryptoDKG.IDs, 0, threshold)
+	psigs := make([]cryptoDKG.PartialSignature, 0, threshold)
+	for nID, share := range s.shares[round] {
+		if len(IDs) == threshold {
+			break
+		}
+		sig, err := share.Sign(hash)
+		s.Require().NoError(err)
+		IDs = append(IDs, typesDKG.NewID(nID))
+		psigs = append(psigs, cryptoDKG.PartialSignature(sig))
+	}
+	sig, err := cryptoDKG.RecoverSignature(psigs, IDs)
+	s.Require().NoError(err)
+	return sig.Signature
+}
+
+func (s *LightClientTestSuite) newGenesis() *RoundInfo {
+	return &RoundInfo{
+		Round:   0,
+		Config:  s.config,
+		NodeSet: s.pubKeys,
+		CRS:     crypto.Keccak256Hash([]byte("__ DEXON")),
+	}
+}
+
+// newRoundInfo prepares the round following prev.
+func (s *LightClientTestSuite) newRoundInfo(prev *RoundInfo) *RoundInfo {
+	info := &RoundInfo{
+		Round:   prev.Round + 1,
+		Config:  s.config,
+		NodeSet: s.pubKeys,
+	}
+	if info.Round <= core.DKGDelayRound {
+		info.CRS = crypto.Keccak256Hash(prev.CRS[:])
+	} else {
+		info.CRSSignature = s.tsign(prev.Round, prev.CRS)
+		info.CRS = crypto.Keccak256Hash(info.CRSSignature)
+	}
+	if info.Round >= core.DKGDelayRound {
+		info.MasterPublicKeys = s.runDKG(info.Round, 0)
+	}
+	return s.sign(info, s.prvKeys)
+}
+
+// sign replaces signatures of the round information with ones from prvKeys.
+func (s *LightClientTestSuite) sign(
+	info *RoundInfo, prvKeys []crypto.PrivateKey) *RoundInfo {
+	info.Signatures = nil
+	for _, prvKey := range prvKeys {
+		sig, err := prvKey.Sign(HashRoundInfo(info))
+		s.Require().NoError(err)
+		info.Signatures = append(info.Signatures, sig)
+	}
+	return info
+}
+
+// newBlock proposes a finalized block following parent in a round, the block
+// is the genesis block if parent is nil.
+func (s *LightClientTestSuite) newBlock(
+	info *RoundInfo, parent *types.Block) *types.Block {
+	b := &types.Block{
+		Position:  types.Position{Round: info.Round},
+		Timestamp: time.Now().UTC(),
+		Payload:   common.NewRandomHash().Bytes(),
+	}
+	if parent == nil {
+		b.Position.Height = types.GenesisHeight
+	} else {
+		b.Position.Height = parent.Position.Height + 1
+		b.ParentHash = parent.Hash
+	}
+	for nID, signer := range s.signers {
+		if share, exist := s.shares[info.Round][nID]; exist {
+			signer.SetBLSSigner(func(
+				round uint64, hash common.Hash) (crypto.Signature, error) {
+				return share.Sign(hash)
+			})
+		}
+		s.Require().NoError(signer.SignBlock(b))
+		s.Require().NoError(signer.SignCRS(b, info.CRS))
+		break
+	}
+	if info.Round < core.DKGDelayRound {
+		b.Randomness = core.NoRand
+	} else {
+		b.Randomness = s.tsign(info.Round, b.Hash)
+	}
+	return b
+}
+
+func (s *LightClientTestSuite) newClient() *Client {
+	c, err := NewClient(s.newGenesis(), &common.NullLogger{})
+	s.Require().NoError(err)
+	return c
+}
+
+func (s *LightClientTestSuite) TestGenesisRound() {
+	genesis := s.newGenesis()
+	c := s.newClient()
+	s.Require().Equal(uint64(0), c.LastRound())
+	notarySet, err := c.NotarySet(0)
+	s.Require().NoError(err)
+	s.Require().Len(notarySet, len(s.pubKeys))
+	_, err = c.NotarySet(1)
+	s.Require().Equal(ErrRoundNotReady, err)
+	// Genesis should be round 0.
+	genesis.Round = 1
+	_, err = NewClient(genesis, &common.NullLogger{})
+	s.Require().Equal(ErrInvalidRoundOrder, err)
+	genesis.Round = 0
+	genesis.CRS = common.Hash{}
+	_, err = NewClient(genesis, &common.NullLogger{})
+	s.Require().Equal(ErrInvalidRoundInfo, err)
+	// Verify blocks in genesis round.
+	genesis = s.newGenesis()
+	b0 := s.newBlock(genesis, nil)
+	b1 := s.newBlock(genesis, b0)
+	s.Require().NoError(c.VerifyBlock(b0))
+	s.Require().NoError(c.VerifyBlock(b1))
+	// Randomness should be NoRand before DKGDelayRound.
+	b1.Randomness = []byte{0x01}
+	s.Require().Equal(ErrIncorrectBlockRandomness, c.VerifyBlock(b1))
+	b1.Randomness = core.NoRand
+	// Payload is checked by VerifyBlock but not VerifyHeader.
+	b1.Payload = []byte{0x01}
+	s.Require().Equal(utils.ErrIncorrectHash, c.VerifyBlock(b1))
+	s.Require().NoError(c.VerifyHeader(b1))
+	// CRS signature should match.
+	b1.CRSSignature.Signature = common.NewRandomHash().Bytes()
+	s.Require().Equal(ErrIncorrectCRSSignature, c.VerifyHeader(b1))
+	// Proposer should be in notary set.
+	prvKey, err := ecdsa.NewPrivateKey()
+	s.Require().NoError(err)
+	s.Require().NoError(utils.NewSigner(prvKey).SignBlock(b1))
+	s.Require().Equal(ErrNotInNotarySet, c.VerifyHeader(b1))
+	// Blocks in unknown rounds are not verified.
+	b1.Position.Round = 1
+	s.Require().Equal(ErrRoundNotReady, c.VerifyHeader(b1))
+}
+
+func (s *LightClientTestSuite) TestSyncHeaders() {
+	genesis := s.newGenesis()
+	c := s.newClient()
+	s.Require().Nil(c.Tip())
+	b0 := s.newBlock(genesis, nil)
+	b1 := s.newBlock(genesis, b0)
+	b2 := s.newBlock(genesis, b1)
+	// The first header should be the genesis block.
+	s.Require().Equal(ErrInvalidHeaderOrder, c.SyncHeaders(b1))
+	s.Require().NoError(c.SyncHeaders(b0, b1))
+	tip := c.Tip()
+	s.Require().Equal(b1.Hash, tip.Hash)
+	s.Require().Empty(tip.Payload)
+	// Headers should follow the tip.
+	s.Require().Equal(ErrInvalidHeaderOrder, c.SyncHeaders(b1))
+	forked := s.newBlock(genesis, b0)
+	forked.Position.Height = b2.Position.Height
+	s.Require().Equal(ErrInvalidHeaderOrder, c.SyncHeaders(forked))
+	// Invalid headers are not synced.
+	b2.Randomness = []byte{0x01}
+	s.Require().Equal(ErrIncorrectBlockRandomness, c.SyncHeaders(b2))
+	s.Require().Equal(b1.Hash, c.Tip().Hash)
+	b2.Randomness = core.NoRand
+	// Headers without payloads are synced.
+	b2.Payload = nil
+	s.Require().NoError(c.SyncHeaders(b2))
+	s.Require().Equal(b2.Hash, c.Tip().Hash)
+	// Rounds are switched one by one.
+	b3 := s.newBlock(genesis, b2)
+	b3.Position.Round = 2
+	s.Require().Equal(ErrInvalidHeaderOrder, c.SyncHeaders(b3))
+}
+
+func (s *LightClientTestSuite) TestAddRound() {
+	genesis := s.newGenesis()
+	c := s.newClient()
+	round1 := s.newRoundInfo(genesis)
+	round2 := s.newRoundInfo(round1)
+	// Rounds should be added in order.
+	s.Require().Equal(ErrInvalidRoundOrder, c.AddRound(round2))
+	// CRS before DKGDelayRound is hashed from the previous one.
+	crs := round1.CRS
+	round1.CRS = common.NewRandomHash()
+	s.Require().Equal(ErrIncorrectCRS, c.AddRound(s.sign(round1, s.prvKeys)))
+	round1.CRS = crs
+	// Master public keys should be signed by the notary set.
+	mpk := round1.MasterPublicKeys[0]
+	round1.MasterPublicKeys[0] = test.CloneDKGMasterPublicKey(mpk)
+	round1.MasterPublicKeys[0].Reset++
+	s.Require().Equal(ErrInvalidMasterPublicKey,
+		c.AddRound(s.sign(round1, s.prvKeys)))
+	round1.MasterPublicKeys[0] = mpk
+	// DKG should be valid.
+	mpks := round1.MasterPublicKeys
+	round1.MasterPublicKeys = mpks[:1]
+	s.Require().Error(c.AddRound(s.sign(round1, s.prvKeys)))
+	round1.MasterPublicKeys = mpks
+	s.Require().NoError(c.AddRound(s.sign(round1, s.prvKeys)))
+	s.Require().Equal(uint64(1), c.LastRound())
+	// CRS after DKGDelayRound should be signed by DKG of the previous round.
+	sig := round2.CRSSignature
+	round2.CRSSignature = s.tsign(round1.Round, common.NewRandomHash())
+	round2.CRS = crypto.Keccak256Hash(round2.CRSSignature)
+	s.Require().Equal(ErrIncorrectCRS, c.AddRound(s.sign(round2, s.prvKeys)))
+	round2.CRSSignature = sig
+	s.Require().Equal(ErrIncorrectCRS, c.AddRound(s.sign(round2, s.prvKeys)))
+	round2.CRS = crypto.Keccak256Hash(sig)
+	s.Require().NoError(c.AddRound(s.sign(round2, s.prvKeys)))
+	// The CRS is rehashed before signing when DKG is reset.
+	round3 := s.newRoundInfo(round2)
+	round3.Reset = 1
+	s.Require().Equal(ErrIncorrectCRS, c.AddRound(s.sign(round3, s.prvKeys)))
+	round3.CRSSignature = s.tsign(round2.Round, utils.Rehash(round2.CRS, 1))
+	round3.CRS = crypto.Keccak256Hash(round3.CRSSignature)
+	round3.MasterPublicKeys = s.runDKG(round3.Round, round3.Reset)
+	s.Require().NoError(c.AddRound(s.sign(round3, s.prvKeys)))
+}
+
+func (s *LightClientTestSuite) TestRoundInfoSignatures() {
+	genesis := s.newGenesis()
+	c := s.newClient()
+	round1 := s.newRoundInfo(genesis)
+	// Information changed after signed is rejected.
+	config := round1.Config
+	round1.Config = config.Clone()
+	round1.Config.NotarySetSize--
+	s.Require().Equal(ErrRoundInfoNotSigned, c.AddRound(round1))
+	round1.Config = config
+	nodeSet := round1.NodeSet
+	round1.NodeSet = nodeSet[1:]
+	s.Require().Equal(ErrRoundInfoNotSigned, c.AddRound(round1))
+	round1.NodeSet = nodeSet
+	mpks := round1.MasterPublicKeys
+	round1.MasterPublicKeys = mpks[1:]
+	s.Require().Equal(ErrRoundInfoNotSigned, c.AddRound(round1))
+	round1.MasterPublicKeys = mpks
+	// Signatures should be from more than 2/3 of the notary set.
+	threshold := utils.GetBAThreshold(genesis.Config)
+	s.sign(round1, s.prvKeys[:threshold-1])
+	s.Require().Equal(ErrRoundInfoNotSigned, c.AddRound(round1))
+	// Duplicated signatures are counted once.
+	s.sign(round1, s.prvKeys[:1])
+	round1.Signatures = append(round1.Signatures, round1.Signatures[0],
+		round1.Signatures[0], round1.Signatures[0])
+	s.Require().Equal(ErrRoundInfoNotSigned, c.AddRound(round1))
+	// Signatures from nodes not in the notary set are not counted.
+	others, _, err := test.NewKeys(threshold)
+	s.Require().NoError(err)
+	s.sign(round1, append(others, s.prvKeys[:threshold-1]...))
+	s.Require().Equal(ErrRoundInfoNotSigned, c.AddRound(round1))
+	s.sign(round1, s.prvKeys[:threshold])
+	s.Require().NoError(c.AddRound(round1))
+}
+
+func (s *LightClientTestSuite) TestVerifyBlock() {
+	genesis := s.newGenesis()
+	c := s.newClient()
+	round1 := s.newRoundInfo(genesis)
+	s.Require().NoError(c.AddRound(round1))
+	b0 := s.newBlock(genesis, nil)
+	b1 := s.newBlock(round1, b0)
+	b2 := s.newBlock(round1, b1)
+	s.Require().NoError(c.VerifyBlock(b1))
+	s.Require().NoError(c.SyncHeaders(b0, b1, b2))
+	s.Require().Equal(b2.Hash, c.Tip().Hash)
+	// Randomness should be signed by DKG of the round.
+	b3 := s.newBlock(round1, b2)
+	b3.Randomness = s.tsign(round1.Round, common.NewRandomHash())
+	s.Require().Equal(ErrIncorrectBlockRandomness, c.VerifyBlock(b3))
+	// CRS signature should be signed by the proposer with its share.
+	b3 = s.newBlock(round1, b2)
+	b3.CRSSignature = b2.CRSSignature
+	s.Require().Equal(ErrIncorrectCRSSignature, c.VerifyBlock(b3))
+	// Empty blocks are not signed.
+	empty := &types.Block{
+		ParentHash: b2.Hash,
+		Position: types.Position{
+			Round:  round1.Round,
+			Height: b2.Position.Height + 1,
+		},
+		Timestamp: b2.Timestamp.Add(time.Second),
+	}
+	var err error
+	empty.Hash, err = utils.HashBlock(empty)
+	s.Require().NoError(err)
+	empty.Randomness = s.tsign(round1.Round, empty.Hash)
+	s.Require().NoError(c.VerifyBlock(empty))
+	s.Require().NoError(c.SyncHeaders(empty))
+	empty.Timestamp = empty.Timestamp.Add(time.Second)
+	s.Require().Equal(utils.ErrIncorrectHash, c.VerifyBlock(empty))
+}
+
+func (s *LightClientTestSuite) TestNodeSetCommitment() {
+	genesis := s.newGenesis()
+	c := s.newClient()
+	round1 := s.newRoundInfo(genesis)
+	_, err := c.NodeSetCommitment(round1.Round)
+	s.Require().Equal(ErrRoundNotReady, err)
+	s.Require().NoError(c.AddRound(round1))
+	// There is no finality proof before DKGDelayRound.
+	_, err = c.NodeSetCommitment(genesis.Round)
+	s.Require().Equal(utils.ErrNoFinalityProof, err)
+	// Finality proofs are verified against the commitment of the client.
+	b0 := s.newBlock(genesis, nil)
+	b1 := s.newBlock(round1, b0)
+	b1.Payload = nil
+	proof := &types.FinalityProof{
+		Header:         *b1,
+		GroupPublicKey: c.rounds[round1.Round].gpk.GroupPublicKey.Bytes(),
+	}
+	for nID := range s.signers {
+		proof.NotarySet = append(proof.NotarySet, nID)
+	}
+	proof.NodeSetCommitment = utils.HashNodeSetCommitment(
+		round1.Round, proof.NotarySet, proof.GroupPublicKey)
+	trusted, err := c.NodeSetCommitment(round1.Round)
+	s.Require().NoError(err)
+	s.Require().NoError(utils.VerifyFinalityProof(proof, trusted))
+	// Proofs claiming another notary set are rejected.
+	proof.NotarySet = proof.NotarySet[1:]
+	proof.NodeSetCommitment = utils.HashNodeSetCommitment(
+		round1.Round, proof.NotarySet, proof.GroupPublicKey)
+	s.Require().Equal(utils.ErrUntrustedNodeSetCommitment,
+		utils.VerifyFinalityProof(proof, trusted))
+}
+
+func TestLightClient(t *testing.T) {
+	suite.Run(t, new(LightClientTestSuite))
+}
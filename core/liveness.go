@@ -0,0 +1,112 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// Errors for liveness policy.
+var (
+	// ErrNoBlockDelivered is returned by Consensus.Run when the liveness
+	// policy stops the Consensus instance.
+	ErrNoBlockDelivered = errors.New("no blocks delivered for too long")
+	// ErrInvalidLivenessPolicy is raised when a liveness policy is not
+	// applicable.
+	ErrInvalidLivenessPolicy = errors.New("invalid liveness policy")
+)
+
+// LivenessAction is the action to take when no block is delivered for too
+// long. Actions can be combined, e.g. LivenessActionNotify|LivenessActionStop.
+type LivenessAction uint32
+
+// LivenessAction enum.
+const (
+	// LivenessActionNotify sends a LivenessEvent to LivenessPolicy.Notify
+	// without blocking.
+	LivenessActionNotify LivenessAction = 1 << iota
+	// LivenessActionCallback calls LivenessPolicy.Callback.
+	LivenessActionCallback
+	// LivenessActionResync proposes to skip the last delivered block through
+	// LivenessPolicy.Recovery, which is the same recovery path used by
+	// syncer.WatchCat.
+	LivenessActionResync
+	// LivenessActionStop stops the Consensus instance, Consensus.Run would
+	// return ErrNoBlockDelivered.
+	LivenessActionStop
+)
+
+// LivenessEvent describes a violation of liveness.
+type LivenessEvent struct {
+	ID types.NodeID
+	// LastDelivered is the position of the last delivered block, it's empty
+	// when no block is delivered yet.
+	LastDelivered types.Position
+	// Elapsed is the time since last delivered block, or since the delivery
+	// guard started when no block is delivered after that.
+	Elapsed time.Duration
+}
+
+// LivenessPolicy defines how Consensus reacts when no block is delivered for
+// too long.
+type LivenessPolicy struct {
+	// StartupTimeout is the time to wait after dMoment before checking
+	// delivery, nodes take time to start.
+	StartupTimeout time.Duration
+	// DeliveryTimeout is the maximum time allowed between two delivered
+	// blocks, actions would be taken again after each timeout.
+	DeliveryTimeout time.Duration
+	// Actions to take when timeout.
+	Actions LivenessAction
+	// Notify is required by LivenessActionNotify.
+	Notify chan<- LivenessEvent
+	// Callback is required by LivenessActionCallback.
+	Callback func(LivenessEvent)
+	// Recovery is required by LivenessActionResync.
+	Recovery Recovery
+}
+
+// DefaultLivenessPolicy returns the liveness policy used by Consensus when
+// none is provided.
+func DefaultLivenessPolicy() LivenessPolicy {
+	return LivenessPolicy{
+		StartupTimeout:  60 * time.Second,
+		DeliveryTimeout: 60 * time.Second,
+		Actions:         LivenessActionStop,
+	}
+}
+
+// Verify checks if all required fields for its actions are provided.
+func (p *LivenessPolicy) Verify() error {
+	if p.StartupTimeout < 0 || p.DeliveryTimeout <= 0 {
+		return ErrInvalidLivenessPolicy
+	}
+	if p.Actions&LivenessActionNotify != 0 && p.Notify == nil {
+		return ErrInvalidLivenessPolicy
+	}
+	if p.Actions&LivenessActionCallback != 0 && p.Callback == nil {
+		return ErrInvalidLivenessPolicy
+	}
+	if p.Actions&LivenessActionResync != 0 && p.Recovery == nil {
+		return ErrInvalidLivenessPolicy
+	}
+	return nil
+}
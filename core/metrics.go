@@ -0,0 +1,420 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// Metrics describes the interface to receive measurements from a Consensus
+// instance. Implementations should be safe for concurrent use and should not
+// block, these methods are called within the critical path of consensus.
+type Metrics interface {
+	// ObserveBAPeriods is called when BA confirms a block at a position,
+	// with the period BA reached for that position.
+	ObserveBAPeriods(pos types.Position, period uint64)
+
+	// ObserveBlockLatency is called when a block is delivered at a position
+	// this node proposed a block for, with the time elapsed since proposing.
+	ObserveBlockLatency(pos types.Position, latency time.Duration)
+
+	// SetMsgChanDepth reports the number of pending messages in the normal
+	// and priority message channels.
+	SetMsgChanDepth(normal, priority int)
+
+	// ObserveTSigLatency is called when a TSig is recovered.
+	ObserveTSigLatency(round uint64, latency time.Duration)
+
+	// ObserveDKGPhase is called when a DKG phase is done.
+	ObserveDKGPhase(round, reset uint64, phase int, elapse time.Duration)
+
+	// SetPendingBlocks reports the number of blocks waiting in blockChain,
+	// pending are blocks waiting for their parents and confirmed are blocks
+	// waiting to be delivered.
+	SetPendingBlocks(pending, confirmed int)
+
+	// IncBadPeer is called when a peer is reported through
+	// Network.ReportBadPeerChan.
+	IncBadPeer()
+
+	// IncDroppedBadPeerReport is called when a report of bad peer is dropped
+	// because Network.ReportBadPeerChan is full.
+	IncDroppedBadPeerReport()
+}
+
+// NullMetrics drops all measurements.
+type NullMetrics struct{}
+
+// ObserveBAPeriods implements Metrics interface.
+func (m *NullMetrics) ObserveBAPeriods(types.Position, uint64) {}
+
+// ObserveBlockLatency implements Metrics interface.
+func (m *NullMetrics) ObserveBlockLatency(types.Position, time.Duration) {}
+
+// SetMsgChanDepth implements Metrics interface.
+func (m *NullMetrics) SetMsgChanDepth(int, int) {}
+
+// ObserveTSigLatency implements Metrics interface.
+func (m *NullMetrics) ObserveTSigLatency(uint64, time.Duration) {}
+
+// ObserveDKGPhase implements Metrics interface.
+func (m *NullMetrics) ObserveDKGPhase(uint64, uint64, int, time.Duration) {}
+
+// SetPendingBlocks implements Metrics interface.
+func (m *NullMetrics) SetPendingBlocks(int, int) {}
+
+// IncBadPeer implements Metrics interface.
+func (m *NullMetrics) IncBadPeer() {}
+
+// IncDroppedBadPeerReport implements Metrics interface.
+func (m *NullMetrics) IncDroppedBadPeerReport() {}
+
+// Names of metrics exposed by MetricsRegistry.
+const (
+	MetricBAPeriods           = "dexcon_ba_periods"
+	MetricBALastPeriod        = "dexcon_ba_last_period"
+	MetricBALastHeight        = "dexcon_ba_last_height"
+	MetricBlockLatency        = "dexcon_block_propose_to_deliver_seconds"
+	MetricMsgChanDepth        = "dexcon_msg_chan_depth"
+	MetricTSigLatency         = "dexcon_tsig_seconds"
+	MetricDKGPhase            = "dexcon_dkg_phase_seconds"
+	MetricPendingBlocks       = "dexcon_blockchain_pending_blocks"
+	MetricBadPeerReports      = "dexcon_bad_peer_reports_total"
+	MetricBadPeerDropped      = "dexcon_bad_peer_reports_dropped_total"
+	metricTypeCounter         = "counter"
+	metricTypeGauge           = "gauge"
+	metricTypeHistogram       = "histogram"
+	metricLabelChan           = "chan"
+	metricLabelPhase          = "phase"
+	metricLabelBlockState     = "state"
+	metricChanNormal          = "normal"
+	metricChanPriority        = "priority"
+	metricBlockStatePending   = "pending"
+	metricBlockStateConfirmed = "confirmed"
+)
+
+var (
+	// Buckets for BA periods, BA starts from period 2 when restarted.
+	baPeriodBuckets = []float64{2, 3, 4, 5, 6, 8, 10, 15, 20, 50}
+	// Buckets for latency in seconds.
+	latencyBuckets = []float64{
+		.05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+)
+
+type histogram struct {
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)),
+	}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, upper := range h.buckets {
+		if v <= upper {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+type metricFamily struct {
+	name    string
+	help    string
+	typ     string
+	buckets []float64
+	// Values are keyed by their encoded label pairs.
+	values     map[string]float64
+	histograms map[string]*histogram
+}
+
+// MetricsRegistry is the default in-memory implementation of Metrics. It
+// exposes all measurements in the text exposition format of Prometheus via
+// WriteTo or ServeHTTP.
+type MetricsRegistry struct {
+	lock     sync.Mutex
+	families map[string]*metricFamily
+}
+
+// NewMetricsRegistry constructs a MetricsRegistry instance.
+func NewMetricsRegistry() *MetricsRegistry {
+	r := &MetricsRegistry{
+		families: make(map[string]*metricFamily),
+	}
+	r.register(MetricBAPeriods, metricTypeHistogram, baPeriodBuckets,
+		"Number of BA periods taken to confirm a position.")
+	r.register(MetricBALastPeriod, metricTypeGauge, nil,
+		"BA period of the last confirmed position.")
+	r.register(MetricBALastHeight, metricTypeGauge, nil,
+		"Height of the last position confirmed by BA.")
+	r.register(MetricBlockLatency, metricTypeHistogram, latencyBuckets,
+		"Time from proposing a block to delivering the same position.")
+	r.register(MetricMsgChanDepth, metricTypeGauge, nil,
+		"Number of messages waiting to be processed.")
+	r.register(MetricTSigLatency, metricTypeHistogram, latencyBuckets,
+		"Time to recover a threshold signature.")
+	r.register(MetricDKGPhase, metricTypeHistogram, latencyBuckets,
+		"Time to run a DKG phase.")
+	r.register(MetricPendingBlocks, metricTypeGauge, nil,
+		"Number of blocks waiting in blockchain module.")
+	r.register(MetricBadPeerReports, metricTypeCounter, nil,
+		"Number of peers reported as bad peer.")
+	r.register(MetricBadPeerDropped, metricTypeCounter, nil,
+		"Number of bad peer reports dropped by full channel.")
+	return r
+}
+
+func (r *MetricsRegistry) register(
+	name, typ string, buckets []float64, help string) {
+	r.families[name] = &metricFamily{
+		name:       name,
+		help:       help,
+		typ:        typ,
+		buckets:    buckets,
+		values:     make(map[string]float64),
+		histograms: make(map[string]*histogram),
+	}
+}
+
+// encodeLabels encodes key-value pairs of labels, the order of labels is
+// kept as is.
+func encodeLabels(labels ...string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	buf := &bytes.Buffer{}
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(buf, "%s=%q", labels[i], labels[i+1])
+	}
+	return buf.String()
+}
+
+func (r *MetricsRegistry) set(name string, v float64, labels ...string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.families[name].values[encodeLabels(labels...)] = v
+}
+
+func (r *MetricsRegistry) add(name string, v float64, labels ...string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.families[name].values[encodeLabels(labels...)] += v
+}
+
+func (r *MetricsRegistry) observe(name string, v float64, labels ...string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	f := r.families[name]
+	key := encodeLabels(labels...)
+	h, exist := f.histograms[key]
+	if !exist {
+		h = newHistogram(f.buckets)
+		f.histograms[key] = h
+	}
+	h.observe(v)
+}
+
+// ObserveBAPeriods implements Metrics interface.
+func (r *MetricsRegistry) ObserveBAPeriods(
+	pos types.Position, period uint64) {
+	r.observe(MetricBAPeriods, float64(period))
+	r.set(MetricBALastPeriod, float64(period))
+	r.set(MetricBALastHeight, float64(pos.Height))
+}
+
+// ObserveBlockLatency implements Metrics interface.
+func (r *MetricsRegistry) ObserveBlockLatency(
+	_ types.Position, latency time.Duration) {
+	r.observe(MetricBlockLatency, latency.Seconds())
+}
+
+// SetMsgChanDepth implements Metrics interface.
+func (r *MetricsRegistry) SetMsgChanDepth(normal, priority int) {
+	r.set(MetricMsgChanDepth, float64(normal),
+		metricLabelChan, metricChanNormal)
+	r.set(MetricMsgChanDepth, float64(priority),
+		metricLabelChan, metricChanPriority)
+}
+
+// ObserveTSigLatency implements Metrics interface.
+func (r *MetricsRegistry) ObserveTSigLatency(
+	_ uint64, latency time.Duration) {
+	r.observe(MetricTSigLatency, latency.Seconds())
+}
+
+// ObserveDKGPhase implements Metrics interface.
+func (r *MetricsRegistry) ObserveDKGPhase(
+	_, _ uint64, phase int, elapse time.Duration) {
+	r.observe(MetricDKGPhase, elapse.Seconds(),
+		metricLabelPhase, strconv.Itoa(phase))
+}
+
+// SetPendingBlocks implements Metrics interface.
+func (r *MetricsRegistry) SetPendingBlocks(pending, confirmed int) {
+	r.set(MetricPendingBlocks, float64(pending),
+		metricLabelBlockState, metricBlockStatePending)
+	r.set(MetricPendingBlocks, float64(confirmed),
+		metricLabelBlockState, metricBlockStateConfirmed)
+}
+
+// IncBadPeer implements Metrics interface.
+func (r *MetricsRegistry) IncBadPeer() {
+	r.add(MetricBadPeerReports, 1)
+}
+
+// IncDroppedBadPeerReport implements Metrics interface.
+func (r *MetricsRegistry) IncDroppedBadPeerReport() {
+	r.add(MetricBadPeerDropped, 1)
+}
+
+// Value returns the current value of a counter or gauge, it's mainly for
+// testing.
+func (r *MetricsRegistry) Value(name string, labels ...string) float64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	f, exist := r.families[name]
+	if !exist {
+		return 0
+	}
+	return f.values[encodeLabels(labels...)]
+}
+
+// Count returns how many values are observed by a histogram, it's mainly for
+// testing.
+func (r *MetricsRegistry) Count(name string, labels ...string) uint64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	f, exist := r.families[name]
+	if !exist {
+		return 0
+	}
+	h, exist := f.histograms[encodeLabels(labels...)]
+	if !exist {
+		return 0
+	}
+	return h.count
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func withLabel(labels, key, value string) string {
+	extra := encodeLabels(key, value)
+	if len(labels) == 0 {
+		return extra
+	}
+	return labels + "," + extra
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// WriteTo writes all metrics in text exposition format.
+func (r *MetricsRegistry) WriteTo(w io.Writer) (int64, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	buf := &bytes.Buffer{}
+	names := make(map[string]struct{}, len(r.families))
+	for name := range r.families {
+		names[name] = struct{}{}
+	}
+	writeSample := func(name, labels string, v string) {
+		if len(labels) == 0 {
+			fmt.Fprintf(buf, "%s %s\n", name, v)
+		} else {
+			fmt.Fprintf(buf, "%s{%s} %s\n", name, labels, v)
+		}
+	}
+	for _, name := range sortedKeys(names) {
+		f := r.families[name]
+		fmt.Fprintf(buf, "# HELP %s %s\n", f.name, f.help)
+		fmt.Fprintf(buf, "# TYPE %s %s\n", f.name, f.typ)
+		if f.typ != metricTypeHistogram {
+			keys := make(map[string]struct{}, len(f.values))
+			for k := range f.values {
+				keys[k] = struct{}{}
+			}
+			for _, labels := range sortedKeys(keys) {
+				writeSample(f.name, labels, formatFloat(f.values[labels]))
+			}
+			continue
+		}
+		keys := make(map[string]struct{}, len(f.histograms))
+		for k := range f.histograms {
+			keys[k] = struct{}{}
+		}
+		for _, labels := range sortedKeys(keys) {
+			h := f.histograms[labels]
+			for i, upper := range h.buckets {
+				writeSample(f.name+"_bucket",
+					withLabel(labels, "le", formatFloat(upper)),
+					strconv.FormatUint(h.counts[i], 10))
+			}
+			writeSample(f.name+"_bucket",
+				withLabel(labels, "le", "+Inf"),
+				strconv.FormatUint(h.count, 10))
+			writeSample(f.name+"_sum", labels, formatFloat(h.sum))
+			writeSample(f.name+"_count", labels,
+				strconv.FormatUint(h.count, 10))
+		}
+	}
+	return buf.WriteTo(w)
+}
+
+// ServeHTTP implements http.Handler interface.
+func (r *MetricsRegistry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if _, err := r.WriteTo(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type MetricsTestSuite struct {
+	suite.Suite
+}
+
+func (s *MetricsTestSuite) TestRegistry() {
+	r := NewMetricsRegistry()
+	r.ObserveBAPeriods(types.Position{Height: 10}, 3)
+	r.ObserveBAPeriods(types.Position{Height: 11}, 2)
+	r.SetMsgChanDepth(5, 1)
+	r.SetPendingBlocks(2, 3)
+	r.IncBadPeer()
+	r.IncBadPeer()
+	r.IncDroppedBadPeerReport()
+	r.ObserveDKGPhase(1, 0, 2, 300*time.Millisecond)
+	r.ObserveTSigLatency(1, 2*time.Second)
+	s.Require().Equal(uint64(2), r.Count(MetricBAPeriods))
+	s.Require().Equal(float64(2), r.Value(MetricBALastPeriod))
+	s.Require().Equal(float64(11), r.Value(MetricBALastHeight))
+	s.Require().Equal(float64(5), r.Value(
+		MetricMsgChanDepth, metricLabelChan, metricChanNormal))
+	s.Require().Equal(float64(1), r.Value(
+		MetricMsgChanDepth, metricLabelChan, metricChanPriority))
+	s.Require().Equal(float64(3), r.Value(MetricPendingBlocks,
+		metricLabelBlockState, metricBlockStateConfirmed))
+	s.Require().Equal(float64(2), r.Value(MetricBadPeerReports))
+	s.Require().Equal(float64(1), r.Value(MetricBadPeerDropped))
+	s.Require().Equal(uint64(1), r.Count(MetricDKGPhase, metricLabelPhase, "2"))
+	s.Require().Equal(uint64(0), r.Count(MetricDKGPhase, metricLabelPhase, "3"))
+	s.Require().Equal(uint64(1), r.Count(MetricTSigLatency))
+}
+
+func (s *MetricsTestSuite) TestExposition() {
+	r := NewMetricsRegistry()
+	r.ObserveBAPeriods(types.Position{Height: 1}, 4)
+	r.SetMsgChanDepth(7, 0)
+	r.IncBadPeer()
+	buf := &bytes.Buffer{}
+	_, err := r.WriteTo(buf)
+	s.Require().NoError(err)
+	out := buf.String()
+	for _, line := range []string{
+		"# TYPE dexcon_ba_periods histogram",
+		`dexcon_ba_periods_bucket{le="3"} 0`,
+		`dexcon_ba_periods_bucket{le="4"} 1`,
+		`dexcon_ba_periods_bucket{le="+Inf"} 1`,
+		"dexcon_ba_periods_count 1",
+		"# TYPE dexcon_bad_peer_reports_total counter",
+		"dexcon_bad_peer_reports_total 1",
+		`dexcon_msg_chan_depth{chan="normal"} 7`,
+	} {
+		s.Require().True(strings.Contains(out, line+"\n"), line)
+	}
+	// Check the http handler.
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	s.Require().Equal(out, rec.Body.String())
+}
+
+func TestMetrics(t *testing.T) {
+	suite.Run(t, new(MetricsTestSuite))
+}
@@ -0,0 +1,143 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+)
+
+type sigVerifyJob struct {
+	msg  types.Msg
+	done chan struct{}
+}
+
+// sigVerifier verifies ECDSA signatures of messages from network by a pool of
+// workers before they're processed. Results are kept in the signature cache,
+// so verifying them again when processing is cheap. Messages are output in
+// the order they're submitted.
+type sigVerifier struct {
+	cache   *utils.SignatureCache
+	workers int
+	jobs    chan *sigVerifyJob
+	pending chan *sigVerifyJob
+	clock   Clock
+	logger  common.Logger
+}
+
+func newSigVerifier(cache *utils.SignatureCache, workers, queueSize int,
+	clock Clock, logger common.Logger) *sigVerifier {
+	return &sigVerifier{
+		cache:   cache,
+		workers: workers,
+		jobs:    make(chan *sigVerifyJob, queueSize),
+		pending: make(chan *sigVerifyJob, queueSize),
+		clock:   clock,
+		logger:  logger,
+	}
+}
+
+// start launches workers and outputs messages until ctx is done.
+func (v *sigVerifier) start(ctx context.Context, output chan<- types.Msg) {
+	for i := 0; i < v.workers; i++ {
+		go v.work(ctx)
+	}
+	go v.deliver(ctx, output)
+}
+
+// submit queues a message, it's blocked when the queue is full and returns
+// false when ctx is done.
+func (v *sigVerifier) submit(ctx context.Context, msg types.Msg) bool {
+	job := &sigVerifyJob{msg: msg, done: make(chan struct{})}
+	needVerify := false
+	switch msg.Payload.(type) {
+	case *types.Vote, *typesDKG.PartialSignature:
+		needVerify = true
+	default:
+		close(job.done)
+	}
+	// Jobs are queued as pending first to keep the order of messages.
+	select {
+	case v.pending <- job:
+	case <-ctx.Done():
+		return false
+	}
+	if !needVerify {
+		return true
+	}
+	select {
+	case v.jobs <- job:
+	case <-ctx.Done():
+		return false
+	}
+	return true
+}
+
+func (v *sigVerifier) verify(msg interface{}) {
+	switch val := msg.(type) {
+	case *types.Vote:
+		v.cache.VerifyVoteSignature(val)
+	case *typesDKG.PartialSignature:
+		v.cache.VerifyDKGPartialSignatureSignature(val)
+	}
+}
+
+func (v *sigVerifier) work(ctx context.Context) {
+	for {
+		select {
+		case job := <-v.jobs:
+			v.verify(job.msg.Payload)
+			close(job.done)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (v *sigVerifier) deliver(ctx context.Context, output chan<- types.Msg) {
+	for {
+		var job *sigVerifyJob
+		select {
+		case job = <-v.pending:
+		case <-ctx.Done():
+			return
+		}
+		select {
+		case <-job.done:
+		case <-ctx.Done():
+			return
+		}
+	outputLoop:
+		for {
+			select {
+			case output <- job.msg:
+				break outputLoop
+			case <-v.clock.After(500 * time.Millisecond):
+				v.logger.Debug("internal message channel is full",
+					"pending", job.msg)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+)
+
+// newSignedMsgs generates count messages, most of them are signed votes and
+// others are partial signatures and blocks.
+func newSignedMsgs(count int) ([]types.Msg, error) {
+	signers := make([]*utils.Signer, 4)
+	for i := range signers {
+		prvKey, err := ecdsa.NewPrivateKey()
+		if err != nil {
+			return nil, err
+		}
+		signers[i] = utils.NewSigner(prvKey)
+	}
+	msgs := make([]types.Msg, 0, count)
+	for i := 0; i < count; i++ {
+		signer := signers[i%len(signers)]
+		var payload interface{}
+		switch i % 10 {
+		case 8:
+			psig := &typesDKG.PartialSignature{
+				Round: 1,
+				Hash:  common.NewRandomHash(),
+			}
+			if err := signer.SignDKGPartialSignature(psig); err != nil {
+				return nil, err
+			}
+			payload = psig
+		case 9:
+			payload = &types.Block{Hash: common.NewRandomHash()}
+		default:
+			vote := types.NewVote(
+				types.VoteCom, common.NewRandomHash(), uint64(i))
+			if err := signer.SignVote(vote); err != nil {
+				return nil, err
+			}
+			payload = vote
+		}
+		msgs = append(msgs, types.Msg{PeerID: i, Payload: payload})
+	}
+	return msgs, nil
+}
+
+// processSignedMsg verifies msg in the way processMsg does.
+func processSignedMsg(cache *utils.SignatureCache, msg interface{}) bool {
+	switch val := msg.(type) {
+	case *types.Vote:
+		ok, err := cache.VerifyVoteSignature(val)
+		return ok && err == nil
+	case *typesDKG.PartialSignature:
+		ok, err := cache.VerifyDKGPartialSignatureSignature(val)
+		return ok && err == nil
+	}
+	return true
+}
+
+type SigVerifierTestSuite struct {
+	suite.Suite
+}
+
+func (s *SigVerifierTestSuite) TestOrder() {
+	msgs, err := newSignedMsgs(200)
+	s.Require().NoError(err)
+	// Break one vote.
+	msgs[3].Payload.(*types.Vote).Period++
+	cache := utils.NewSignatureCache(len(msgs))
+	v := newSigVerifier(cache, 4, 8, systemClock{}, &common.NullLogger{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	output := make(chan types.Msg, 8)
+	v.start(ctx, output)
+	go func() {
+		for _, msg := range msgs {
+			v.submit(ctx, msg)
+		}
+	}()
+	for i := range msgs {
+		msg := <-output
+		s.Require().Equal(i, msg.PeerID)
+		s.Require().Equal(i != 3, processSignedMsg(cache, msg.Payload))
+	}
+	// Results are all cached, blocks are not verified.
+	s.Require().Equal(len(msgs)-len(msgs)/10, cache.Len())
+	// Nothing is accepted after stopped.
+	cancel()
+	for i := 0; i < 20; i++ {
+		if !v.submit(ctx, msgs[0]) {
+			return
+		}
+	}
+	s.Fail("submit should fail after stopped")
+}
+
+func TestSigVerifier(t *testing.T) {
+	suite.Run(t, new(SigVerifierTestSuite))
+}
+
+const benchmarkSignedMsgCount = 1000
+
+// BenchmarkVerifySignaturesSequential verifies signatures one by one on the
+// goroutine processing messages, which is how it works without sigVerifier.
+func BenchmarkVerifySignaturesSequential(b *testing.B) {
+	msgs, err := newSignedMsgs(benchmarkSignedMsgCount)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, msg := range msgs {
+			if !processSignedMsg(nil, msg.Payload) {
+				b.Fatal("incorrect signature")
+			}
+		}
+	}
+}
+
+// BenchmarkVerifySignaturesPipeline verifies signatures by sigVerifier before
+// processing messages.
+func BenchmarkVerifySignaturesPipeline(b *testing.B) {
+	msgs, err := newSignedMsgs(benchmarkSignedMsgCount)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		cache := utils.NewSignatureCache(len(msgs))
+		v := newSigVerifier(cache, runtime.NumCPU(), len(msgs),
+			systemClock{}, &common.NullLogger{})
+		output := make(chan types.Msg, len(msgs))
+		v.start(ctx, output)
+		go func() {
+			for _, msg := range msgs {
+				v.submit(ctx, msg)
+			}
+		}()
+		for range msgs {
+			msg := <-output
+			if !processSignedMsg(cache, msg.Payload) {
+				b.Fatal("incorrect signature")
+			}
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sort"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// AgreementStatus is a snapshot of the running BA.
+type AgreementStatus struct {
+	Position types.Position `json:"position"`
+	// Stopped is true when BA is waiting for the next round.
+	Stopped   bool          `json:"stopped"`
+	State     string        `json:"state"`
+	Period    uint64        `json:"period"`
+	LockValue common.Hash   `json:"lock_value"`
+	LockIter  uint64        `json:"lock_iter"`
+	Leader    types.NodeID  `json:"leader"`
+	IsLeader  bool          `json:"is_leader"`
+	NotarySet types.NodeIDs `json:"notary_set"`
+}
+
+// PendingBlockStatus describes a pending block in blockChain, Hash is empty
+// when only the randomness at that position is received.
+type PendingBlockStatus struct {
+	Position types.Position `json:"position"`
+	Hash     common.Hash    `json:"hash"`
+}
+
+// BlockChainStatus is a snapshot of blocks confirmed but not delivered yet.
+type BlockChainStatus struct {
+	LastConfirmed       types.Position       `json:"last_confirmed"`
+	LastDelivered       types.Position       `json:"last_delivered"`
+	ConfirmedBlocks     []types.Position     `json:"confirmed_blocks"`
+	PendingBlocks       []PendingBlockStatus `json:"pending_blocks"`
+	PendingRandomnesses []types.Position     `json:"pending_randomnesses"`
+}
+
+// DKGStatus is a snapshot of the latest DKG protocol.
+type DKGStatus struct {
+	Round   uint64 `json:"round"`
+	Reset   uint64 `json:"reset"`
+	Step    int    `json:"step"`
+	Running bool   `json:"running"`
+}
+
+// TSigStatus is the progress of a running TSig protocol.
+type TSigStatus struct {
+	Hash       common.Hash `json:"hash"`
+	Round      uint64      `json:"round"`
+	Signatures int         `json:"signatures"`
+	Threshold  int         `json:"threshold"`
+}
+
+// Status is a snapshot of a running Consensus instance.
+type Status struct {
+	ID      types.NodeID `json:"id"`
+	Now     time.Time    `json:"now"`
+	DMoment time.Time    `json:"dmoment"`
+	// LastDeliveredTime is empty when no block is delivered since started.
+	LastDeliveredTime time.Time         `json:"last_delivered_time"`
+	Agreement         *AgreementStatus  `json:"agreement"`
+	BlockChain        *BlockChainStatus `json:"blockchain"`
+	DKG               *DKGStatus        `json:"dkg"`
+	TSigs             []TSigStatus      `json:"tsigs"`
+}
+
+// Status returns a snapshot of internal states for introspection.
+func (con *Consensus) Status() *Status {
+	s := &Status{
+		ID:         con.ID,
+		Now:        con.clock.Now(),
+		DMoment:    con.dMoment,
+		BlockChain: con.bcModule.status(),
+		DKG:        con.cfgModule.dkgStatus(),
+		TSigs:      con.cfgModule.tsigStatus(),
+	}
+	if t, ok := con.lastDeliveredTime.Load().(time.Time); ok {
+		s.LastDeliveredTime = t
+	}
+	if con.baMgr != nil {
+		s.Agreement = con.baMgr.status()
+	}
+	return s
+}
+
+func (mgr *agreementMgr) status() *AgreementStatus {
+	mgr.lock.RLock()
+	defer mgr.lock.RUnlock()
+	if mgr.baModule == nil {
+		return nil
+	}
+	return mgr.baModule.status()
+}
+
+func (a *agreement) status() *AgreementStatus {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	a.data.lock.RLock()
+	defer a.data.lock.RUnlock()
+	s := &AgreementStatus{
+		Position:  a.agreementID(),
+		Period:    a.data.period,
+		LockValue: a.data.lockValue,
+		LockIter:  a.data.lockIter,
+		Leader:    a.leader(),
+		IsLeader:  a.data.isLeader,
+		NotarySet: make(types.NodeIDs, 0, len(a.notarySet)),
+	}
+	s.Stopped = isStop(s.Position)
+	if a.state != nil {
+		s.State = a.state.state().String()
+	}
+	for nID := range a.notarySet {
+		s.NotarySet = append(s.NotarySet, nID)
+	}
+	sort.Sort(s.NotarySet)
+	return s
+}
+
+func (bc *blockChain) status() *BlockChainStatus {
+	bc.lock.RLock()
+	defer bc.lock.RUnlock()
+	s := &BlockChainStatus{
+		ConfirmedBlocks: make([]types.Position, 0, len(bc.confirmedBlocks)),
+		PendingBlocks:   make([]PendingBlockStatus, 0, len(bc.pendingBlocks)),
+		PendingRandomnesses: make(
+			[]types.Position, 0, len(bc.pendingRandomnesses)),
+	}
+	if bc.lastConfirmed != nil {
+		s.LastConfirmed = bc.lastConfirmed.Position
+	}
+	if bc.lastDelivered != nil {
+		s.LastDelivered = bc.lastDelivered.Position
+	}
+	for _, b := range bc.confirmedBlocks {
+		s.ConfirmedBlocks = append(s.ConfirmedBlocks, b.Position)
+	}
+	for _, r := range bc.pendingBlocks {
+		p := PendingBlockStatus{Position: r.position}
+		if r.block != nil {
+			p.Hash = r.block.Hash
+		}
+		s.PendingBlocks = append(s.PendingBlocks, p)
+	}
+	for pos := range bc.pendingRandomnesses {
+		s.PendingRandomnesses = append(s.PendingRandomnesses, pos)
+	}
+	sort.Slice(s.PendingRandomnesses, func(i, j int) bool {
+		return s.PendingRandomnesses[j].Newer(s.PendingRandomnesses[i])
+	})
+	return s
+}
+
+// updateDKGStatusNoLock refreshes the snapshot returned by dkgStatus, it
+// should be called with cc.dkgLock held whenever the DKG status changes.
+func (cc *configurationChain) updateDKGStatusNoLock() {
+	var s *DKGStatus
+	if cc.dkg != nil {
+		s = &DKGStatus{
+			Round:   cc.dkg.round,
+			Reset:   cc.dkg.reset,
+			Step:    cc.dkg.step,
+			Running: cc.dkgRunning,
+		}
+	}
+	cc.dkgStatusLock.Lock()
+	defer cc.dkgStatusLock.Unlock()
+	cc.dkgStatusSnapshot = s
+}
+
+// dkgStatus doesn't wait for cc.dkgLock, which is held for a whole DKG phase.
+func (cc *configurationChain) dkgStatus() *DKGStatus {
+	cc.dkgStatusLock.Lock()
+	defer cc.dkgStatusLock.Unlock()
+	if cc.dkgStatusSnapshot == nil {
+		return nil
+	}
+	s := *cc.dkgStatusSnapshot
+	return &s
+}
+
+func (cc *configurationChain) tsigStatus() []TSigStatus {
+	cc.tsigReady.L.Lock()
+	defer cc.tsigReady.L.Unlock()
+	s := make([]TSigStatus, 0, len(cc.tsig))
+	for hash, tsig := range cc.tsig {
+		s = append(s, TSigStatus{
+			Hash:       hash,
+			Round:      tsig.nodePublicKeys.Round,
+			Signatures: len(tsig.sigs) + len(tsig.unverified),
+			Threshold:  tsig.nodePublicKeys.Threshold,
+		})
+	}
+	sort.Slice(s, func(i, j int) bool {
+		return s[i].Hash.Less(s[j].Hash)
+	})
+	return s
+}
@@ -82,9 +82,11 @@ type Consensus struct {
 	dummyFinished      <-chan struct{}
 	dummyMsgBuffer     []types.Msg
 	initChainTipHeight uint64
+	opts               []core.ConsensusOption
 }
 
-// NewConsensus creates an instance for Consensus (syncer consensus).
+// NewConsensus creates an instance for Consensus (syncer consensus), opts are
+// passed to core.Consensus created when synced.
 func NewConsensus(
 	initHeight uint64,
 	dMoment time.Time,
@@ -93,7 +95,8 @@ func NewConsensus(
 	db db.Database,
 	network core.Network,
 	prv crypto.PrivateKey,
-	logger common.Logger) *Consensus {
+	logger common.Logger,
+	opts ...core.ConsensusOption) *Consensus {
 
 	con := &Consensus{
 		dMoment:      dMoment,
@@ -108,6 +111,7 @@ func NewConsensus(
 		receiveChan:  make(chan *types.Block, 1000),
 		pullChan:     make(chan common.Hash, 1000),
 		heightEvt:    common.NewEvent(),
+		opts:         opts,
 	}
 	con.ctx, con.ctxCancel = context.WithCancel(context.Background())
 	_, con.initChainTipHeight = db.GetCompactionChainTipInfo()
@@ -350,18 +354,16 @@ func (con *Consensus) SyncBlocks(
 		"latest", latest,
 	)
 	for _, b := range blocks {
-		if err = con.db.PutBlock(*b); err != nil {
-			// A block might be put into db when confirmed by BA, but not
-			// finalized yet.
-			if err == db.ErrBlockExists {
-				err = con.db.UpdateBlock(*b)
-			}
-			if err != nil {
-				return
-			}
+		batch := con.db.NewBatch()
+		// A block might be put into db when confirmed by BA, but not
+		// finalized yet.
+		if con.db.HasBlock(b.Hash) {
+			batch.UpdateBlock(*b)
+		} else {
+			batch.PutBlock(*b)
 		}
-		if err = con.db.PutCompactionChainTipInfo(
-			b.Hash, b.Position.Height); err != nil {
+		batch.PutCompactionChainTipInfo(b.Hash, b.Position.Height)
+		if err = batch.Commit(); err != nil {
 			return
 		}
 		con.heightEvt.NotifyHeight(b.Position.Height)
@@ -407,7 +409,8 @@ func (con *Consensus) GetSyncedConsensus() (*core.Consensus, error) {
 		con.prv,
 		con.blocks,
 		con.dummyMsgBuffer,
-		con.logger)
+		con.logger,
+		con.opts...)
 	return con.syncedConsensus, err
 }
 
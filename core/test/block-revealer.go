@@ -104,3 +104,57 @@ func (r *BlockRevealerByPosition) NextBlock() (types.Block, error) {
 func (r *BlockRevealerByPosition) Reset() {
 	r.nextRevealIndex = 0
 }
+
+// BlockRevealerByHeight implements BlockRevealer interface, which would
+// reveal blocks in the order of compaction chain via the height index of db,
+// without loading all blocks into memory.
+type BlockRevealerByHeight struct {
+	dbInst      db.Reader
+	startHeight uint64
+	nextHeight  uint64
+	revealed    bool
+	iter        db.BlockIterator
+}
+
+// NewBlockRevealerByHeight constructs a block revealer in the order of
+// compaction chain, starting from startHeight.
+func NewBlockRevealerByHeight(dbInst db.Reader, startHeight uint64) (
+	r *BlockRevealerByHeight, err error) {
+	r = &BlockRevealerByHeight{
+		dbInst:      dbInst,
+		startHeight: startHeight,
+	}
+	if err = r.reset(); err != nil {
+		r = nil
+	}
+	return
+}
+
+func (r *BlockRevealerByHeight) reset() (err error) {
+	r.revealed = false
+	r.iter, err = r.dbInst.GetBlocksFromHeight(r.startHeight)
+	return
+}
+
+// NextBlock implements Revealer.Next method, which would reveal blocks in the
+// order of compaction chain. ErrNotValidCompactionChain is returned when the
+// heights of blocks are not continuous.
+func (r *BlockRevealerByHeight) NextBlock() (types.Block, error) {
+	b, err := r.iter.NextBlock()
+	if err != nil {
+		return b, err
+	}
+	if r.revealed && b.Position.Height != r.nextHeight {
+		return types.Block{}, ErrNotValidCompactionChain
+	}
+	r.revealed = true
+	r.nextHeight = b.Position.Height + 1
+	return b, nil
+}
+
+// Reset implement Revealer.Reset method, which would reset revealing.
+func (r *BlockRevealerByHeight) Reset() {
+	if err := r.reset(); err != nil {
+		panic(err)
+	}
+}
@@ -85,6 +85,44 @@ func (s *BlockRevealerTestSuite) TestBlockRevealByPosition() {
 	chk(3)
 }
 
+func (s *BlockRevealerTestSuite) TestBlockRevealByHeight() {
+	dbInst, err := db.NewMemBackedDB()
+	s.Require().NoError(err)
+	for _, h := range []uint64{1, 3} {
+		s.Require().NoError(dbInst.PutBlock(types.Block{
+			Hash:     common.NewRandomHash(),
+			Position: types.Position{Height: h},
+		}))
+	}
+	r, err := NewBlockRevealerByHeight(dbInst, 0)
+	s.Require().NoError(err)
+	chk := func(h uint64) {
+		b, err := r.NextBlock()
+		s.Require().NoError(err)
+		s.Require().Equal(h, b.Position.Height)
+	}
+	// The compaction chain is not complete.
+	chk(1)
+	_, err = r.NextBlock()
+	s.Require().Equal(ErrNotValidCompactionChain, err)
+	// Put a block to make the compaction chain complete.
+	s.Require().NoError(dbInst.PutBlock(types.Block{
+		Hash:     common.NewRandomHash(),
+		Position: types.Position{Height: 2},
+	}))
+	r.Reset()
+	chk(1)
+	chk(2)
+	chk(3)
+	_, err = r.NextBlock()
+	s.Require().Equal(db.ErrIterationFinished, err)
+	// Test 'startHeight' parameter.
+	r, err = NewBlockRevealerByHeight(dbInst, 2)
+	s.Require().NoError(err)
+	chk(2)
+	chk(3)
+}
+
 func TestBlockRevealer(t *testing.T) {
 	suite.Run(t, new(BlockRevealerTestSuite))
 }
@@ -0,0 +1,185 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package test
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the same as core.Clock, it's declared here because this package
+// can't import core.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	Sleep(d time.Duration)
+}
+
+// systemClock implements Clock by wall clock.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (systemClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+type fakeClockWaiter struct {
+	until time.Time
+	ch    chan time.Time
+}
+
+// FakeClock implements core.Clock with virtual time, which only moves when
+// advanced explicitly.
+type FakeClock struct {
+	lock    sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeClockWaiter
+	// added counts waiters ever added, it's used to find out if routines
+	// waiting on this clock are still busy.
+	added uint64
+}
+
+// NewFakeClock constructs a FakeClock instance starting from now.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.lock)
+	return c
+}
+
+// Now implements core.Clock interface.
+func (c *FakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+// After implements core.Clock interface.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, &fakeClockWaiter{
+		until: c.now.Add(d),
+		ch:    ch,
+	})
+	c.added++
+	sort.SliceStable(c.waiters, func(i, j int) bool {
+		return c.waiters[i].until.Before(c.waiters[j].until)
+	})
+	c.cond.Broadcast()
+	return ch
+}
+
+// Sleep implements core.Clock interface.
+func (c *FakeClock) Sleep(d time.Duration) {
+	<-c.After(d)
+}
+
+// Advance moves the virtual time forward by d and wakes up all waiters whose
+// deadline is reached.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.setWithoutLock(c.now.Add(d))
+}
+
+// AdvanceToNext moves the virtual time to the earliest deadline of waiters,
+// it returns false when there is no waiter.
+func (c *FakeClock) AdvanceToNext() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.waiters) == 0 {
+		return false
+	}
+	c.setWithoutLock(c.waiters[0].until)
+	return true
+}
+
+// Waiters returns the count of pending waiters.
+func (c *FakeClock) Waiters() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.waiters)
+}
+
+// BlockUntil blocks until there are at least n pending waiters, which could
+// be used to make sure routines are waiting before advancing the time.
+func (c *FakeClock) BlockUntil(n int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
+
+// Drive moves the clock to the earliest deadline of waiters whenever no
+// waiter is added for idle of wall clock time, until ctx is done. Routines
+// waiting on this clock are considered blocked by then, so a whole system
+// driven by this clock runs as fast as it can process, instead of at the pace
+// of wall clock. Waiters with the same deadline are woken up in the order
+// they're added.
+func (c *FakeClock) Drive(ctx context.Context, idle time.Duration) {
+	ticker := time.NewTicker(idle)
+	defer ticker.Stop()
+	c.lock.Lock()
+	added := c.added
+	c.lock.Unlock()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		c.lock.Lock()
+		if c.added == added && len(c.waiters) > 0 {
+			c.setWithoutLock(c.waiters[0].until)
+		}
+		added = c.added
+		c.lock.Unlock()
+	}
+}
+
+func (c *FakeClock) setWithoutLock(now time.Time) {
+	if now.Before(c.now) {
+		return
+	}
+	c.now = now
+	idx := 0
+	for ; idx < len(c.waiters); idx++ {
+		w := c.waiters[idx]
+		if w.until.After(now) {
+			break
+		}
+		w.ch <- now
+	}
+	c.waiters = c.waiters[idx:]
+}
@@ -0,0 +1,107 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/core"
+)
+
+type FakeClockTestSuite struct {
+	suite.Suite
+}
+
+func (s *FakeClockTestSuite) TestAdvance() {
+	start := time.Now()
+	var c core.Clock = NewFakeClock(start)
+	clock := c.(*FakeClock)
+	ch1 := c.After(2 * time.Second)
+	ch2 := c.After(time.Second)
+	s.Require().Equal(2, clock.Waiters())
+	// Non-positive durations are fired immediately.
+	s.Require().Equal(start, <-c.After(0))
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case <-ch1:
+		s.FailNow("should not be fired")
+	case <-ch2:
+		s.FailNow("should not be fired")
+	default:
+	}
+	clock.Advance(500 * time.Millisecond)
+	s.Require().Equal(start.Add(time.Second), <-ch2)
+	s.Require().Equal(1, clock.Waiters())
+	s.Require().True(clock.AdvanceToNext())
+	s.Require().Equal(start.Add(2*time.Second), <-ch1)
+	s.Require().Equal(start.Add(2*time.Second), c.Now())
+	s.Require().False(clock.AdvanceToNext())
+}
+
+func (s *FakeClockTestSuite) TestSleep() {
+	clock := NewFakeClock(time.Now())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		clock.Sleep(time.Hour)
+	}()
+	clock.BlockUntil(1)
+	clock.Advance(time.Hour)
+	<-done
+}
+
+func (s *FakeClockTestSuite) TestDrive() {
+	start := time.Now()
+	clock := NewFakeClock(start)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		clock.Drive(ctx, time.Millisecond)
+	}()
+	// Hours of virtual time pass without waiting for them.
+	begin := time.Now()
+	for i := 1; i <= 10; i++ {
+		s.Require().Equal(start.Add(time.Duration(i)*time.Hour),
+			<-clock.After(time.Hour))
+	}
+	s.Require().True(time.Since(begin) < 5*time.Second)
+	// The clock stops when there is no waiter.
+	now := clock.Now()
+	time.Sleep(10 * time.Millisecond)
+	s.Require().Equal(now, clock.Now())
+	// Waiters are woken up in the order of their deadlines.
+	ch1 := clock.After(2 * time.Minute)
+	ch2 := clock.After(time.Minute)
+	s.Require().Equal(now.Add(time.Minute), <-ch2)
+	s.Require().Equal(now.Add(2*time.Minute), <-ch1)
+	cancel()
+	<-done
+	now = clock.Now()
+	clock.After(time.Minute)
+	time.Sleep(10 * time.Millisecond)
+	s.Require().Equal(now, clock.Now())
+}
+
+func TestFakeClock(t *testing.T) {
+	suite.Run(t, new(FakeClockTestSuite))
+}
@@ -45,6 +45,7 @@ type FakeTransport struct {
 	serverChannel chan<- *TransportEnvelope
 	peers         map[types.NodeID]fakePeerRecord
 	dMoment       time.Time
+	clock         Clock
 }
 
 // NewFakeTransportServer constructs FakeTransport instance for peer server.
@@ -52,6 +53,7 @@ func NewFakeTransportServer() TransportServer {
 	return &FakeTransport{
 		peerType:    TransportPeerServer,
 		recvChannel: make(chan *TransportEnvelope, 1000),
+		clock:       systemClock{},
 	}
 }
 
@@ -62,9 +64,19 @@ func NewFakeTransportClient(pubKey crypto.PublicKey) TransportClient {
 		recvChannel: make(chan *TransportEnvelope, 1000),
 		nID:         types.NewNodeID(pubKey),
 		pubKey:      pubKey,
+		clock:       systemClock{},
 	}
 }
 
+// SetClock sets the clock to wait for latencies of messages, wall clock is
+// used by default.
+func (t *FakeTransport) SetClock(clock Clock) {
+	if clock == nil {
+		return
+	}
+	t.clock = clock
+}
+
 // Disconnect implements Transport.Disconnect method.
 func (t *FakeTransport) Disconnect(endpoint types.NodeID) {
 	delete(t.peers, endpoint)
@@ -108,7 +120,7 @@ func (t *FakeTransport) Broadcast(endpoints map[types.NodeID]struct{},
 			continue
 		}
 		go func(nID types.NodeID) {
-			time.Sleep(latency.Delay())
+			t.clock.Sleep(latency.Delay())
 			// #nosec G104
 			t.Send(nID, msg)
 		}(ID)
@@ -364,6 +364,17 @@ func (g *Governance) CatchUpWithRound(round uint64) {
 	defer g.lock.Unlock()
 	for uint64(len(g.configs)) <= round {
 		config, nodeSet := g.stateModule.Snapshot()
+		// Nodes scheduled to exit are excluded from node set of that round.
+		exited := g.stateModule.exitedNodes(uint64(len(g.configs)))
+		if len(exited) > 0 {
+			remains := make([]crypto.PublicKey, 0, len(nodeSet))
+			for _, key := range nodeSet {
+				if _, exists := exited[types.NewNodeID(key)]; !exists {
+					remains = append(remains, key)
+				}
+			}
+			nodeSet = remains
+		}
 		g.configs = append(g.configs, config)
 		g.nodeSets = append(g.nodeSets, nodeSet)
 	}
@@ -504,6 +515,25 @@ func (g *Governance) RegisterConfigChange(
 	return nil
 }
 
+// ScheduleNodeExit tells this governance instance to remove a node from node
+// sets from some round, exits can't be scheduled for genesis rounds.
+func (g *Governance) ScheduleNodeExit(
+	key crypto.PublicKey, round uint64) error {
+	if round < 2 {
+		return errors.New("attempt to schedule node exit for genesis rounds")
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if round < uint64(len(g.configs)) {
+		return errors.New("attempt to schedule node exit for prepared rounds")
+	}
+	if err := g.stateModule.ScheduleNodeExit(key, round); err != nil {
+		return err
+	}
+	g.broadcastPendingStateChanges()
+	return nil
+}
+
 // SwitchToRemoteMode would switch this governance instance to remote mode,
 // which means: it will broadcast all changes from its underlying state
 // instance.
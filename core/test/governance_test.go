@@ -115,6 +115,41 @@ func (s *GovernanceTestSuite) TestRegisterChange() {
 	req.Equal(g.Configuration(7).NotarySetSize, uint32(40))
 }
 
+func (s *GovernanceTestSuite) TestScheduleNodeExit() {
+	var (
+		req                = s.Require()
+		roundLength uint64 = 100
+	)
+	_, genesisNodes, err := NewKeys(7)
+	req.NoError(err)
+	g, err := NewGovernance(NewState(
+		1, genesisNodes, 100*time.Millisecond, &common.NullLogger{}, true), 2)
+	req.NoError(err)
+	req.NoError(g.State().RequestChange(StateChangeRoundLength,
+		uint64(roundLength)))
+	req.Error(g.ScheduleNodeExit(genesisNodes[0], 1))
+	g.CatchUpWithRound(3)
+	req.Error(g.ScheduleNodeExit(genesisNodes[0], 3))
+	req.NoError(g.ScheduleNodeExit(genesisNodes[0], 4))
+	req.NoError(g.ScheduleNodeExit(genesisNodes[1], 5))
+	g.NotifyRound(2, roundLength*2)
+	g.NotifyRound(3, roundLength*3)
+	exited := func(round uint64, key crypto.PublicKey) bool {
+		for _, k := range g.NodeSet(round) {
+			if types.NewNodeID(k) == types.NewNodeID(key) {
+				return false
+			}
+		}
+		return true
+	}
+	req.Len(g.NodeSet(3), 7)
+	req.Len(g.NodeSet(4), 6)
+	req.True(exited(4, genesisNodes[0]))
+	req.Len(g.NodeSet(5), 5)
+	req.True(exited(5, genesisNodes[0]))
+	req.True(exited(5, genesisNodes[1]))
+}
+
 func (s *GovernanceTestSuite) TestProhibit() {
 	round := uint64(1)
 	prvKeys, genesisNodes, err := NewKeys(4)
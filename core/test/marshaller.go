@@ -21,18 +21,21 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"github.com/dexon-foundation/dexon-consensus/core/types"
-	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/codec"
 )
 
 // DefaultMarshaller is the default marshaller for testing core.Consensus.
+// Messages of core.Consensus are encoded by codec.Codec, messages between
+// test modules are encoded in JSON.
 type DefaultMarshaller struct {
+	codec    *codec.Codec
 	fallback Marshaller
 }
 
 // NewDefaultMarshaller constructs an DefaultMarshaller instance.
 func NewDefaultMarshaller(fallback Marshaller) *DefaultMarshaller {
 	return &DefaultMarshaller{
+		codec:    codec.NewCodec(0),
 		fallback: fallback,
 	}
 }
@@ -41,54 +44,6 @@ func NewDefaultMarshaller(fallback Marshaller) *DefaultMarshaller {
 func (m *DefaultMarshaller) Unmarshal(
 	msgType string, payload []byte) (msg interface{}, err error) {
 	switch msgType {
-	case "block":
-		block := &types.Block{}
-		if err = json.Unmarshal(payload, block); err != nil {
-			break
-		}
-		msg = block
-	case "vote":
-		vote := &types.Vote{}
-		if err = json.Unmarshal(payload, vote); err != nil {
-			break
-		}
-		msg = vote
-	case "agreement-result":
-		result := &types.AgreementResult{}
-		if err = json.Unmarshal(payload, result); err != nil {
-			break
-		}
-		msg = result
-	case "dkg-private-share":
-		privateShare := &typesDKG.PrivateShare{}
-		if err = json.Unmarshal(payload, privateShare); err != nil {
-			break
-		}
-		msg = privateShare
-	case "dkg-master-public-key":
-		masterPublicKey := typesDKG.NewMasterPublicKey()
-		if err = json.Unmarshal(payload, masterPublicKey); err != nil {
-			break
-		}
-		msg = masterPublicKey
-	case "dkg-complaint":
-		complaint := &typesDKG.Complaint{}
-		if err = json.Unmarshal(payload, complaint); err != nil {
-			break
-		}
-		msg = complaint
-	case "dkg-partial-signature":
-		psig := &typesDKG.PartialSignature{}
-		if err = json.Unmarshal(payload, psig); err != nil {
-			break
-		}
-		msg = psig
-	case "dkg-finalize":
-		final := &typesDKG.Finalize{}
-		if err = json.Unmarshal(payload, final); err != nil {
-			break
-		}
-		msg = final
 	case "packed-state-changes":
 		packed := &packedStateChanges{}
 		if err = json.Unmarshal(payload, packed); err != nil {
@@ -102,6 +57,10 @@ func (m *DefaultMarshaller) Unmarshal(
 		}
 		msg = req
 	default:
+		msg, err = m.codec.Unmarshal(msgType, payload)
+		if err != codec.ErrUnknownMessageType {
+			break
+		}
 		if m.fallback == nil {
 			err = fmt.Errorf("unknown msg type: %v", msgType)
 			break
@@ -115,30 +74,6 @@ func (m *DefaultMarshaller) Unmarshal(
 func (m *DefaultMarshaller) Marshal(
 	msg interface{}) (msgType string, payload []byte, err error) {
 	switch msg.(type) {
-	case *types.Block:
-		msgType = "block"
-		payload, err = json.Marshal(msg)
-	case *types.Vote:
-		msgType = "vote"
-		payload, err = json.Marshal(msg)
-	case *types.AgreementResult:
-		msgType = "agreement-result"
-		payload, err = json.Marshal(msg)
-	case *typesDKG.PrivateShare:
-		msgType = "dkg-private-share"
-		payload, err = json.Marshal(msg)
-	case *typesDKG.MasterPublicKey:
-		msgType = "dkg-master-public-key"
-		payload, err = json.Marshal(msg)
-	case *typesDKG.Complaint:
-		msgType = "dkg-complaint"
-		payload, err = json.Marshal(msg)
-	case *typesDKG.PartialSignature:
-		msgType = "dkg-partial-signature"
-		payload, err = json.Marshal(msg)
-	case *typesDKG.Finalize:
-		msgType = "dkg-finalize"
-		payload, err = json.Marshal(msg)
 	case packedStateChanges:
 		msgType = "packed-state-changes"
 		payload, err = json.Marshal(msg)
@@ -146,6 +81,10 @@ func (m *DefaultMarshaller) Marshal(
 		msgType = "pull-request"
 		payload, err = json.Marshal(msg)
 	default:
+		msgType, payload, err = m.codec.Marshal(msg)
+		if err != codec.ErrUnknownMessageType {
+			break
+		}
 		if m.fallback == nil {
 			err = fmt.Errorf("unknwon message type: %v", msg)
 			break
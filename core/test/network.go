@@ -63,6 +63,11 @@ type NetworkConfig struct {
 	DirectLatency LatencyModel
 	GossipLatency LatencyModel
 	Marshaller    Marshaller
+	// Logger receives errors of TCP connections, it's optional.
+	Logger common.Logger
+	// Clock is used to wait for latencies of messages, wall clock is used if
+	// it's nil.
+	Clock Clock
 }
 
 // PullRequest is a generic request to pull everything (ex. vote, block...).
@@ -176,6 +181,7 @@ type Network struct {
 	config               NetworkConfig
 	ctx                  context.Context
 	ctxCancel            context.CancelFunc
+	clock                Clock
 	trans                *censorClient
 	dMoment              time.Time
 	fromTransport        <-chan *TransportEnvelope
@@ -203,8 +209,9 @@ type Network struct {
 
 // NewNetwork setup network stuffs for nodes, which provides an
 // implementation of core.Network based on TransportClient.
-func NewNetwork(pubKey crypto.PublicKey, config NetworkConfig) (
+func NewNetwork(prvKey crypto.PrivateKey, config NetworkConfig) (
 	n *Network) {
+	pubKey := prvKey.PublicKey()
 	// Construct basic network instance.
 	n = &Network{
 		ID:               types.NewNodeID(pubKey),
@@ -220,17 +227,25 @@ func NewNetwork(pubKey crypto.PublicKey, config NetworkConfig) (
 		voteCache: make(
 			map[types.Position]map[types.VoteHeader]*types.Vote),
 		censor: &dummyCensor{},
+		clock:  config.Clock,
+	}
+	if n.clock == nil {
+		n.clock = systemClock{}
 	}
 	n.ctx, n.ctxCancel = context.WithCancel(context.Background())
 	// Construct transport layer.
 	var trans TransportClient
 	switch config.Type {
-	case NetworkTypeTCPLocal:
-		trans = NewTCPTransportClient(pubKey, config.Marshaller, true)
-	case NetworkTypeTCP:
-		trans = NewTCPTransportClient(pubKey, config.Marshaller, false)
+	case NetworkTypeTCPLocal, NetworkTypeTCP:
+		tcpTrans := NewTCPTransportClient(
+			prvKey, config.Marshaller, config.Type == NetworkTypeTCPLocal)
+		tcpTrans.SetLogger(config.Logger)
+		tcpTrans.SetClock(n.clock)
+		trans = tcpTrans
 	case NetworkTypeFake:
-		trans = NewFakeTransportClient(pubKey)
+		fakeTrans := NewFakeTransportClient(pubKey).(*FakeTransport)
+		fakeTrans.SetClock(n.clock)
+		trans = fakeTrans
 	default:
 		panic(fmt.Errorf("unknown network type: %v", config.Type))
 	}
@@ -349,6 +364,14 @@ func (n *Network) BroadcastDKGPartialSignature(
 	}
 }
 
+// BroadcastEvidence implements core.EvidenceBroadcaster interface.
+func (n *Network) BroadcastEvidence(evidence *types.Evidence) {
+	if err := n.trans.Broadcast(
+		n.peers, n.config.DirectLatency, evidence); err != nil {
+		panic(err)
+	}
+}
+
 // ReceiveChan implements core.Network interface.
 func (n *Network) ReceiveChan() <-chan types.Msg {
 	return n.toConsensus
@@ -409,7 +432,7 @@ func (n *Network) dispatchMsg(e *TransportEnvelope) {
 			PeerID:  e.From,
 			Payload: v,
 		}
-	case *types.AgreementResult,
+	case *types.AgreementResult, *types.Evidence,
 		*typesDKG.PrivateShare, *typesDKG.PartialSignature:
 		n.toConsensus <- types.Msg{
 			PeerID:  e.From,
@@ -586,7 +609,7 @@ Loop:
 		select {
 		case <-n.ctx.Done():
 			break Loop
-		case <-time.After(2 * n.config.DirectLatency.Delay()):
+		case <-n.clock.After(2 * n.config.DirectLatency.Delay()):
 			// Consume everything in the notification channel.
 			for {
 				select {
@@ -698,6 +721,8 @@ func (n *Network) cloneForFake(v interface{}) interface{} {
 	case *types.AgreementResult:
 		// Perform deep copy for randomness result.
 		return cloneAgreementResult(val)
+	case *types.Evidence:
+		return val.Clone()
 	}
 	return v
 }
@@ -725,7 +750,7 @@ func (n *Network) getNotarySet(round uint64) map[types.NodeID]struct{} {
 
 func (n *Network) send(endpoint types.NodeID, msg interface{}) {
 	go func() {
-		time.Sleep(n.config.DirectLatency.Delay())
+		n.clock.Sleep(n.config.DirectLatency.Delay())
 		if err := n.trans.Send(endpoint, msg); err != nil {
 			panic(err)
 		}
@@ -38,7 +38,7 @@ type NetworkTestSuite struct {
 }
 
 func (s *NetworkTestSuite) setupNetworks(
-	pubKeys []crypto.PublicKey) map[types.NodeID]*Network {
+	prvKeys []crypto.PrivateKey) map[types.NodeID]*Network {
 	var (
 		server = NewFakeTransportServer()
 		wg     sync.WaitGroup
@@ -47,7 +47,7 @@ func (s *NetworkTestSuite) setupNetworks(
 	s.Require().NoError(err)
 	// Setup several network modules.
 	networks := make(map[types.NodeID]*Network)
-	for _, key := range pubKeys {
+	for _, key := range prvKeys {
 		n := NewNetwork(key, NetworkConfig{
 			Type:          NetworkTypeFake,
 			DirectLatency: &FixedLatencyModel{},
@@ -61,7 +61,7 @@ func (s *NetworkTestSuite) setupNetworks(
 			go n.Run()
 		}()
 	}
-	s.Require().NoError(server.WaitForPeers(uint32(len(pubKeys))))
+	s.Require().NoError(server.WaitForPeers(uint32(len(prvKeys))))
 	wg.Wait()
 	return networks
 }
@@ -110,9 +110,9 @@ func (s *NetworkTestSuite) TestPullBlocks() {
 		peerCount = 10
 		req       = s.Require()
 	)
-	_, pubKeys, err := NewKeys(peerCount)
+	prvKeys, _, err := NewKeys(peerCount)
 	req.NoError(err)
-	networks := s.setupNetworks(pubKeys)
+	networks := s.setupNetworks(prvKeys)
 	// Generate several random hashes.
 	hashes := common.Hashes{}
 	for range networks {
@@ -170,9 +170,9 @@ func (s *NetworkTestSuite) TestPullVotes() {
 		voteTestCount = maxVoteCache / 2
 		req           = s.Require()
 	)
-	_, pubKeys, err := NewKeys(peerCount)
+	prvKeys, _, err := NewKeys(peerCount)
 	req.NoError(err)
-	networks := s.setupNetworks(pubKeys)
+	networks := s.setupNetworks(prvKeys)
 	// Randomly pick one network instance as master.
 	var master *Network
 	for _, master = range networks {
@@ -246,7 +246,7 @@ func (s *NetworkTestSuite) TestBroadcastToSet() {
 		peerCount = 5
 		round     = uint64(1)
 	)
-	_, pubKeys, err := NewKeys(peerCount)
+	prvKeys, pubKeys, err := NewKeys(peerCount)
 	req.NoError(err)
 	gov, err := NewGovernance(NewState(
 		1, pubKeys, time.Second, &common.NullLogger{}, true), 2)
@@ -254,7 +254,7 @@ func (s *NetworkTestSuite) TestBroadcastToSet() {
 	req.NoError(gov.State().RequestChange(StateChangeNotarySetSize, uint32(1)))
 	gov.NotifyRound(round,
 		utils.GetRoundHeight(gov, 0)+gov.Configuration(0).RoundLength)
-	networks := s.setupNetworks(pubKeys)
+	networks := s.setupNetworks(prvKeys)
 	cache := utils.NewNodeSetCache(gov)
 	// Cache required set of nodeIDs.
 	notarySet, err := cache.GetNotarySet(round)
@@ -310,9 +310,9 @@ func (s *NetworkTestSuite) TestCensor() {
 		req       = s.Require()
 		peerCount = 5
 	)
-	_, pubKeys, err := NewKeys(peerCount)
+	prvKeys, pubKeys, err := NewKeys(peerCount)
 	req.NoError(err)
-	networks := s.setupNetworks(pubKeys)
+	networks := s.setupNetworks(prvKeys)
 	receiveChans := make(map[types.NodeID]<-chan types.Msg, peerCount)
 	for nID, node := range networks {
 		receiveChans[nID] = node.ReceiveChan()
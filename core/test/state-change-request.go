@@ -50,6 +50,8 @@ const (
 	StateChangeNotarySetSize
 	// Node set related.
 	StateAddNode
+	StateRemoveNode
+	StateScheduleNodeExit
 )
 
 func (t StateChangeType) String() string {
@@ -78,6 +80,10 @@ func (t StateChangeType) String() string {
 		return "ChangeNotarySetSize"
 	case StateAddNode:
 		return "AddNode"
+	case StateRemoveNode:
+		return "RemoveNode"
+	case StateScheduleNodeExit:
+		return "ScheduleNodeExit"
 	}
 	panic(fmt.Errorf("attempting to dump unknown type of state change: %d", t))
 }
@@ -137,6 +143,17 @@ func (req *StateChangeRequest) Clone() (copied *StateChangeRequest) {
 		copiedBytes := make([]byte, len(srcBytes))
 		copy(copiedBytes, srcBytes)
 		req.Payload = copiedBytes
+	case StateRemoveNode:
+		srcBytes := req.Payload.([]byte)
+		copiedBytes := make([]byte, len(srcBytes))
+		copy(copiedBytes, srcBytes)
+		copied.Payload = copiedBytes
+	case StateScheduleNodeExit:
+		exitReq := req.Payload.(*nodeExitRequest)
+		copied.Payload = &nodeExitRequest{
+			Round: exitReq.Round,
+			Key:   append([]byte(nil), exitReq.Key...),
+		}
 	case StateAddCRS:
 		crsReq := req.Payload.(*crsAdditionRequest)
 		copied.Payload = &crsAdditionRequest{
@@ -196,6 +213,13 @@ func (req *StateChangeRequest) String() (ret string) {
 	case StateAddNode:
 		ret += fmt.Sprintf(
 			"%s", types.NewNodeID(req.Payload.(crypto.PublicKey)).String()[:6])
+	case StateRemoveNode:
+		ret += fmt.Sprintf(
+			"%s", nodeIDFromBytes(req.Payload.([]byte)).String()[:6])
+	case StateScheduleNodeExit:
+		exitReq := req.Payload.(*nodeExitRequest)
+		ret += fmt.Sprintf("Round:%v Node:%s", exitReq.Round,
+			nodeIDFromBytes(exitReq.Key).String()[:6])
 	default:
 		panic(fmt.Errorf(
 			"attempting to dump unknown type of state change request: %v",
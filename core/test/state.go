@@ -91,6 +91,21 @@ type crsAdditionRequest struct {
 	CRS   common.Hash `json:"crs"`
 }
 
+type nodeExitRequest struct {
+	Round uint64 `json:"round"`
+	Key   []byte `json:"key"`
+}
+
+// nodeIDFromBytes converts a public key in bytes, which is how keys are
+// carried in state change requests, to node ID.
+func nodeIDFromBytes(b []byte) types.NodeID {
+	pubKey, err := ecdsa.NewPublicKeyFromByteSlice(b)
+	if err != nil {
+		return types.NodeID{}
+	}
+	return types.NewNodeID(pubKey)
+}
+
 // State emulates what the global state in governace contract on a fullnode.
 type State struct {
 	// Configuration related.
@@ -101,6 +116,8 @@ type State struct {
 	minBlockInterval time.Duration
 	// Nodes
 	nodes map[types.NodeID]crypto.PublicKey
+	// The round from which a node is no longer in node set.
+	nodeExits map[types.NodeID]uint64
 	// DKG & CRS
 	dkgComplaints       map[uint64]map[types.NodeID][]*typesDKG.Complaint
 	dkgMasterPublicKeys map[uint64]map[types.NodeID]*typesDKG.MasterPublicKey
@@ -147,6 +164,7 @@ func NewState(
 		minBlockInterval: 4 * lambda,
 		crs:              crs,
 		nodes:            nodes,
+		nodeExits:        make(map[types.NodeID]uint64),
 		notarySetSize:    uint32(len(nodes)),
 		ownRequests:      make(map[common.Hash]*StateChangeRequest),
 		globalRequests:   make(map[common.Hash]*StateChangeRequest),
@@ -194,6 +212,19 @@ func (s *State) Snapshot() (*types.Config, []crypto.PublicKey) {
 	return cfg, nodes
 }
 
+// exitedNodes returns nodes which are no longer in node set of that round.
+func (s *State) exitedNodes(round uint64) map[types.NodeID]struct{} {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	exited := make(map[types.NodeID]struct{})
+	for nID, exitRound := range s.nodeExits {
+		if round >= exitRound {
+			exited[nID] = struct{}{}
+		}
+	}
+	return exited
+}
+
 // AttachLogger allows to attach custom logger.
 func (s *State) AttachLogger(logger common.Logger) {
 	s.logger = logger
@@ -244,10 +275,13 @@ func (s *State) unpackPayload(
 		var tmp uint32
 		err = rlp.DecodeBytes(raw.Payload, &tmp)
 		v = tmp
-	case StateAddNode:
+	case StateAddNode, StateRemoveNode:
 		var tmp []byte
 		err = rlp.DecodeBytes(raw.Payload, &tmp)
 		v = tmp
+	case StateScheduleNodeExit:
+		v = &nodeExitRequest{}
+		err = rlp.DecodeBytes(raw.Payload, v)
 	default:
 		err = ErrUnknownStateChangeType
 	}
@@ -307,6 +341,15 @@ func (s *State) Equal(other *State) error {
 			return ErrStateNodeSetNotEqual
 		}
 	}
+	if len(s.nodeExits) != len(other.nodeExits) {
+		return ErrStateNodeSetNotEqual
+	}
+	for nID, round := range s.nodeExits {
+		if otherRound, exists := other.nodeExits[nID]; !exists ||
+			round != otherRound {
+			return ErrStateNodeSetNotEqual
+		}
+	}
 	// Check DKG Complaints, here I assume the addition sequence of complaints
 	// proposed by one node would be identical on each node (this should be true
 	// when state change requests are carried by blocks and executed in order).
@@ -480,6 +523,7 @@ func (s *State) Clone() (copied *State) {
 		local:            s.local,
 		logger:           s.logger,
 		nodes:            make(map[types.NodeID]crypto.PublicKey),
+		nodeExits:        make(map[types.NodeID]uint64),
 		dkgComplaints: make(
 			map[uint64]map[types.NodeID][]*typesDKG.Complaint),
 		dkgMasterPublicKeys: make(
@@ -493,6 +537,9 @@ func (s *State) Clone() (copied *State) {
 	for nID, key := range s.nodes {
 		copied.nodes[nID] = key
 	}
+	for nID, round := range s.nodeExits {
+		copied.nodeExits[nID] = round
+	}
 	// DKG & CRS
 	for round, complaintsForRound := range s.dkgComplaints {
 		copied.dkgComplaints[round] =
@@ -735,6 +782,21 @@ func (s *State) isValidRequest(req *StateChangeRequest) error {
 		} else {
 			return ErrMissingPreviousCRS
 		}
+	case StateRemoveNode:
+		// Removing a node not in node set changes nothing.
+		nID := nodeIDFromBytes(req.Payload.([]byte))
+		if _, exists := s.nodes[nID]; !exists {
+			return ErrDuplicatedChange
+		}
+	case StateScheduleNodeExit:
+		exitReq := req.Payload.(*nodeExitRequest)
+		nID := nodeIDFromBytes(exitReq.Key)
+		if _, exists := s.nodes[nID]; !exists {
+			return ErrDuplicatedChange
+		}
+		if round, exists := s.nodeExits[nID]; exists && round == exitReq.Round {
+			return ErrDuplicatedChange
+		}
 	case StateResetDKG:
 		newCRS := req.Payload.(common.Hash)
 		if s.crs[len(s.crs)-1].Equal(newCRS) {
@@ -757,6 +819,13 @@ func (s *State) applyRequest(req *StateChangeRequest) error {
 			return err
 		}
 		s.nodes[types.NewNodeID(pubKey)] = pubKey
+	case StateRemoveNode:
+		nID := nodeIDFromBytes(req.Payload.([]byte))
+		delete(s.nodes, nID)
+		delete(s.nodeExits, nID)
+	case StateScheduleNodeExit:
+		exitReq := req.Payload.(*nodeExitRequest)
+		s.nodeExits[nodeIDFromBytes(exitReq.Key)] = exitReq.Round
 	case StateAddCRS:
 		crsRequest := req.Payload.(*crsAdditionRequest)
 		if crsRequest.Round != uint64(len(s.crs)) {
@@ -831,13 +900,22 @@ func (s *State) ProposeCRS(round uint64, crs common.Hash) (err error) {
 	return
 }
 
+// ScheduleNodeExit requests to remove a node from node sets of rounds no
+// earlier than the given round.
+func (s *State) ScheduleNodeExit(key crypto.PublicKey, round uint64) error {
+	return s.RequestChange(StateScheduleNodeExit, &nodeExitRequest{
+		Round: round,
+		Key:   key.Bytes(),
+	})
+}
+
 // RequestChange submits a state change request.
 func (s *State) RequestChange(
 	t StateChangeType, payload interface{}) (err error) {
 	s.logger.Info("Request Change to State", "type", t, "value", payload)
 	// Patch input parameter's type.
 	switch t {
-	case StateAddNode:
+	case StateAddNode, StateRemoveNode:
 		payload = payload.(crypto.PublicKey).Bytes()
 	case StateChangeLambdaBA,
 		StateChangeLambdaDKG,
@@ -846,6 +924,8 @@ func (s *State) RequestChange(
 	// These cases for for type assertion, make sure callers pass expected types.
 	case StateAddCRS:
 		payload = payload.(*crsAdditionRequest)
+	case StateScheduleNodeExit:
+		payload = payload.(*nodeExitRequest)
 	case StateAddDKGMPKReady:
 		payload = payload.(*typesDKG.MPKReady)
 	case StateAddDKGFinal:
@@ -451,6 +451,42 @@ func (s *StateTestSuite) TestRequestBroadcastAndPack() {
 	req.NoError(st.Equal(st1))
 }
 
+func (s *StateTestSuite) TestRemoveNode() {
+	var (
+		req    = s.Require()
+		lambda = 250 * time.Millisecond
+	)
+	_, genesisNodes, err := NewKeys(6)
+	req.NoError(err)
+	// Local mode.
+	st := NewState(1, genesisNodes, lambda, &common.NullLogger{}, true)
+	req.NoError(st.RequestChange(StateRemoveNode, genesisNodes[0]))
+	_, nodes := st.Snapshot()
+	req.Len(nodes, 5)
+	req.False(s.findNode(nodes, genesisNodes[0]))
+	// Removing a removed node changes nothing.
+	req.Equal(ErrDuplicatedChange,
+		st.RequestChange(StateRemoveNode, genesisNodes[0]))
+	req.NoError(st.ScheduleNodeExit(genesisNodes[1], 3))
+	_, nodes = st.Snapshot()
+	req.True(s.findNode(nodes, genesisNodes[1]))
+	req.Empty(st.exitedNodes(2))
+	req.Contains(st.exitedNodes(3), types.NewNodeID(genesisNodes[1]))
+	req.Contains(st.exitedNodes(4), types.NewNodeID(genesisNodes[1]))
+	// Remote mode, changes are applied after packed.
+	st1 := NewState(1, genesisNodes, lambda, &common.NullLogger{}, false)
+	req.NoError(st1.RequestChange(StateRemoveNode, genesisNodes[0]))
+	req.NoError(st1.ScheduleNodeExit(genesisNodes[1], 3))
+	req.Empty(st1.exitedNodes(3))
+	packed, err := st1.PackOwnRequests()
+	req.NoError(err)
+	req.NoError(st1.Apply(packed))
+	_, nodes1 := st1.Snapshot()
+	req.True(s.compareNodes(nodes, nodes1))
+	req.Equal(st.exitedNodes(3), st1.exitedNodes(3))
+	req.NoError(st1.Equal(st1.Clone()))
+}
+
 func (s *StateTestSuite) TestUnmatchedResetCount() {
 	_, genesisNodes, err := NewKeys(20)
 	s.Require().NoError(err)
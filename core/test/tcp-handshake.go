@@ -0,0 +1,67 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package test
+
+import (
+	"net"
+
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	"github.com/dexon-foundation/dexon-consensus/network/secure"
+)
+
+// tcpMaxMessageSize limits messages read from authenticated peers.
+const tcpMaxMessageSize = 64 * 1024 * 1024
+
+// secureConn is an authenticated TCP connection, messages are encrypted by
+// session keys negotiated in handshake.
+type secureConn struct {
+	net.Conn
+	peerID  types.NodeID
+	peerKey crypto.PublicKey
+	session *secure.Session
+}
+
+// writeMsg encrypts and writes a message, it's not safe for concurrent use.
+func (c *secureConn) writeMsg(b []byte) error {
+	return c.session.WriteMsg(c.Conn, b)
+}
+
+// readMsg reads and decrypts a message, it's not safe for concurrent use.
+func (c *secureConn) readMsg() ([]byte, error) {
+	return c.session.ReadMsg(c.Conn)
+}
+
+// secureHandshake authenticates the peer of a connection by its node key.
+// Connections to self are allowed, they are made to check if a listener is
+// ours.
+func (t *TCPTransport) secureHandshake(conn net.Conn) (*secureConn, error) {
+	peer, err := secure.Handshake(conn, t.prvKey, secure.Config{
+		MaxMsgSize: tcpMaxMessageSize,
+		AllowSelf:  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &secureConn{
+		Conn:    conn,
+		peerID:  peer.ID,
+		peerKey: peer.PubKey,
+		session: peer.Session,
+	}, nil
+}
@@ -24,7 +24,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"math"
 	"math/rand"
 	"net"
 	"os"
@@ -39,6 +38,7 @@ import (
 	"github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
 	"github.com/dexon-foundation/dexon-consensus/core/types"
 	"github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+	"github.com/dexon-foundation/dexon-consensus/network/secure"
 )
 
 const (
@@ -101,12 +101,21 @@ var (
 
 	// ErrMessageOverflow is reported if the message is too long.
 	ErrMessageOverflow = fmt.Errorf("message size overflow")
+
+	// ErrTCPMalformedMessage is reported if a message can't be split into
+	// the envelope and the payload.
+	ErrTCPMalformedMessage = fmt.Errorf("tcp malformed message")
+
+	// ErrTCPUnauthenticatedSender is reported if the sender of a message is
+	// not the peer authenticated in handshake.
+	ErrTCPUnauthenticatedSender = fmt.Errorf("tcp unauthenticated sender")
 )
 
 // TCPTransport implements Transport interface via TCP connection.
 type TCPTransport struct {
 	peerType          TransportPeerType
 	nID               types.NodeID
+	prvKey            crypto.PrivateKey
 	pubKey            crypto.PublicKey
 	localPort         int
 	peers             map[types.NodeID]*tcpPeerRecord
@@ -118,16 +127,20 @@ type TCPTransport struct {
 	throughputRecords []ThroughputRecord
 	throughputLock    sync.Mutex
 	dMoment           time.Time
+	logger            common.Logger
+	clock             Clock
 }
 
-// NewTCPTransport constructs an TCPTransport instance.
-func NewTCPTransport(peerType TransportPeerType, pubKey crypto.PublicKey,
+// NewTCPTransport constructs an TCPTransport instance. The private key is used
+// to prove our identity to peers in handshake.
+func NewTCPTransport(peerType TransportPeerType, prvKey crypto.PrivateKey,
 	marshaller Marshaller, localPort int) *TCPTransport {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &TCPTransport{
 		peerType:          peerType,
-		nID:               types.NewNodeID(pubKey),
-		pubKey:            pubKey,
+		nID:               types.NewNodeID(prvKey.PublicKey()),
+		prvKey:            prvKey,
+		pubKey:            prvKey.PublicKey(),
 		peers:             make(map[types.NodeID]*tcpPeerRecord),
 		recvChannel:       make(chan *TransportEnvelope, 1000),
 		ctx:               ctx,
@@ -135,74 +148,43 @@ func NewTCPTransport(peerType TransportPeerType, pubKey crypto.PublicKey,
 		localPort:         localPort,
 		marshaller:        marshaller,
 		throughputRecords: []ThroughputRecord{},
+		logger:            &common.NullLogger{},
+		clock:             systemClock{},
 	}
 }
 
-const handshakeMsg = "Welcome to DEXON network for test."
-
-func (t *TCPTransport) serverHandshake(conn net.Conn) (
-	nID types.NodeID, err error) {
-	if err := conn.SetDeadline(time.Now().Add(3 * time.Second)); err != nil {
-		panic(err)
-	}
-	msg := &tcpMessage{
-		NodeID: t.nID,
-		Type:   "handshake",
-		Info:   handshakeMsg,
-	}
-	var payload []byte
-	payload, err = json.Marshal(msg)
-	if err != nil {
+// SetLogger sets the logger to report connections closed because of
+// misbehaving peers, nothing is logged by default.
+func (t *TCPTransport) SetLogger(logger common.Logger) {
+	if logger == nil {
 		return
 	}
-	if err = t.write(conn, payload); err != nil {
-		return
-	}
-	if payload, err = t.read(conn); err != nil {
-		return
-	}
-	if err = json.Unmarshal(payload, &msg); err != nil {
+	t.logger = logger
+}
+
+// SetClock sets the clock to wait for latencies of messages, wall clock is
+// used by default.
+func (t *TCPTransport) SetClock(clock Clock) {
+	if clock == nil {
 		return
 	}
-	if msg.Type != "handshake-ack" || msg.Info != handshakeMsg {
-		err = ErrTCPHandShakeFail
-		return
+	t.clock = clock
+}
+
+func (t *TCPTransport) serverHandshake(conn net.Conn) (
+	sc *secureConn, err error) {
+	if err := conn.SetDeadline(time.Now().Add(3 * time.Second)); err != nil {
+		panic(err)
 	}
-	nID = msg.NodeID
-	return
+	return t.secureHandshake(conn)
 }
 
 func (t *TCPTransport) clientHandshake(conn net.Conn) (
-	nID types.NodeID, err error) {
+	sc *secureConn, err error) {
 	if err := conn.SetDeadline(time.Now().Add(3 * time.Second)); err != nil {
 		panic(err)
 	}
-	var payload []byte
-	if payload, err = t.read(conn); err != nil {
-		return
-	}
-	msg := &tcpMessage{}
-	if err = json.Unmarshal(payload, &msg); err != nil {
-		return
-	}
-	if msg.Type != "handshake" || msg.Info != handshakeMsg {
-		err = ErrTCPHandShakeFail
-		return
-	}
-	nID = msg.NodeID
-	msg = &tcpMessage{
-		NodeID: t.nID,
-		Type:   "handshake-ack",
-		Info:   handshakeMsg,
-	}
-	payload, err = json.Marshal(msg)
-	if err != nil {
-		return
-	}
-	if err = t.write(conn, payload); err != nil {
-		return
-	}
-	return
+	return t.secureHandshake(conn)
 }
 
 // Disconnect implements Transport.Disconnect method.
@@ -246,7 +228,7 @@ func (t *TCPTransport) Broadcast(endpoints map[types.NodeID]struct{},
 			continue
 		}
 		go func(ID types.NodeID) {
-			time.Sleep(latency.Delay())
+			t.clock.Sleep(latency.Delay())
 			t.send(ID, msg, payload)
 		}(nID)
 	}
@@ -275,72 +257,55 @@ func (t *TCPTransport) Peers() (peers []crypto.PublicKey) {
 	return
 }
 
-func (t *TCPTransport) write(conn net.Conn, b []byte) (err error) {
-	if len(b) > math.MaxUint32 {
-		return ErrMessageOverflow
-	}
-	msgLength := make([]byte, 4)
-	binary.LittleEndian.PutUint32(msgLength, uint32(len(b)))
-	if _, err = conn.Write(msgLength); err != nil {
-		return
-	}
-	if _, err = conn.Write(b); err != nil {
-		return
-	}
-	return
-}
-
-func (t *TCPTransport) read(conn net.Conn) (b []byte, err error) {
-	msgLength := make([]byte, 4)
-	if _, err = io.ReadFull(conn, msgLength); err != nil {
-		return
-	}
-	b = make([]byte, int(binary.LittleEndian.Uint32(msgLength)))
-	if _, err = io.ReadFull(conn, b); err != nil {
-		return
-	}
-	return
+// tcpEnvelope is the header of messages between TCP transports, the payload
+// follows the header as raw bytes.
+type tcpEnvelope struct {
+	PeerType TransportPeerType `json:"peer_type"`
+	From     types.NodeID      `json:"from"`
+	Type     string            `json:"type"`
 }
 
+// marshalMessage encodes a message as the length of the JSON encoded
+// envelope, the envelope, and the payload. Messages of the transport itself
+// are encoded in JSON, others are encoded by the marshaller and carried as
+// is.
 func (t *TCPTransport) marshalMessage(
 	msg interface{}) (payload []byte, err error) {
 
-	msgCarrier := struct {
-		PeerType TransportPeerType `json:"peer_type"`
-		From     types.NodeID      `json:"from"`
-		Type     string            `json:"type"`
-		Payload  interface{}       `json:"payload"`
-	}{
+	envelope := tcpEnvelope{
 		PeerType: t.peerType,
 		From:     t.nID,
-		Payload:  msg,
 	}
 	switch msg.(type) {
 	case *tcpHandshake:
-		msgCarrier.Type = "tcp-handshake"
+		envelope.Type = "tcp-handshake"
 	case *tcpMessage:
-		msgCarrier.Type = "trans-msg"
+		envelope.Type = "trans-msg"
 	case []ThroughputRecord:
-		msgCarrier.Type = "throughput-record"
+		envelope.Type = "throughput-record"
 	case *BlockEventMessage:
-		msgCarrier.Type = "block-event"
-	default:
-		if t.marshaller == nil {
-			err = fmt.Errorf("unknown msg type: %v", msg)
-			break
-		}
+		envelope.Type = "block-event"
+	}
+	var body []byte
+	if envelope.Type != "" {
+		body, err = json.Marshal(msg)
+	} else if t.marshaller != nil {
 		// Delegate to user defined marshaller.
-		var buff []byte
-		msgCarrier.Type, buff, err = t.marshaller.Marshal(msg)
-		if err != nil {
-			break
-		}
-		msgCarrier.Payload = json.RawMessage(buff)
+		envelope.Type, body, err = t.marshaller.Marshal(msg)
+	} else {
+		err = fmt.Errorf("unknown msg type: %v", msg)
+	}
+	if err != nil {
+		return
 	}
+	header, err := json.Marshal(&envelope)
 	if err != nil {
 		return
 	}
-	payload, err = json.Marshal(msgCarrier)
+	payload = make([]byte, 4, 4+len(header)+len(body))
+	binary.LittleEndian.PutUint32(payload, uint32(len(header)))
+	payload = append(payload, header...)
+	payload = append(payload, body...)
 	return
 }
 
@@ -351,54 +316,60 @@ func (t *TCPTransport) unmarshalMessage(
 	msg interface{},
 	err error) {
 
-	msgCarrier := struct {
-		PeerType TransportPeerType `json:"peer_type"`
-		From     types.NodeID      `json:"from"`
-		Type     string            `json:"type"`
-		Payload  json.RawMessage   `json:"payload"`
-	}{}
-	if err = json.Unmarshal(payload, &msgCarrier); err != nil {
+	if len(payload) < 4 {
+		err = ErrTCPMalformedMessage
+		return
+	}
+	headerSize := binary.LittleEndian.Uint32(payload)
+	if uint64(headerSize) > uint64(len(payload)-4) {
+		err = ErrTCPMalformedMessage
+		return
+	}
+	envelope := tcpEnvelope{}
+	if err = json.Unmarshal(payload[4:4+headerSize], &envelope); err != nil {
 		return
 	}
-	peerType = msgCarrier.PeerType
-	from = msgCarrier.From
-	switch msgCarrier.Type {
+	body := payload[4+headerSize:]
+	peerType = envelope.PeerType
+	from = envelope.From
+	switch envelope.Type {
 	case "tcp-handshake":
 		handshake := &tcpHandshake{}
-		if err = json.Unmarshal(msgCarrier.Payload, &handshake); err != nil {
+		if err = json.Unmarshal(body, &handshake); err != nil {
 			return
 		}
 		msg = handshake
 	case "trans-msg":
 		m := &tcpMessage{}
-		if err = json.Unmarshal(msgCarrier.Payload, m); err != nil {
+		if err = json.Unmarshal(body, m); err != nil {
 			return
 		}
 		msg = m
 	case "throughput-record":
 		m := &[]ThroughputRecord{}
-		if err = json.Unmarshal(msgCarrier.Payload, m); err != nil {
+		if err = json.Unmarshal(body, m); err != nil {
 			return
 		}
 		msg = m
 	case "block-event":
 		m := &BlockEventMessage{}
-		if err = json.Unmarshal(msgCarrier.Payload, m); err != nil {
+		if err = json.Unmarshal(body, m); err != nil {
 			return
 		}
 		msg = m
 	default:
 		if t.marshaller == nil {
-			err = fmt.Errorf("unknown msg type: %v", msgCarrier.Type)
+			err = fmt.Errorf("unknown msg type: %v", envelope.Type)
 			break
 		}
-		msg, err = t.marshaller.Unmarshal(msgCarrier.Type, msgCarrier.Payload)
+		msg, err = t.marshaller.Unmarshal(envelope.Type, body)
 	}
 	return
 }
 
-// connReader is a reader routine to read from a TCP connection.
-func (t *TCPTransport) connReader(conn net.Conn) {
+// connReader is a reader routine to read from a TCP connection, messages not
+// sent by the authenticated peer are rejected.
+func (t *TCPTransport) connReader(conn *secureConn) {
 	defer func() {
 		if err := conn.Close(); err != nil {
 			panic(err)
@@ -415,6 +386,12 @@ func (t *TCPTransport) connReader(conn net.Conn) {
 			toBreak = true
 			return
 		}
+		if err == secure.ErrDecryptFailed || err == secure.ErrMsgTooLarge {
+			t.logger.Error("Failed to read message",
+				"peer", conn.peerID, "error", err)
+			toBreak = true
+			return
+		}
 		// Check if timeout.
 		nErr, ok := err.(*net.OpError)
 		if !ok {
@@ -438,8 +415,7 @@ Loop:
 
 			panic(err)
 		}
-		// Read message length.
-		if payload, err = t.read(conn); err != nil {
+		if payload, err = conn.readMsg(); err != nil {
 			if checkErr(err) {
 				break
 			}
@@ -449,6 +425,13 @@ Loop:
 		if err != nil {
 			panic(err)
 		}
+		if from != conn.peerID {
+			t.logger.Error("Message not sent by peer",
+				"from", from,
+				"peer", conn.peerID,
+				"error", ErrTCPUnauthenticatedSender)
+			break
+		}
 		t.recvChannel <- &TransportEnvelope{
 			PeerType: peerType,
 			From:     from,
@@ -458,7 +441,7 @@ Loop:
 }
 
 // connWriter is a writer routine to write to TCP connection.
-func (t *TCPTransport) connWriter(conn net.Conn) chan<- []byte {
+func (t *TCPTransport) connWriter(conn *secureConn) chan<- []byte {
 	// Disable write deadline.
 	if err := conn.SetWriteDeadline(time.Time{}); err != nil {
 		panic(err)
@@ -482,8 +465,7 @@ func (t *TCPTransport) connWriter(conn net.Conn) chan<- []byte {
 			case <-t.ctx.Done():
 				return
 			case msg := <-ch:
-				// Send message length in uint32.
-				if err := t.write(conn, msg); err != nil {
+				if err := conn.writeMsg(msg); err != nil {
 					panic(err)
 				}
 			}
@@ -530,11 +512,15 @@ func (t *TCPTransport) listenerRoutine(listener *net.TCPListener) {
 			}
 			continue
 		}
-		if _, err := t.serverHandshake(conn); err != nil {
-			fmt.Println(err)
+		sc, err := t.serverHandshake(conn)
+		if err != nil {
+			t.logger.Error("Failed to handshake",
+				"remote", conn.RemoteAddr(), "error", err)
+			// #nosec G104
+			conn.Close()
 			continue
 		}
-		go t.connReader(conn)
+		go t.connReader(sc)
 	}
 }
 
@@ -562,18 +548,18 @@ func (t *TCPTransport) buildConnectionsToPeers() (err error) {
 				addErr(localErr)
 				return
 			}
-			serverID, localErr := t.clientHandshake(conn)
+			sc, localErr := t.clientHandshake(conn)
 			if localErr != nil {
 				addErr(localErr)
 				return
 			}
-			if nID != serverID {
+			if nID != sc.peerID {
 				addErr(ErrConnectToUnexpectedPeer)
 				return
 			}
 			t.peersLock.Lock()
 			defer t.peersLock.Unlock()
-			t.peers[nID].sendChannel = t.connWriter(conn)
+			t.peers[nID].sendChannel = t.connWriter(sc)
 		}(nID, rec.conn)
 	}
 	wg.Wait()
@@ -601,12 +587,12 @@ type TCPTransportClient struct {
 
 // NewTCPTransportClient constructs a TCPTransportClient instance.
 func NewTCPTransportClient(
-	pubKey crypto.PublicKey,
+	prvKey crypto.PrivateKey,
 	marshaller Marshaller,
 	local bool) *TCPTransportClient {
 
 	return &TCPTransportClient{
-		TCPTransport: *NewTCPTransport(TransportPeer, pubKey, marshaller, 8080),
+		TCPTransport: *NewTCPTransport(TransportPeer, prvKey, marshaller, 8080),
 		local:        local,
 	}
 }
@@ -647,12 +633,12 @@ func (t *TCPTransportClient) Join(
 				err = e
 				return
 			}
-			nID, e := t.clientHandshake(testConn)
+			sc, e := t.clientHandshake(testConn)
 			if e != nil {
 				err = e
 				return
 			}
-			if nID == t.nID {
+			if sc.peerID == t.nID {
 				break
 			}
 			// #nosec G104
@@ -688,11 +674,11 @@ func (t *TCPTransportClient) Join(
 	if err != nil {
 		return
 	}
-	_, err = t.clientHandshake(serverConn)
+	sc, err := t.clientHandshake(serverConn)
 	if err != nil {
 		return
 	}
-	t.serverWriteChannel = t.connWriter(serverConn)
+	t.serverWriteChannel = t.connWriter(sc)
 	if t.local {
 		conn = addr
 	} else {
@@ -799,7 +785,7 @@ func NewTCPTransportServer(
 		// NOTE: the assumption here is the node ID of peers
 		//       won't be zero.
 		TCPTransport: *NewTCPTransport(
-			TransportPeerServer, prvKey.PublicKey(), marshaller, serverPort),
+			TransportPeerServer, prvKey, marshaller, serverPort),
 	}
 }
 
@@ -838,6 +824,10 @@ func (t *TCPTransportServer) WaitForPeers(numPeers uint32) (err error) {
 			panic(fmt.Errorf("expect connection report, not %v", e))
 		}
 		pubKey, conn := parsePeerInfo(msg.Info)
+		if msg.NodeID != e.From || types.NewNodeID(pubKey) != e.From {
+			err = ErrTCPUnauthenticatedSender
+			return
+		}
 		fmt.Println("Peer connected", "peer", conn)
 		t.peers[msg.NodeID] = &tcpPeerRecord{
 			conn:   conn,
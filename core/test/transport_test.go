@@ -18,6 +18,7 @@
 package test
 
 import (
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"net"
@@ -28,6 +29,7 @@ import (
 
 	"github.com/dexon-foundation/dexon-consensus/common"
 	"github.com/dexon-foundation/dexon-consensus/core/types"
+	"github.com/dexon-foundation/dexon-consensus/network/secure"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -82,6 +84,34 @@ func (m *testMarshaller) Marshal(
 	return
 }
 
+// rawMarshaller encodes block hashes as raw bytes, which are not valid JSON.
+type rawMarshaller struct{}
+
+func (m *rawMarshaller) Unmarshal(
+	msgType string, payload []byte) (msg interface{}, err error) {
+
+	if msgType != "raw-hash" || len(payload) != common.HashLength {
+		err = fmt.Errorf("unknown message type: %v", msgType)
+		return
+	}
+	hash := common.Hash{}
+	copy(hash[:], payload)
+	msg = &hash
+	return
+}
+
+func (m *rawMarshaller) Marshal(
+	msg interface{}) (msgType string, payload []byte, err error) {
+
+	hash, ok := msg.(*common.Hash)
+	if !ok {
+		err = fmt.Errorf("unknown message type: %v", msg)
+		return
+	}
+	msgType, payload = "raw-hash", hash[:]
+	return
+}
+
 type TransportTestSuite struct {
 	suite.Suite
 }
@@ -257,9 +287,8 @@ func (s *TransportTestSuite) TestTCPLocal() {
 	for _, prvKey := range prvKeys {
 		nID := types.NewNodeID(prvKey.PublicKey())
 		peer := &testPeer{
-			nID: nID,
-			trans: NewTCPTransportClient(
-				prvKey.PublicKey(), &testMarshaller{}, true),
+			nID:   nID,
+			trans: NewTCPTransportClient(prvKey, &testMarshaller{}, true),
 		}
 		peers[nID] = peer
 		go func() {
@@ -282,6 +311,137 @@ func (s *TransportTestSuite) TestTCPLocal() {
 	}
 }
 
+// secureHandshakePair runs handshake between transports on both ends of a
+// loopback TCP connection, the server side result is returned.
+func (s *TransportTestSuite) secureHandshakePair(
+	server *TCPTransport, dial func(net.Conn)) (*secureConn, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	s.Require().NoError(err)
+	defer ln.Close()
+	go func() {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		s.Require().NoError(err)
+		dial(conn)
+	}()
+	conn, err := ln.Accept()
+	s.Require().NoError(err)
+	return server.serverHandshake(conn)
+}
+
+func (s *TransportTestSuite) TestTCPHandshake() {
+	var (
+		req     = s.Require()
+		prvKeys = GenerateRandomPrivateKeys(2)
+		server  = NewTCPTransport(TransportPeer, prvKeys[0], nil, 0)
+		client  = NewTCPTransport(TransportPeer, prvKeys[1], nil, 0)
+		clients = make(chan *secureConn, 1)
+	)
+	// A successful handshake, messages are encrypted by session keys.
+	sc, err := s.secureHandshakePair(server, func(conn net.Conn) {
+		sc, err := client.clientHandshake(conn)
+		req.NoError(err)
+		clients <- sc
+	})
+	req.NoError(err)
+	req.Equal(client.nID, sc.peerID)
+	clientConn := <-clients
+	req.Equal(server.nID, clientConn.peerID)
+	msg := []byte("hello")
+	req.NoError(clientConn.writeMsg(msg))
+	received, err := sc.readMsg()
+	req.NoError(err)
+	req.Equal(msg, received)
+	req.NoError(sc.writeMsg(msg))
+	received, err = clientConn.readMsg()
+	req.NoError(err)
+	req.Equal(msg, received)
+	// Tampered messages can't be decrypted.
+	header := make([]byte, 4)
+	binary.BigEndian.PutUint32(header, uint32(len("forged message")))
+	_, err = clientConn.Conn.Write(append(header, "forged message"...))
+	req.NoError(err)
+	_, err = sc.readMsg()
+	req.Equal(secure.ErrDecryptFailed, err)
+	// Oversized messages are rejected before being read.
+	binary.BigEndian.PutUint32(header, 2*tcpMaxMessageSize)
+	_, err = clientConn.Conn.Write(header)
+	req.NoError(err)
+	_, err = sc.readMsg()
+	req.Equal(secure.ErrMsgTooLarge, err)
+	// Connections to self are used to check the listener.
+	sc, err = s.secureHandshakePair(server, func(conn net.Conn) {
+		// #nosec G104
+		server.clientHandshake(conn)
+	})
+	req.NoError(err)
+	req.Equal(server.nID, sc.peerID)
+}
+
+func (s *TransportTestSuite) TestTCPMessageEnvelope() {
+	var (
+		req   = s.Require()
+		trans = NewTCPTransport(TransportPeer,
+			GenerateRandomPrivateKeys(1)[0], &rawMarshaller{}, 0)
+		hash = common.NewRandomHash()
+	)
+	// Payloads from the marshaller are carried as is.
+	payload, err := trans.marshalMessage(&hash)
+	req.NoError(err)
+	req.Equal(hash[:], payload[len(payload)-common.HashLength:])
+	peerType, from, msg, err := trans.unmarshalMessage(payload)
+	req.NoError(err)
+	req.Equal(TransportPeer, peerType)
+	req.Equal(trans.nID, from)
+	req.Equal(&hash, msg)
+	// Messages of the transport itself are still encoded in JSON.
+	payload, err = trans.marshalMessage(&tcpMessage{Type: "conn"})
+	req.NoError(err)
+	_, _, msg, err = trans.unmarshalMessage(payload)
+	req.NoError(err)
+	req.Equal(&tcpMessage{Type: "conn"}, msg)
+	// Truncated envelopes are rejected.
+	_, _, _, err = trans.unmarshalMessage(payload[:3])
+	req.Equal(ErrTCPMalformedMessage, err)
+	payload[0]++
+	_, _, _, err = trans.unmarshalMessage(payload[:10])
+	req.Equal(ErrTCPMalformedMessage, err)
+}
+
+func (s *TransportTestSuite) TestTCPUnauthenticatedSender() {
+	var (
+		req     = s.Require()
+		prvKeys = GenerateRandomPrivateKeys(3)
+		server  = NewTCPTransport(TransportPeer, prvKeys[0], nil, 0)
+		client  = NewTCPTransport(TransportPeer, prvKeys[1], nil, 0)
+		clients = make(chan *secureConn, 1)
+	)
+	defer server.Close()
+	sc, err := s.secureHandshakePair(server, func(conn net.Conn) {
+		sc, err := client.clientHandshake(conn)
+		req.NoError(err)
+		clients <- sc
+	})
+	req.NoError(err)
+	clientConn := <-clients
+	// Pretend to be another node after handshake.
+	client.nID = types.NewNodeID(prvKeys[2].PublicKey())
+	payload, err := client.marshalMessage(&tcpMessage{Type: "conn"})
+	req.NoError(err)
+	req.NoError(clientConn.writeMsg(payload))
+	done := make(chan struct{})
+	go func() {
+		server.connReader(sc)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case e := <-server.recvChannel:
+		req.FailNow("message from unauthenticated sender", "%v", e)
+	case <-time.After(5 * time.Second):
+		req.FailNow("timeout")
+	}
+}
+
 func TestTransport(t *testing.T) {
 	suite.Run(t, new(TransportTestSuite))
 }
@@ -36,10 +36,9 @@ const (
 	TickerCRS
 )
 
-// defaultTicker is a wrapper to implement ticker interface based on
-// time.Ticker.
+// defaultTicker is a wrapper to implement ticker interface based on Clock.
 type defaultTicker struct {
-	ticker     *time.Ticker
+	clock      Clock
 	tickerChan chan time.Time
 	duration   time.Duration
 	ctx        context.Context
@@ -48,8 +47,8 @@ type defaultTicker struct {
 }
 
 // newDefaultTicker constructs an defaultTicker instance by giving an interval.
-func newDefaultTicker(lambda time.Duration) *defaultTicker {
-	ticker := &defaultTicker{duration: lambda}
+func newDefaultTicker(lambda time.Duration, clock Clock) *defaultTicker {
+	ticker := &defaultTicker{duration: lambda, clock: clock}
 	ticker.init()
 	return ticker
 }
@@ -61,7 +60,6 @@ func (t *defaultTicker) Tick() <-chan time.Time {
 
 // Stop implements Stop method of ticker interface.
 func (t *defaultTicker) Stop() {
-	t.ticker.Stop()
 	t.ctxCancel()
 	t.waitGroup.Wait()
 	t.ctx = nil
@@ -77,7 +75,6 @@ func (t *defaultTicker) Restart() {
 }
 
 func (t *defaultTicker) init() {
-	t.ticker = time.NewTicker(t.duration)
 	t.tickerChan = make(chan time.Time)
 	t.ctx, t.ctxCancel = context.WithCancel(context.Background())
 	t.waitGroup.Add(1)
@@ -91,7 +88,7 @@ loop:
 		select {
 		case <-t.ctx.Done():
 			break loop
-		case v := <-t.ticker.C:
+		case v := <-t.clock.After(t.duration):
 			select {
 			case t.tickerChan <- v:
 			default:
@@ -102,8 +99,9 @@ loop:
 
 // newTicker is a helper to setup a ticker by giving an Governance. If
 // the governace object implements a ticker generator, a ticker from that
-// generator would be returned, else constructs a default one.
-func newTicker(gov Governance, round uint64, tickerType TickerType) (t Ticker) {
+// generator would be returned, else constructs a default one driven by clock.
+func newTicker(gov Governance, round uint64, tickerType TickerType,
+	clock Clock) (t Ticker) {
 	type tickerGenerator interface {
 		NewTicker(TickerType) Ticker
 	}
@@ -121,7 +119,15 @@ func newTicker(gov Governance, round uint64, tickerType TickerType) (t Ticker) {
 		default:
 			panic(fmt.Errorf("unknown ticker type: %d", tickerType))
 		}
-		t = newDefaultTicker(duration)
+		t = newDefaultTicker(duration, clock)
 	}
 	return
 }
+
+// newClockTickerFactory returns a TickerFactory creating tickers by newTicker
+// with the clock.
+func newClockTickerFactory(clock Clock) TickerFactory {
+	return func(gov Governance, round uint64, tickerType TickerType) Ticker {
+		return newTicker(gov, round, tickerType, clock)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/core/test"
+)
+
+type TickerTestSuite struct {
+	suite.Suite
+}
+
+func (s *TickerTestSuite) TestFakeClock() {
+	start := time.Now()
+	clock := test.NewFakeClock(start)
+	ticker := newDefaultTicker(time.Second, clock)
+	defer ticker.Stop()
+	// The ticker should wait on the clock for the interval.
+	clock.BlockUntil(1)
+	clock.Advance(999 * time.Millisecond)
+	s.Require().Equal(1, clock.Waiters())
+	clock.Advance(time.Millisecond)
+	clock.BlockUntil(1)
+	// Ticks are dropped when nobody is listening, keep advancing the clock
+	// until one is received.
+	tickChan := make(chan time.Time, 1)
+	go func() { tickChan <- <-ticker.Tick() }()
+	for {
+		clock.BlockUntil(1)
+		s.Require().True(clock.AdvanceToNext())
+		select {
+		case v := <-tickChan:
+			s.Require().Zero(v.Sub(start) % time.Second)
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestTicker(t *testing.T) {
+	suite.Run(t, new(TickerTestSuite))
+}
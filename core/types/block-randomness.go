@@ -24,13 +24,30 @@ import (
 	"github.com/dexon-foundation/dexon-consensus/common"
 )
 
+// AgreementResultFormat is the way an agreement result proves the block is
+// confirmed.
+type AgreementResultFormat byte
+
+// AgreementResultFormat enum.
+const (
+	// AgreementResultVotes carries votes from notary set.
+	AgreementResultVotes AgreementResultFormat = iota
+	// AgreementResultCertificate carries only the threshold signature
+	// recovered from partial signatures in votes as randomness, which proves
+	// the block is confirmed once verified against the group public key.
+	AgreementResultCertificate
+	// Do not add any format below MaxAgreementResultFormat.
+	MaxAgreementResultFormat
+)
+
 // AgreementResult describes an agremeent result.
 type AgreementResult struct {
-	BlockHash    common.Hash `json:"block_hash"`
-	Position     Position    `json:"position"`
-	Votes        []Vote      `json:"votes"`
-	IsEmptyBlock bool        `json:"is_empty_block"`
-	Randomness   []byte      `json:"randomness"`
+	BlockHash    common.Hash           `json:"block_hash"`
+	Position     Position              `json:"position"`
+	Votes        []Vote                `json:"votes"`
+	IsEmptyBlock bool                  `json:"is_empty_block"`
+	Randomness   []byte                `json:"randomness"`
+	Format       AgreementResultFormat `json:"format"`
 }
 
 func (r *AgreementResult) String() string {
@@ -256,6 +256,41 @@ type PartialSignature struct {
 	Signature        crypto.Signature           `json:"signature"`
 }
 
+type rlpPartialSignature struct {
+	ProposerID       types.NodeID
+	Round            uint64
+	Hash             common.Hash
+	PartialSignature crypto.Signature
+	Signature        crypto.Signature
+}
+
+// EncodeRLP implements rlp.Encoder
+func (p *PartialSignature) EncodeRLP(w io.Writer) error {
+	return rlp.Encode(w, rlpPartialSignature{
+		ProposerID:       p.ProposerID,
+		Round:            p.Round,
+		Hash:             p.Hash,
+		PartialSignature: crypto.Signature(p.PartialSignature),
+		Signature:        p.Signature,
+	})
+}
+
+// DecodeRLP implements rlp.Decoder
+func (p *PartialSignature) DecodeRLP(s *rlp.Stream) error {
+	var dec rlpPartialSignature
+	if err := s.Decode(&dec); err != nil {
+		return err
+	}
+	*p = PartialSignature{
+		ProposerID:       dec.ProposerID,
+		Round:            dec.Round,
+		Hash:             dec.Hash,
+		PartialSignature: cryptoDKG.PartialSignature(dec.PartialSignature),
+		Signature:        dec.Signature,
+	}
+	return nil
+}
+
 // MPKReady describe a dkg ready message in DKG protocol.
 type MPKReady struct {
 	ProposerID types.NodeID     `json:"proposer_id"`
@@ -432,6 +467,7 @@ type NodePublicKeys struct {
 	QualifyNodeIDs map[types.NodeID]struct{}
 	IDMap          map[types.NodeID]cryptoDKG.ID
 	PublicKeys     map[types.NodeID]*cryptoDKG.PublicKey
+	GroupPublicKey *cryptoDKG.PublicKey
 	Threshold      int
 }
 
@@ -474,12 +510,18 @@ func NewNodePublicKeys(
 		}
 		pubKeys[mpkMap[recvID].ProposerID] = pubKey
 	}
+	// Recover Group Public Key.
+	pubShares := make([]*cryptoDKG.PublicKeyShares, 0, len(qualifyIDs))
+	for _, id := range qualifyIDs {
+		pubShares = append(pubShares, &mpkMap[id].PublicKeyShares)
+	}
 	return &NodePublicKeys{
 		Round:          round,
 		QualifyIDs:     qualifyIDs,
 		QualifyNodeIDs: qualifyNodeIDs,
 		IDMap:          idMap,
 		PublicKeys:     pubKeys,
+		GroupPublicKey: cryptoDKG.RecoverGroupPublicKey(pubShares),
 		Threshold:      threshold,
 	}, nil
 }
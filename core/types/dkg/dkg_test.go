@@ -171,6 +171,29 @@ func (s *DKGTestSuite) TestRLPEncodeDecode() {
 	s.Require().True(c.Round == cc.Round)
 	s.Require().True(reflect.DeepEqual(c.PrivateShare, cc.PrivateShare))
 	s.Require().True(reflect.DeepEqual(c.Signature, cc.Signature))
+
+	// Test DKG PartialSignature.
+	psig := PartialSignature{
+		ProposerID: d.ProposerID,
+		Round:      10,
+		Hash:       common.Hash{7, 8, 9},
+		PartialSignature: cryptoDKG.PartialSignature{
+			Type:      "456",
+			Signature: []byte{1, 1, 1},
+		},
+		Signature: crypto.Signature{
+			Type:      "123",
+			Signature: []byte{3, 3, 3},
+		},
+	}
+
+	b, err = rlp.EncodeToBytes(&psig)
+	s.Require().NoError(err)
+
+	var ppsig PartialSignature
+	err = rlp.DecodeBytes(b, &ppsig)
+	s.Require().NoError(err)
+	s.Require().True(reflect.DeepEqual(psig, ppsig))
 }
 
 func (s *DKGTestSuite) TestMasterPublicKeyEquality() {
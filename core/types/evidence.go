@@ -0,0 +1,82 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"fmt"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+)
+
+// EvidenceType is the type of misbehaviour proved by an evidence.
+type EvidenceType byte
+
+// EvidenceType enum.
+const (
+	// EvidenceForkVote proves a node signed two votes with different blocks
+	// at the same position, period and vote type.
+	EvidenceForkVote EvidenceType = iota
+	// EvidenceForkBlock proves a node proposed two blocks at the same
+	// position.
+	EvidenceForkBlock
+	// EvidenceDKGPrivateShare proves a node sent a private share which
+	// mismatches its master public key in DKG protocol.
+	EvidenceDKGPrivateShare
+	// Do not add any type below MaxEvidenceType.
+	MaxEvidenceType
+)
+
+func (t EvidenceType) String() string {
+	switch t {
+	case EvidenceForkVote:
+		return "fork-vote"
+	case EvidenceForkBlock:
+		return "fork-block"
+	case EvidenceDKGPrivateShare:
+		return "dkg-private-share"
+	}
+	return fmt.Sprintf("unknown(%d)", byte(t))
+}
+
+// Evidence is a proof of misbehaviour of a node. The payload is the RLP
+// encoded messages signed by the offender, and the evidence is signed by the
+// node reporting it.
+type Evidence struct {
+	ProposerID NodeID           `json:"proposer_id"`
+	Type       EvidenceType     `json:"type"`
+	Offender   NodeID           `json:"offender"`
+	Payload    []byte           `json:"payload"`
+	Signature  crypto.Signature `json:"signature"`
+}
+
+func (e *Evidence) String() string {
+	return fmt.Sprintf("Evidence{RP:%s Type:%s Offender:%s}",
+		e.ProposerID.String()[:6], e.Type, e.Offender.String()[:6])
+}
+
+// Clone returns a deep copy of an evidence.
+func (e *Evidence) Clone() *Evidence {
+	return &Evidence{
+		ProposerID: e.ProposerID,
+		Type:       e.Type,
+		Offender:   e.Offender,
+		Payload:    common.CopyBytes(e.Payload),
+		Signature:  e.Signature.Clone(),
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"fmt"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+)
+
+// FinalityProof proves a block is finalized by the threshold signature of the
+// notary set in its round. It carries everything needed to verify that
+// signature, the verifier only has to know the NodeSetCommitment it trusts for
+// the round.
+type FinalityProof struct {
+	// Header is the finalized block without payload.
+	Header Block `json:"header"`
+	// GroupPublicKey is the serialized group public key of DKG in the round.
+	GroupPublicKey []byte `json:"group_public_key"`
+	// NotarySet is the notary set of the round sorted by node ID.
+	NotarySet NodeIDs `json:"notary_set"`
+	// NodeSetCommitment is the hash committing to the round, notary set and
+	// group public key.
+	NodeSetCommitment common.Hash `json:"node_set_commitment"`
+}
+
+func (p *FinalityProof) String() string {
+	return fmt.Sprintf("FinalityProof{Block:%s Commitment:%s}",
+		p.Header.Hash.String()[:6], p.NodeSetCommitment.String()[:6])
+}
+
+// Clone returns a deep copy of a finality proof.
+func (p *FinalityProof) Clone() *FinalityProof {
+	return &FinalityProof{
+		Header:            *p.Header.Clone(),
+		GroupPublicKey:    common.CopyBytes(p.GroupPublicKey),
+		NotarySet:         append(NodeIDs(nil), p.NotarySet...),
+		NodeSetCommitment: p.NodeSetCommitment,
+	}
+}
@@ -22,3 +22,10 @@ type Msg struct {
 	PeerID  interface{}
 	Payload interface{}
 }
+
+// BadPeerReport is sent to networks supporting it when a peer sends invalid
+// messages or too many messages.
+type BadPeerReport struct {
+	PeerID interface{}
+	Reason error
+}
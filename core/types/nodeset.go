@@ -33,7 +33,8 @@ type NodeSet struct {
 
 // SubSetTarget is the sub set target for GetSubSet().
 type SubSetTarget struct {
-	data [][]byte
+	data    [][]byte
+	weights map[NodeID]uint64
 }
 
 type subSetTargetType byte
@@ -88,6 +89,16 @@ func NewNotarySetTarget(crs common.Hash) *SubSetTarget {
 	return newTarget(targetNotarySet, crs[:])
 }
 
+// NewWeightedNotarySetTarget is the target for getting Notary Set sampled by
+// weights of nodes, nodes with higher weights are more likely to be selected
+// and nodes without weights are never selected.
+func NewWeightedNotarySetTarget(
+	crs common.Hash, weights map[NodeID]uint64) *SubSetTarget {
+	target := newTarget(targetNotarySet, crs[:])
+	target.weights = weights
+	return target
+}
+
 // NewNodeLeaderTarget is the target for getting leader of fast BA.
 func NewNodeLeaderTarget(crs common.Hash, height uint64) *SubSetTarget {
 	binaryHeight := make([]byte, 8)
@@ -118,13 +129,16 @@ func (ns *NodeSet) GetSubSet(
 	h := rankHeap{}
 	idx := 0
 	for nID := range ns.IDs {
+		rank := newNodeRank(nID, target)
+		if rank == nil {
+			continue
+		}
 		if idx < size {
-			h = append(h, newNodeRank(nID, target))
+			h = append(h, rank)
 		} else if idx == size {
 			heap.Init(&h)
 		}
 		if idx >= size {
-			rank := newNodeRank(nID, target)
 			if rank.rank.Cmp(h[0].rank) < 0 {
 				h[0] = rank
 				heap.Fix(&h, 0)
@@ -155,6 +169,13 @@ func newNodeRank(ID NodeID, target *SubSetTarget) *nodeRank {
 	data = append(data, target.data...)
 	h := crypto.Keccak256Hash(data...)
 	num := new(big.Int).SetBytes(h[:])
+	if target.weights != nil {
+		weight := target.weights[ID]
+		if weight == 0 {
+			return nil
+		}
+		num.Div(num, new(big.Int).SetUint64(weight))
+	}
 	return &nodeRank{
 		ID:   ID,
 		rank: num,
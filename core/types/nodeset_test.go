@@ -65,6 +65,48 @@ func (s *NodeSetTestSuite) TestGetSubSetZeroSize() {
 	s.Len(emptySet, 0)
 }
 
+func (s *NodeSetTestSuite) TestGetWeightedSubSet() {
+	nodes := NewNodeSet()
+	for len(nodes.IDs) < 10 {
+		nodes.IDs[NodeID{common.NewRandomHash()}] = struct{}{}
+	}
+	nIDs := make(NodeIDs, 0, len(nodes.IDs))
+	for nID := range nodes.IDs {
+		nIDs = append(nIDs, nID)
+	}
+	// Nodes without weights are never selected.
+	weights := map[NodeID]uint64{nIDs[0]: 1, nIDs[1]: 1, nIDs[2]: 1}
+	notarySet := nodes.GetSubSet(
+		4, NewWeightedNotarySetTarget(common.NewRandomHash(), weights))
+	s.Require().Len(notarySet, 3)
+	for nID := range weights {
+		s.Require().Contains(notarySet, nID)
+	}
+	// Nodes with higher weights are more likely to be selected.
+	weights = make(map[NodeID]uint64)
+	for i, nID := range nIDs {
+		weights[nID] = 1
+		if i < 2 {
+			weights[nID] = 100
+		}
+	}
+	counts := make(map[NodeID]int)
+	for i := 0; i < 100; i++ {
+		crs := common.NewRandomHash()
+		target := NewWeightedNotarySetTarget(crs, weights)
+		for nID := range nodes.GetSubSet(3, target) {
+			counts[nID]++
+		}
+		// The same CRS leads to the same result.
+		s.Require().Equal(nodes.GetSubSet(3, target), nodes.GetSubSet(
+			3, NewWeightedNotarySetTarget(crs, weights)))
+	}
+	for _, nID := range nIDs[2:] {
+		s.Require().True(counts[nIDs[0]] > counts[nID])
+		s.Require().True(counts[nIDs[1]] > counts[nID])
+	}
+}
+
 func TestNodeSet(t *testing.T) {
 	suite.Run(t, new(NodeSetTestSuite))
 }
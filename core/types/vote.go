@@ -19,6 +19,9 @@ package types
 
 import (
 	"fmt"
+	"io"
+
+	"github.com/dexon-foundation/dexon/rlp"
 
 	"github.com/dexon-foundation/dexon-consensus/common"
 	"github.com/dexon-foundation/dexon-consensus/core/crypto"
@@ -98,3 +101,34 @@ func (v *Vote) Clone() *Vote {
 		Signature: v.Signature.Clone(),
 	}
 }
+
+// rlpVote keeps the header as a nested list, which is the same layout votes
+// are encoded in evidences and logs.
+type rlpVote struct {
+	Header           VoteHeader
+	PartialSignature crypto.Signature
+	Signature        crypto.Signature
+}
+
+// EncodeRLP implements rlp.Encoder
+func (v *Vote) EncodeRLP(w io.Writer) error {
+	return rlp.Encode(w, rlpVote{
+		Header:           v.VoteHeader,
+		PartialSignature: crypto.Signature(v.PartialSignature),
+		Signature:        v.Signature,
+	})
+}
+
+// DecodeRLP implements rlp.Decoder
+func (v *Vote) DecodeRLP(s *rlp.Stream) error {
+	var dec rlpVote
+	if err := s.Decode(&dec); err != nil {
+		return err
+	}
+	*v = Vote{
+		VoteHeader:       dec.Header,
+		PartialSignature: cryptoDKG.PartialSignature(dec.PartialSignature),
+		Signature:        dec.Signature,
+	}
+	return nil
+}
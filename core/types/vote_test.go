@@ -0,0 +1,65 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	cryptoDKG "github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
+	"github.com/dexon-foundation/dexon/rlp"
+)
+
+type VoteTestSuite struct {
+	suite.Suite
+}
+
+func (s *VoteTestSuite) TestRLPEncodeDecode() {
+	vote := NewVote(VoteCom, common.NewRandomHash(), 3)
+	vote.ProposerID = NodeID{common.NewRandomHash()}
+	vote.Position = Position{Round: 1, Height: 10}
+	vote.PartialSignature = cryptoDKG.PartialSignature{
+		Type:      "bls",
+		Signature: []byte{1, 2, 3},
+	}
+	vote.Signature = crypto.Signature{
+		Type:      "ecdsa",
+		Signature: []byte{4, 5, 6},
+	}
+	b, err := rlp.EncodeToBytes(vote)
+	s.Require().NoError(err)
+	dec := &Vote{}
+	s.Require().NoError(rlp.DecodeBytes(b, dec))
+	s.Require().Equal(vote, dec)
+	// Votes in existing evidences and logs are encoded by fields, the
+	// encoder should keep the same layout.
+	fields, err := rlp.EncodeToBytes(&struct {
+		VoteHeader
+		PartialSignature cryptoDKG.PartialSignature
+		Signature        crypto.Signature
+	}{vote.VoteHeader, vote.PartialSignature, vote.Signature})
+	s.Require().NoError(err)
+	s.Require().Equal(fields, b)
+}
+
+func TestVote(t *testing.T) {
+	suite.Run(t, new(VoteTestSuite))
+}
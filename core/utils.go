@@ -43,6 +43,10 @@ var (
 		"incorrect vote proposer")
 	ErrIncorrectVotePeriod = fmt.Errorf(
 		"incorrect vote period")
+	ErrUnknownAgreementResultFormat = fmt.Errorf(
+		"unknown agreement result format")
+	ErrIncorrectAgreementResultFormat = fmt.Errorf(
+		"incorrect agreement result format")
 )
 
 // NodeSetCache is type alias to avoid fullnode compile error when moving
@@ -156,9 +160,17 @@ func HashConfigurationBlock(
 }
 
 // VerifyAgreementResult perform sanity check against a types.AgreementResult
-// instance.
+// instance. The randomness of results in rounds after DKGDelayRound should be
+// verified against the group public key by callers.
 func VerifyAgreementResult(
 	res *types.AgreementResult, cache *NodeSetCache) error {
+	switch res.Format {
+	case types.AgreementResultVotes:
+	case types.AgreementResultCertificate:
+		return verifyAgreementCertificate(res)
+	default:
+		return ErrUnknownAgreementResultFormat
+	}
 	if res.Position.Round >= DKGDelayRound {
 		if len(res.Randomness) == 0 {
 			return ErrMissingRandomness
@@ -169,7 +181,12 @@ func VerifyAgreementResult(
 	if err != nil {
 		return err
 	}
-	if len(res.Votes) < len(notarySet)*2/3+1 {
+	weights, err := cache.GetNotarySetWeights(res.Position.Round)
+	if err != nil {
+		return err
+	}
+	if len(res.Votes) == 0 ||
+		(weights == nil && len(res.Votes) < len(notarySet)*2/3+1) {
 		return ErrNotEnoughVotes
 	}
 	voted := make(map[types.NodeID]struct{}, len(notarySet))
@@ -209,12 +226,33 @@ func VerifyAgreementResult(
 		}
 		voted[vote.ProposerID] = struct{}{}
 	}
-	if len(voted) < len(notarySet)*2/3+1 {
+	if weights != nil {
+		votedWeight := uint64(0)
+		for nID := range voted {
+			votedWeight += weights[nID]
+		}
+		if votedWeight < utils.GetBAWeightThreshold(weights) {
+			return ErrNotEnoughVotes
+		}
+	} else if len(voted) < len(notarySet)*2/3+1 {
 		return ErrNotEnoughVotes
 	}
 	return nil
 }
 
+// verifyAgreementCertificate checks the format of a certificate, the
+// randomness is the only proof carried and is verified by callers.
+func verifyAgreementCertificate(res *types.AgreementResult) error {
+	// Threshold signatures are not available before DKGDelayRound.
+	if res.Position.Round < DKGDelayRound || len(res.Votes) != 0 {
+		return ErrIncorrectAgreementResultFormat
+	}
+	if len(res.Randomness) == 0 {
+		return ErrMissingRandomness
+	}
+	return nil
+}
+
 // DiffUint64 calculates difference between two uint64.
 func DiffUint64(a, b uint64) uint64 {
 	if a > b {
@@ -236,8 +274,8 @@ func isTravisCI() bool {
 }
 
 // checkWithCancel is a helper to perform periodic checking with cancel.
-func checkWithCancel(parentCtx context.Context, interval time.Duration,
-	checker func() bool) (ret bool) {
+func checkWithCancel(parentCtx context.Context, clock Clock,
+	interval time.Duration, checker func() bool) (ret bool) {
 	ctx, cancel := context.WithCancel(parentCtx)
 	defer cancel()
 Loop:
@@ -248,7 +286,7 @@ Loop:
 		select {
 		case <-ctx.Done():
 			break Loop
-		case <-time.After(interval):
+		case <-clock.After(interval):
 		}
 	}
 	return
@@ -366,6 +366,34 @@ func VerifyDKGSuccessSignature(
 	return true, nil
 }
 
+// HashEvidence generates hash of a types.Evidence, which identifies the
+// misbehaviour regardless of the node reporting it.
+func HashEvidence(evidence *types.Evidence) common.Hash {
+	return crypto.Keccak256Hash(
+		[]byte{byte(evidence.Type)},
+		evidence.Offender.Hash[:],
+		evidence.Payload,
+	)
+}
+
+func hashSignedEvidence(evidence *types.Evidence) common.Hash {
+	hash := HashEvidence(evidence)
+	return crypto.Keccak256Hash(evidence.ProposerID.Hash[:], hash[:])
+}
+
+// VerifyEvidenceSignature verifies the signature of types.Evidence.
+func VerifyEvidenceSignature(evidence *types.Evidence) (bool, error) {
+	pubKey, err := crypto.SigToPub(
+		hashSignedEvidence(evidence), evidence.Signature)
+	if err != nil {
+		return false, err
+	}
+	if evidence.ProposerID != types.NewNodeID(pubKey) {
+		return false, nil
+	}
+	return true, nil
+}
+
 // Rehash hashes the hash again and again and again...
 func Rehash(hash common.Hash, count uint) common.Hash {
 	result := hash
@@ -327,6 +327,30 @@ func (s *CryptoTestSuite) TestDKGSignature() {
 	success.Reset--
 }
 
+func (s *CryptoTestSuite) TestEvidenceSignature() {
+	prv, err := ecdsa.NewPrivateKey()
+	s.Require().NoError(err)
+	evidence := &types.Evidence{
+		Type:     types.EvidenceForkBlock,
+		Offender: types.NodeID{Hash: common.NewRandomHash()},
+		Payload:  []byte{1, 2, 3},
+	}
+	hash := HashEvidence(evidence)
+	s.Require().NoError(NewSigner(prv).SignEvidence(evidence))
+	s.Equal(types.NewNodeID(prv.PublicKey()), evidence.ProposerID)
+	ok, err := VerifyEvidenceSignature(evidence)
+	s.Require().NoError(err)
+	s.True(ok)
+	// The hash is not affected by the reporter.
+	s.Equal(hash, HashEvidence(evidence))
+	// Test incorrect payload.
+	evidence.Payload = []byte{3, 2, 1}
+	ok, err = VerifyEvidenceSignature(evidence)
+	s.Require().NoError(err)
+	s.False(ok)
+	s.NotEqual(hash, HashEvidence(evidence))
+}
+
 func TestCrypto(t *testing.T) {
 	suite.Run(t, new(CryptoTestSuite))
 }
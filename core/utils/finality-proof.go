@@ -0,0 +1,120 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	cryptoDKG "github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+var (
+	// ErrNoFinalityProof for blocks in rounds before DKGDelayRound, which are
+	// not finalized by threshold signature.
+	ErrNoFinalityProof = errors.New("no finality proof before DKGDelayRound")
+	// ErrIncorrectNodeSetCommitment for finality proofs whose commitment
+	// mismatches the notary set or group public key carried.
+	ErrIncorrectNodeSetCommitment = errors.New(
+		"incorrect node set commitment")
+	// ErrUntrustedNodeSetCommitment for finality proofs whose commitment is
+	// not the one trusted by the verifier.
+	ErrUntrustedNodeSetCommitment = errors.New(
+		"untrusted node set commitment")
+	// ErrIncorrectFinality for finality proofs whose block randomness is not
+	// signed by the group public key.
+	ErrIncorrectFinality = errors.New("incorrect finality")
+	// ErrNotInNotarySet for blocks proposed by nodes not in notary set.
+	ErrNotInNotarySet = errors.New("not in notary set")
+)
+
+// HashNodeSetCommitment generates the commitment to a round, its notary set
+// and the group public key of DKG.
+func HashNodeSetCommitment(round uint64, notarySet types.NodeIDs,
+	groupPublicKey []byte) common.Hash {
+	sorted := append(types.NodeIDs(nil), notarySet...)
+	sort.Sort(sorted)
+	binaryRound := make([]byte, 8)
+	binary.LittleEndian.PutUint64(binaryRound, round)
+	data := make([][]byte, 0, len(sorted)+2)
+	data = append(data, binaryRound)
+	for _, nID := range sorted {
+		data = append(data, nID.Hash[:])
+	}
+	data = append(data, groupPublicKey)
+	return crypto.Keccak256Hash(data...)
+}
+
+// VerifyFinalityProof verifies a finality proof against the node set
+// commitment trusted by the caller for the round of the block. The proof only
+// carries data it claims, so the trusted commitment must come from elsewhere,
+// ex. a light client following rounds from genesis or a value published by
+// governance.
+func VerifyFinalityProof(proof *types.FinalityProof,
+	trusted common.Hash) error {
+	b := &proof.Header
+	if b.Position.Round < dkgDelayRound {
+		return ErrNoFinalityProof
+	}
+	if proof.NodeSetCommitment != trusted {
+		return ErrUntrustedNodeSetCommitment
+	}
+	if HashNodeSetCommitment(b.Position.Round, proof.NotarySet,
+		proof.GroupPublicKey) != proof.NodeSetCommitment {
+		return ErrIncorrectNodeSetCommitment
+	}
+	if b.IsEmpty() {
+		// Empty blocks are not signed by proposers.
+		hash, err := HashBlock(b)
+		if err != nil {
+			return err
+		}
+		if hash != b.Hash {
+			return ErrIncorrectHash
+		}
+	} else {
+		inNotarySet := false
+		for _, nID := range proof.NotarySet {
+			if nID == b.ProposerID {
+				inNotarySet = true
+				break
+			}
+		}
+		if !inNotarySet {
+			return ErrNotInNotarySet
+		}
+		if err := VerifyBlockSignatureWithoutPayload(b); err != nil {
+			return err
+		}
+	}
+	var gpk cryptoDKG.PublicKey
+	if err := gpk.Deserialize(proof.GroupPublicKey); err != nil {
+		return err
+	}
+	if !gpk.VerifySignature(b.Hash, crypto.Signature{
+		Type:      "bls",
+		Signature: b.Randomness,
+	}) {
+		return ErrIncorrectFinality
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+type FinalityProofTestSuite struct {
+	suite.Suite
+}
+
+func (s *FinalityProofTestSuite) newProof() *types.FinalityProof {
+	prvKey, err := ecdsa.NewPrivateKey()
+	s.Require().NoError(err)
+	signer := NewSigner(prvKey)
+	notarySet := types.NodeIDs{
+		types.NewNodeID(prvKey.PublicKey()),
+		types.NodeID{Hash: common.NewRandomHash()},
+		types.NodeID{Hash: common.NewRandomHash()},
+	}
+	// The group secret key is used directly instead of running DKG.
+	groupKey := dkg.NewPrivateKey()
+	b := &types.Block{
+		Position:  types.Position{Round: 1, Height: 10},
+		Timestamp: time.Now().UTC(),
+		Payload:   []byte{0x01},
+	}
+	s.Require().NoError(signer.SignBlock(b))
+	sig, err := groupKey.Sign(b.Hash)
+	s.Require().NoError(err)
+	b.Randomness = sig.Signature
+	b.Payload = nil
+	proof := &types.FinalityProof{
+		Header:         *b,
+		GroupPublicKey: groupKey.PublicKey().Bytes(),
+		NotarySet:      notarySet,
+	}
+	proof.NodeSetCommitment = HashNodeSetCommitment(
+		b.Position.Round, notarySet, proof.GroupPublicKey)
+	return proof
+}
+
+func (s *FinalityProofTestSuite) TestCommitment() {
+	nIDs := types.NodeIDs{
+		types.NodeID{Hash: common.NewRandomHash()},
+		types.NodeID{Hash: common.NewRandomHash()},
+	}
+	gpk := []byte{0x01, 0x02}
+	commitment := HashNodeSetCommitment(1, nIDs, gpk)
+	// The order of notary set doesn't matter.
+	s.Require().Equal(commitment, HashNodeSetCommitment(
+		1, types.NodeIDs{nIDs[1], nIDs[0]}, gpk))
+	s.Require().NotEqual(commitment, HashNodeSetCommitment(2, nIDs, gpk))
+	s.Require().NotEqual(commitment, HashNodeSetCommitment(1, nIDs[:1], gpk))
+	s.Require().NotEqual(commitment, HashNodeSetCommitment(1, nIDs, gpk[:1]))
+}
+
+func (s *FinalityProofTestSuite) TestVerify() {
+	proof := s.newProof()
+	trusted := proof.NodeSetCommitment
+	s.Require().NoError(VerifyFinalityProof(proof, trusted))
+	// Commitment should be the trusted one.
+	p := proof.Clone()
+	p.GroupPublicKey = dkg.NewPrivateKey().PublicKey().Bytes()
+	p.NodeSetCommitment = HashNodeSetCommitment(
+		p.Header.Position.Round, p.NotarySet, p.GroupPublicKey)
+	s.Require().Equal(ErrUntrustedNodeSetCommitment,
+		VerifyFinalityProof(p, trusted))
+	// Commitment should match.
+	p = proof.Clone()
+	p.NotarySet = p.NotarySet[1:]
+	s.Require().Equal(ErrIncorrectNodeSetCommitment,
+		VerifyFinalityProof(p, trusted))
+	// Randomness should be signed by the group public key.
+	p = proof.Clone()
+	p.GroupPublicKey = dkg.NewPrivateKey().PublicKey().Bytes()
+	p.NodeSetCommitment = HashNodeSetCommitment(
+		p.Header.Position.Round, p.NotarySet, p.GroupPublicKey)
+	s.Require().Equal(ErrIncorrectFinality,
+		VerifyFinalityProof(p, p.NodeSetCommitment))
+	// Proposer should be in the notary set.
+	p = proof.Clone()
+	p.NotarySet = p.NotarySet[1:]
+	p.NodeSetCommitment = HashNodeSetCommitment(
+		p.Header.Position.Round, p.NotarySet, p.GroupPublicKey)
+	s.Require().Equal(ErrNotInNotarySet,
+		VerifyFinalityProof(p, p.NodeSetCommitment))
+	// Header should not be modified.
+	p = proof.Clone()
+	p.Header.Position.Height++
+	s.Require().Equal(ErrIncorrectHash, VerifyFinalityProof(p, trusted))
+}
+
+func TestFinalityProof(t *testing.T) {
+	suite.Run(t, new(FinalityProofTestSuite))
+}
@@ -39,9 +39,13 @@ type sets struct {
 	crs       common.Hash
 	nodeSet   *types.NodeSet
 	notarySet map[types.NodeID]struct{}
+	// weights of nodes in notary set, it's nil when nodes are not weighted.
+	weights map[types.NodeID]uint64
 }
 
 // NodeSetCacheInterface interface specifies interface used by NodeSetCache.
+// Notary sets are sampled by weights of nodes if it also provides
+// "NodeWeights(round uint64) map[types.NodeID]uint64".
 type NodeSetCacheInterface interface {
 	// Configuration returns the configuration at a given round.
 	// Return the genesis configuration if round == 0.
@@ -132,17 +136,44 @@ func (cache *NodeSetCache) GetNotarySet(
 	return cache.cloneMap(IDs.notarySet), nil
 }
 
+// GetNotarySetWeights returns weights of nodes in notary set of this round,
+// nil is returned when nodes are not weighted.
+func (cache *NodeSetCache) GetNotarySetWeights(
+	round uint64) (map[types.NodeID]uint64, error) {
+	IDs, err := cache.getOrUpdate(round)
+	if err != nil {
+		return nil, err
+	}
+	if IDs.weights == nil {
+		return nil, nil
+	}
+	weights := make(map[types.NodeID]uint64, len(IDs.weights))
+	for nID, w := range IDs.weights {
+		weights[nID] = w
+	}
+	return weights, nil
+}
+
 // Purge a specific round.
 func (cache *NodeSetCache) Purge(rID uint64) {
 	cache.lock.Lock()
 	defer cache.lock.Unlock()
+	cache.purge(rID)
+}
+
+// purge removes a cached round and releases public keys only referenced by
+// that round, callers should hold the write lock.
+func (cache *NodeSetCache) purge(rID uint64) {
 	nIDs, exist := cache.rounds[rID]
 	if !exist {
 		return
 	}
 	for nID := range nIDs.nodeSet.IDs {
-		rec := cache.keyPool[nID]
-		if rec.refCnt--; rec.refCnt == 0 {
+		rec, exists := cache.keyPool[nID]
+		if !exists {
+			continue
+		}
+		if rec.refCnt--; rec.refCnt <= 0 {
 			delete(cache.keyPool, nID)
 		}
 	}
@@ -193,10 +224,21 @@ func (cache *NodeSetCache) update(round uint64) (nIDs *sets, err error) {
 		err = ErrCRSNotReady
 		return
 	}
+	cfg := cache.nsIntf.Configuration(round)
+	if cfg == nil {
+		err = ErrConfigurationNotReady
+		return
+	}
+	// Node set of a round might be updated, and it might be smaller than
+	// before when nodes leave. Release keys referenced by the old one.
+	cache.purge(round)
 	// Cache new round.
 	nodeSet := types.NewNodeSet()
 	for _, key := range keySet {
 		nID := types.NewNodeID(key)
+		if _, exists := nodeSet.IDs[nID]; exists {
+			continue
+		}
 		nodeSet.Add(nID)
 		if rec, exists := cache.keyPool[nID]; exists {
 			rec.refCnt++
@@ -207,32 +249,29 @@ func (cache *NodeSetCache) update(round uint64) (nIDs *sets, err error) {
 			}{key, 1}
 		}
 	}
-	cfg := cache.nsIntf.Configuration(round)
-	if cfg == nil {
-		err = ErrConfigurationNotReady
-		return
-	}
 	nIDs = &sets{
 		crs:       crs,
 		nodeSet:   nodeSet,
 		notarySet: make(map[types.NodeID]struct{}),
 	}
-	nIDs.notarySet = nodeSet.GetSubSet(
-		int(cfg.NotarySetSize), types.NewNotarySetTarget(crs))
+	if weights := GetNodeWeights(cache.nsIntf, round); weights != nil {
+		nIDs.notarySet = nodeSet.GetSubSet(int(cfg.NotarySetSize),
+			types.NewWeightedNotarySetTarget(crs, weights))
+		nIDs.weights = make(map[types.NodeID]uint64, len(nIDs.notarySet))
+		for nID := range nIDs.notarySet {
+			nIDs.weights[nID] = weights[nID]
+		}
+	} else {
+		nIDs.notarySet = nodeSet.GetSubSet(
+			int(cfg.NotarySetSize), types.NewNotarySetTarget(crs))
+	}
 	cache.rounds[round] = nIDs
 	// Purge older rounds.
-	for rID, nIDs := range cache.rounds {
-		nodeSet := nIDs.nodeSet
-		if round-rID <= 5 {
+	for rID := range cache.rounds {
+		if rID >= round || round-rID <= 5 {
 			continue
 		}
-		for nID := range nodeSet.IDs {
-			rec := cache.keyPool[nID]
-			if rec.refCnt--; rec.refCnt == 0 {
-				delete(cache.keyPool, nID)
-			}
-		}
-		delete(cache.rounds, rID)
+		cache.purge(rID)
 	}
 	return
 }
@@ -54,6 +54,19 @@ func (g *nsIntf) NodeSet(round uint64) []crypto.PublicKey {
 	return g.curKeys
 }
 
+type weightedNsIntf struct {
+	nsIntf
+}
+
+// NodeWeights gives weights to the first 5 nodes only.
+func (g *weightedNsIntf) NodeWeights(round uint64) map[types.NodeID]uint64 {
+	weights := make(map[types.NodeID]uint64)
+	for i, key := range g.curKeys[:5] {
+		weights[types.NewNodeID(key)] = uint64(i + 1)
+	}
+	return weights
+}
+
 type NodeSetCacheTestSuite struct {
 	suite.Suite
 }
@@ -144,6 +157,103 @@ func (s *NodeSetCacheTestSuite) TestPurge() {
 	req.False(exist)
 }
 
+func (s *NodeSetCacheTestSuite) TestWeightedNotarySet() {
+	var (
+		nsIntf = &weightedNsIntf{nsIntf{
+			s:   s,
+			crs: common.NewRandomHash(),
+		}}
+		cache = NewNodeSetCache(nsIntf)
+		req   = s.Require()
+	)
+	notarySet, err := cache.GetNotarySet(1)
+	req.NoError(err)
+	weights := nsIntf.NodeWeights(1)
+	// Nodes without weights are never selected.
+	req.Len(notarySet, len(weights))
+	for nID := range notarySet {
+		req.Contains(weights, nID)
+	}
+	notaryWeights, err := cache.GetNotarySetWeights(1)
+	req.NoError(err)
+	req.Equal(weights, notaryWeights)
+	// Weights are not available if nodes are not weighted.
+	cache = NewNodeSetCache(&nsIntf.nsIntf)
+	notaryWeights, err = cache.GetNotarySetWeights(1)
+	req.NoError(err)
+	req.Nil(notaryWeights)
+}
+
+// shrinkingNsIntf removes one node in each round.
+type shrinkingNsIntf struct {
+	nsIntf
+	keys []crypto.PublicKey
+}
+
+func (g *shrinkingNsIntf) NodeSet(round uint64) []crypto.PublicKey {
+	if round >= uint64(len(g.keys)) {
+		return []crypto.PublicKey{}
+	}
+	return g.keys[round:]
+}
+
+func (s *NodeSetCacheTestSuite) TestShrinkingNodeSet() {
+	var (
+		req    = s.Require()
+		nsIntf = &shrinkingNsIntf{nsIntf: nsIntf{
+			s:   s,
+			crs: common.NewRandomHash(),
+		}}
+	)
+	for i := 0; i < 10; i++ {
+		prvKey, err := ecdsa.NewPrivateKey()
+		req.NoError(err)
+		nsIntf.keys = append(nsIntf.keys, prvKey.PublicKey())
+	}
+	cache := NewNodeSetCache(nsIntf)
+	nIDs := make([]types.NodeID, 0, len(nsIntf.keys))
+	for _, key := range nsIntf.keys {
+		nIDs = append(nIDs, types.NewNodeID(key))
+	}
+	for round := uint64(0); round < 3; round++ {
+		notarySet, err := cache.GetNotarySet(round)
+		req.NoError(err)
+		req.Len(notarySet, 7)
+		for _, nID := range nIDs[:round] {
+			req.NotContains(notarySet, nID)
+		}
+	}
+	// Updating a cached round doesn't leak references.
+	req.NoError(cache.Touch(2))
+	req.NoError(cache.Touch(2))
+	// Keys of nodes left are released once rounds containing them are purged.
+	cache.Purge(0)
+	_, exists := cache.GetPublicKey(nIDs[0])
+	req.False(exists)
+	_, exists = cache.GetPublicKey(nIDs[1])
+	req.True(exists)
+	cache.Purge(1)
+	_, exists = cache.GetPublicKey(nIDs[1])
+	req.False(exists)
+	_, exists = cache.GetPublicKey(nIDs[2])
+	req.True(exists)
+	cache.Purge(2)
+	req.Empty(cache.keyPool)
+	// Older rounds are purged when updating newer ones, but not newer rounds
+	// when updating older ones.
+	req.NoError(cache.Touch(9))
+	req.NoError(cache.Touch(3))
+	_, exists = cache.get(9)
+	req.True(exists)
+	req.NoError(cache.Touch(10))
+	_, exists = cache.get(3)
+	req.False(exists)
+	_, exists = cache.GetPublicKey(nIDs[3])
+	req.False(exists)
+	_, exists = cache.GetPublicKey(nIDs[9])
+	req.True(exists)
+}
+
 func TestNodeSetCache(t *testing.T) {
 	suite.Run(t, new(NodeSetCacheTestSuite))
 }
@@ -0,0 +1,111 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+)
+
+type signatureCacheKey struct {
+	hash      common.Hash
+	signature string
+}
+
+type signatureCacheValue struct {
+	nID types.NodeID
+	err error
+}
+
+// SignatureCache caches signers recovered from ECDSA signatures, which is the
+// most expensive part to verify votes and partial signatures. The same
+// message is usually verified more than once, ex. before and after it's
+// queued. A nil SignatureCache is valid and caches nothing.
+type SignatureCache struct {
+	cache *lru.Cache
+}
+
+// NewSignatureCache constructs a SignatureCache instance keeping at most size
+// results.
+func NewSignatureCache(size int) *SignatureCache {
+	cache, err := lru.New(size)
+	if err != nil {
+		panic(err)
+	}
+	return &SignatureCache{cache: cache}
+}
+
+// RecoverNodeID recovers the ID of the node signing hash.
+func (c *SignatureCache) RecoverNodeID(
+	hash common.Hash, sig crypto.Signature) (types.NodeID, error) {
+	if c == nil {
+		return recoverNodeID(hash, sig)
+	}
+	key := signatureCacheKey{
+		hash:      hash,
+		signature: string(sig.Signature),
+	}
+	if v, exist := c.cache.Get(key); exist {
+		value := v.(signatureCacheValue)
+		return value.nID, value.err
+	}
+	nID, err := recoverNodeID(hash, sig)
+	c.cache.Add(key, signatureCacheValue{nID: nID, err: err})
+	return nID, err
+}
+
+// VerifyVoteSignature verifies the signature of types.Vote.
+func (c *SignatureCache) VerifyVoteSignature(vote *types.Vote) (bool, error) {
+	nID, err := c.RecoverNodeID(HashVote(vote), vote.Signature)
+	if err != nil {
+		return false, err
+	}
+	return vote.ProposerID == nID, nil
+}
+
+// VerifyDKGPartialSignatureSignature verifies the signature of
+// typesDKG.PartialSignature.
+func (c *SignatureCache) VerifyDKGPartialSignatureSignature(
+	psig *typesDKG.PartialSignature) (bool, error) {
+	nID, err := c.RecoverNodeID(hashDKGPartialSignature(psig), psig.Signature)
+	if err != nil {
+		return false, err
+	}
+	return psig.ProposerID == nID, nil
+}
+
+// Len returns the count of cached results.
+func (c *SignatureCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	return c.cache.Len()
+}
+
+func recoverNodeID(
+	hash common.Hash, sig crypto.Signature) (types.NodeID, error) {
+	pubKey, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return types.NodeID{}, err
+	}
+	return types.NewNodeID(pubKey), nil
+}
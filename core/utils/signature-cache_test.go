@@ -0,0 +1,101 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+)
+
+type SignatureCacheTestSuite struct {
+	suite.Suite
+}
+
+func (s *SignatureCacheTestSuite) newVote() *types.Vote {
+	prv, err := ecdsa.NewPrivateKey()
+	s.Require().NoError(err)
+	vote := types.NewVote(types.VoteInit, common.NewRandomHash(), 1)
+	s.Require().NoError(NewSigner(prv).SignVote(vote))
+	return vote
+}
+
+func (s *SignatureCacheTestSuite) TestVote() {
+	cache := NewSignatureCache(2)
+	vote := s.newVote()
+	ok, err := cache.VerifyVoteSignature(vote)
+	s.Require().NoError(err)
+	s.Require().True(ok)
+	s.Require().Equal(1, cache.Len())
+	// Cached result is reused.
+	ok, err = cache.VerifyVoteSignature(vote)
+	s.Require().NoError(err)
+	s.Require().True(ok)
+	s.Require().Equal(1, cache.Len())
+	// Votes with different content are not verified by cached results.
+	forged := vote.Clone()
+	forged.Type = types.VoteCom
+	ok, err = cache.VerifyVoteSignature(forged)
+	s.Require().NoError(err)
+	s.Require().False(ok)
+	// Signature of another vote is not accepted either.
+	forged = vote.Clone()
+	forged.Signature = s.newVote().Signature
+	ok, err = cache.VerifyVoteSignature(forged)
+	s.Require().NoError(err)
+	s.Require().False(ok)
+	// Size is bounded.
+	s.Require().Equal(2, cache.Len())
+}
+
+func (s *SignatureCacheTestSuite) TestPartialSignature() {
+	prv, err := ecdsa.NewPrivateKey()
+	s.Require().NoError(err)
+	psig := &typesDKG.PartialSignature{
+		ProposerID: types.NewNodeID(prv.PublicKey()),
+		Round:      5,
+		Hash:       common.NewRandomHash(),
+	}
+	s.Require().NoError(NewSigner(prv).SignDKGPartialSignature(psig))
+	cache := NewSignatureCache(10)
+	ok, err := cache.VerifyDKGPartialSignatureSignature(psig)
+	s.Require().NoError(err)
+	s.Require().True(ok)
+	psig.Round++
+	ok, err = cache.VerifyDKGPartialSignatureSignature(psig)
+	s.Require().NoError(err)
+	s.Require().False(ok)
+}
+
+func (s *SignatureCacheTestSuite) TestNilCache() {
+	var cache *SignatureCache
+	vote := s.newVote()
+	ok, err := cache.VerifyVoteSignature(vote)
+	s.Require().NoError(err)
+	s.Require().True(ok)
+	s.Require().Equal(0, cache.Len())
+}
+
+func TestSignatureCache(t *testing.T) {
+	suite.Run(t, new(SignatureCacheTestSuite))
+}
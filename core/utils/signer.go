@@ -152,3 +152,10 @@ func (s *Signer) SignDKGSuccess(success *typesDKG.Success) (err error) {
 	success.Signature, err = s.prvKey.Sign(hashDKGSuccess(success))
 	return
 }
+
+// SignEvidence signs an evidence.
+func (s *Signer) SignEvidence(evidence *types.Evidence) (err error) {
+	evidence.ProposerID = s.proposerID
+	evidence.Signature, err = s.prvKey.Sign(hashSignedEvidence(evidence))
+	return
+}
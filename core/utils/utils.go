@@ -149,6 +149,16 @@ func GetBAThreshold(config *types.Config) int {
 	return int(config.NotarySetSize*2/3 + 1)
 }
 
+// GetBAWeightThreshold returns threshold for BA votes when nodes in notary set
+// are weighted, it's the total weight of votes required.
+func GetBAWeightThreshold(weights map[types.NodeID]uint64) uint64 {
+	total := uint64(0)
+	for _, w := range weights {
+		total += w
+	}
+	return total*2/3 + 1
+}
+
 // GetNextRoundValidationHeight returns the block height to check if the next
 // round is ready.
 func GetNextRoundValidationHeight(begin, length uint64) uint64 {
@@ -168,6 +178,20 @@ func GetRoundHeight(accessor interface{}, round uint64) uint64 {
 	return height
 }
 
+// GetNodeWeights returns weights of nodes in a round if the accessor exposes
+// them, nil is returned otherwise and nodes are treated equally.
+func GetNodeWeights(
+	accessor interface{}, round uint64) map[types.NodeID]uint64 {
+	type nodeWeightAccessor interface {
+		NodeWeights(round uint64) map[types.NodeID]uint64
+	}
+	accessorInst, ok := accessor.(nodeWeightAccessor)
+	if !ok {
+		return nil
+	}
+	return accessorInst.NodeWeights(round)
+}
+
 // IsDKGValid check if DKG is correctly prepared.
 func IsDKGValid(
 	gov governanceAccessor, logger common.Logger, round, reset uint64) (
@@ -24,6 +24,7 @@ import (
 
 	"github.com/stretchr/testify/suite"
 
+	"github.com/dexon-foundation/dexon-consensus/common"
 	"github.com/dexon-foundation/dexon-consensus/core/crypto"
 	"github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
 	"github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
@@ -113,6 +114,15 @@ func (s *UtilsTestSuite) TestVerifyDKGComplaint() {
 	s.False(ok)
 }
 
+func (s *UtilsTestSuite) TestGetBAWeightThreshold() {
+	weights := make(map[types.NodeID]uint64)
+	for i := uint64(1); i <= 4; i++ {
+		weights[types.NodeID{Hash: common.NewRandomHash()}] = i
+	}
+	s.Require().Equal(uint64(7), GetBAWeightThreshold(weights))
+	s.Require().Equal(uint64(1), GetBAWeightThreshold(nil))
+}
+
 func (s *UtilsTestSuite) TestDummyReceiver() {
 	var (
 		msgCount = 1000
@@ -24,6 +24,7 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
 	"github.com/dexon-foundation/dexon-consensus/core/test"
 	"github.com/dexon-foundation/dexon-consensus/core/types"
 	"github.com/dexon-foundation/dexon-consensus/core/utils"
@@ -33,6 +34,34 @@ type UtilsTestSuite struct {
 	suite.Suite
 }
 
+// weightedGovernance makes NodeSetCache treat nodes in notary set as
+// weighted.
+type weightedGovernance struct {
+	*test.Governance
+	weights map[types.NodeID]uint64
+}
+
+func (g *weightedGovernance) NodeWeights(
+	round uint64) map[types.NodeID]uint64 {
+	return g.weights
+}
+
+// newWeightedCache creates a NodeSetCache in which the first node holds the
+// majority of weights.
+func (s *UtilsTestSuite) newWeightedCache(
+	pubKeys []crypto.PublicKey) *utils.NodeSetCache {
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		pubKeys, time.Second, &common.NullLogger{}, true), ConfigRoundShift)
+	s.Require().NoError(err)
+	weights := make(map[types.NodeID]uint64)
+	for _, pubKey := range pubKeys {
+		weights[types.NewNodeID(pubKey)] = 1
+	}
+	weights[types.NewNodeID(pubKeys[0])] = 10
+	return utils.NewNodeSetCache(
+		&weightedGovernance{Governance: gov, weights: weights})
+}
+
 func (s *UtilsTestSuite) TestRemoveFromSortedUint32Slice() {
 	// Remove something exists.
 	xs := []uint32{1, 2, 3, 4, 5}
@@ -119,6 +148,77 @@ func (s *UtilsTestSuite) TestVerifyAgreementResult() {
 	s.Equal(ErrNotEnoughVotes, VerifyAgreementResult(baResult, cache))
 }
 
+func (s *UtilsTestSuite) TestVerifyWeightedAgreementResult() {
+	prvKeys, pubKeys, err := test.NewKeys(4)
+	s.Require().NoError(err)
+	cache := s.newWeightedCache(pubKeys)
+	hash := common.NewRandomHash()
+	pos := types.Position{Round: 0, Height: 20}
+	baResult := &types.AgreementResult{
+		BlockHash: hash,
+		Position:  pos,
+	}
+	// Agreement result without votes should be rejected.
+	s.Equal(ErrNotEnoughVotes, VerifyAgreementResult(baResult, cache))
+	baResult.Votes = []types.Vote{}
+	s.Equal(ErrNotEnoughVotes, VerifyAgreementResult(baResult, cache))
+
+	// Votes from light nodes are not enough.
+	for _, prvKey := range prvKeys[1:] {
+		vote := types.NewVote(types.VoteCom, hash, 0)
+		vote.Position = pos
+		s.Require().NoError(utils.NewSigner(prvKey).SignVote(vote))
+		baResult.Votes = append(baResult.Votes, *vote)
+	}
+	s.Equal(ErrNotEnoughVotes, VerifyAgreementResult(baResult, cache))
+
+	// The vote from the heavy node alone is enough.
+	vote := types.NewVote(types.VoteCom, hash, 0)
+	vote.Position = pos
+	s.Require().NoError(utils.NewSigner(prvKeys[0]).SignVote(vote))
+	baResult.Votes = []types.Vote{*vote}
+	s.Require().NoError(VerifyAgreementResult(baResult, cache))
+}
+
+func (s *UtilsTestSuite) TestVerifyAgreementCertificate() {
+	_, pubKeys, err := test.NewKeys(4)
+	s.Require().NoError(err)
+	gov, err := test.NewGovernance(test.NewState(DKGDelayRound,
+		pubKeys, time.Second, &common.NullLogger{}, true), ConfigRoundShift)
+	s.Require().NoError(err)
+	cache := utils.NewNodeSetCache(gov)
+	baResult := &types.AgreementResult{
+		BlockHash:  common.NewRandomHash(),
+		Position:   types.Position{Round: DKGDelayRound, Height: 20},
+		Randomness: []byte{0x01},
+		Format:     types.AgreementResultCertificate,
+	}
+	s.Require().NoError(VerifyAgreementResult(baResult, cache))
+
+	// Randomness should be provided.
+	baResult.Randomness = nil
+	s.Equal(ErrMissingRandomness, VerifyAgreementResult(baResult, cache))
+	baResult.Randomness = []byte{0x01}
+
+	// Votes should not be carried.
+	baResult.Votes = []types.Vote{*types.NewVote(
+		types.VoteCom, baResult.BlockHash, 0)}
+	s.Equal(ErrIncorrectAgreementResultFormat,
+		VerifyAgreementResult(baResult, cache))
+	baResult.Votes = nil
+
+	// Certificates are not available before DKGDelayRound.
+	baResult.Position.Round = 0
+	s.Equal(ErrIncorrectAgreementResultFormat,
+		VerifyAgreementResult(baResult, cache))
+	baResult.Position.Round = DKGDelayRound
+
+	// Unknown format.
+	baResult.Format = types.MaxAgreementResultFormat
+	s.Equal(ErrUnknownAgreementResultFormat,
+		VerifyAgreementResult(baResult, cache))
+}
+
 func TestUtils(t *testing.T) {
 	suite.Run(t, new(UtilsTestSuite))
 }
@@ -73,7 +73,7 @@ func (s *ByzantineTestSuite) setupNodes(
 		} else {
 			directLatencyModel = &test.FixedLatencyModel{}
 		}
-		networkModule := test.NewNetwork(k.PublicKey(), test.NetworkConfig{
+		networkModule := test.NewNetwork(k, test.NetworkConfig{
 			Type:          test.NetworkTypeFake,
 			DirectLatency: directLatencyModel,
 			GossipLatency: &test.FixedLatencyModel{},
@@ -109,7 +109,7 @@ func (s *ByzantineTestSuite) setupNodes(
 	for _, k := range prvKeys {
 		node := nodes[types.NewNodeID(k.PublicKey())]
 		// Now is the consensus module.
-		node.con = core.NewConsensus(
+		con, err := core.NewConsensus(
 			dMoment,
 			node.app,
 			node.gov,
@@ -118,6 +118,8 @@ func (s *ByzantineTestSuite) setupNodes(
 			k,
 			node.logger,
 		)
+		s.Require().NoError(err)
+		node.con = con
 	}
 	return nodes
 }
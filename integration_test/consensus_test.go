@@ -97,10 +97,42 @@ func unprohibitDKG(gov *test.Governance) {
 	gov.Unprohibit(test.StateAddDKGComplaint)
 }
 
+// pollInterval is the wall clock interval to check the progress of nodes
+// driven by a fake clock.
+const pollInterval = 100 * time.Millisecond
+
+// newFakeClock creates a clock for nodes, it jumps to the next deadline
+// whenever nodes are all waiting for it, until the returned function is
+// called.
+func (s *ConsensusTestSuite) newFakeClock(
+	dMoment time.Time) (*test.FakeClock, context.CancelFunc) {
+	clock := test.NewFakeClock(dMoment)
+	ctx, cancel := context.WithCancel(context.Background())
+	go clock.Drive(ctx, 5*time.Millisecond)
+	return clock, cancel
+}
+
 func (s *ConsensusTestSuite) setupNodes(
 	dMoment time.Time,
 	prvKeys []crypto.PrivateKey,
-	seedGov *test.Governance) map[types.NodeID]*node {
+	seedGov *test.Governance,
+	opts ...core.ConsensusOption) map[types.NodeID]*node {
+	return s.setupNodesWithClock(dMoment, prvKeys, seedGov, nil, opts...)
+}
+
+// setupNodesWithClock setups nodes whose consensus and network are both
+// driven by clock, wall clock is used if it's nil.
+func (s *ConsensusTestSuite) setupNodesWithClock(
+	dMoment time.Time,
+	prvKeys []crypto.PrivateKey,
+	seedGov *test.Governance,
+	clock *test.FakeClock,
+	opts ...core.ConsensusOption) map[types.NodeID]*node {
+	var netClock test.Clock
+	if clock != nil {
+		netClock = clock
+		opts = append(opts, core.WithClock(clock))
+	}
 	var (
 		wg        sync.WaitGroup
 		initRound uint64
@@ -116,12 +148,13 @@ func (s *ConsensusTestSuite) setupNodes(
 		dbInst, err := db.NewMemBackedDB()
 		s.Require().NoError(err)
 		// Prepare essential modules: app, gov, db.
-		networkModule := test.NewNetwork(k.PublicKey(), test.NetworkConfig{
+		networkModule := test.NewNetwork(k, test.NetworkConfig{
 			Type:          test.NetworkTypeFake,
 			DirectLatency: &test.FixedLatencyModel{},
 			GossipLatency: &test.FixedLatencyModel{},
-			Marshaller:    test.NewDefaultMarshaller(nil)},
-		)
+			Marshaller:    test.NewDefaultMarshaller(nil),
+			Clock:         netClock,
+		})
 		gov := seedGov.Clone()
 		gov.SwitchToRemoteMode(networkModule)
 		gov.NotifyRound(initRound, types.GenesisHeight)
@@ -153,10 +186,15 @@ func (s *ConsensusTestSuite) setupNodes(
 	// Make sure transport layer is ready.
 	s.Require().NoError(server.WaitForPeers(uint32(len(prvKeys))))
 	wg.Wait()
-	for _, k := range prvKeys {
+	for i, k := range prvKeys {
 		node := nodes[types.NewNodeID(k.PublicKey())]
+		// Agreement results in both formats should be accepted by all nodes.
+		format := types.AgreementResultVotes
+		if i%2 == 1 {
+			format = types.AgreementResultCertificate
+		}
 		// Now is the consensus module.
-		node.con = core.NewConsensus(
+		con, err := core.NewConsensus(
 			dMoment,
 			node.app,
 			node.gov,
@@ -164,7 +202,11 @@ func (s *ConsensusTestSuite) setupNodes(
 			node.network,
 			k,
 			node.logger,
+			append([]core.ConsensusOption{
+				core.WithAgreementResultFormat(format)}, opts...)...,
 		)
+		s.Require().NoError(err)
+		node.con = con
 	}
 	return nodes
 }
@@ -182,6 +224,29 @@ func (s *ConsensusTestSuite) verifyNodes(nodes map[types.NodeID]*node) {
 	}
 }
 
+// verifyFinalityProofs checks finality proofs of blocks delivered by a node,
+// both from the running consensus and from its db.
+func (s *ConsensusTestSuite) verifyFinalityProofs(n *node) {
+	var hashes common.Hashes
+	n.app.WithLock(func(app *test.App) {
+		for _, h := range app.DeliverSequence {
+			if app.Delivered[h].Pos.Round >= core.DKGDelayRound {
+				hashes = append(hashes, h)
+			}
+		}
+	})
+	s.Require().NotEmpty(hashes)
+	for _, h := range hashes {
+		proof, err := n.con.FinalityProof(h)
+		s.Require().NoError(err)
+		// The commitment from governance is trusted.
+		offline, err := core.NewFinalityProof(n.db, n.gov, h)
+		s.Require().NoError(err)
+		s.Require().NoError(
+			utils.VerifyFinalityProof(proof, offline.NodeSetCommitment))
+	}
+}
+
 func (s *ConsensusTestSuite) syncBlocksWithSomeNode(
 	sourceNode, syncNode *node,
 	syncerObj *syncer.Consensus,
@@ -189,11 +254,7 @@ func (s *ConsensusTestSuite) syncBlocksWithSomeNode(
 	syncedCon *core.Consensus, syncerHeight uint64, err error) {
 	syncerHeight = nextSyncHeight
 	// Setup revealer.
-	DBAll, err := sourceNode.db.GetAllBlocks()
-	if err != nil {
-		return
-	}
-	r, err := test.NewBlockRevealerByPosition(DBAll, nextSyncHeight)
+	r, err := test.NewBlockRevealerByHeight(sourceNode.db, nextSyncHeight)
 	if err != nil {
 		return
 	}
@@ -303,6 +364,10 @@ Loop:
 		break
 	}
 	s.verifyNodes(nodes)
+	for _, n := range nodes {
+		s.verifyFinalityProofs(n)
+		break
+	}
 }
 
 func (s *ConsensusTestSuite) TestSetSizeChange() {
@@ -385,6 +450,83 @@ Loop:
 	s.verifyNodes(nodes)
 }
 
+func (s *ConsensusTestSuite) TestNodeExit() {
+	// The node exit test case:
+	// - Notary set size is equal to the size of node set.
+	// - Two nodes leave from round 4 and another one leaves from round 5, BA
+	//   and DKG should continue with remaining nodes.
+	var (
+		req        = s.Require()
+		peerCount  = 7
+		dMoment    = time.Now().UTC()
+		untilRound = uint64(6)
+	)
+	prvKeys, pubKeys, err := test.NewKeys(peerCount)
+	req.NoError(err)
+	// Setup seed governance instance.
+	seedGov, err := test.NewGovernance(
+		test.NewState(core.DKGDelayRound,
+			pubKeys, 100*time.Millisecond, &common.NullLogger{}, true),
+		core.ConfigRoundShift)
+	req.NoError(err)
+	req.NoError(seedGov.State().RequestChange(
+		test.StateChangeRoundLength, uint64(100)))
+	req.NoError(seedGov.State().RequestChange(
+		test.StateChangeNotarySetSize, uint32(4)))
+	seedGov.CatchUpWithRound(0)
+	seedGov.CatchUpWithRound(1)
+	nodes := s.setupNodes(dMoment, prvKeys, seedGov)
+	// Schedule exits on one node, they would be broadcasted to others and
+	// applied when delivered.
+	exits := map[types.NodeID]uint64{
+		types.NewNodeID(pubKeys[0]): 4,
+		types.NewNodeID(pubKeys[1]): 4,
+		types.NewNodeID(pubKeys[2]): 5,
+	}
+	pickedNode := nodes[types.NewNodeID(pubKeys[6])]
+	for i := 0; i < 3; i++ {
+		req.NoError(pickedNode.gov.ScheduleNodeExit(
+			pubKeys[i], exits[types.NewNodeID(pubKeys[i])]))
+	}
+	remains := make(map[types.NodeID]*node)
+	for nID, n := range nodes {
+		if _, exited := exits[nID]; !exited {
+			remains[nID] = n
+		}
+	}
+	for _, n := range nodes {
+		go n.con.Run()
+		defer n.con.Stop()
+	}
+Loop:
+	for {
+		<-time.After(5 * time.Second)
+		for _, n := range remains {
+			latestPos := n.app.GetLatestDeliveredPosition()
+			fmt.Println("latestPos", n.ID, &latestPos)
+			if latestPos.Round < untilRound {
+				continue Loop
+			}
+		}
+		break
+	}
+	s.verifyNodes(remains)
+	// Exited nodes are no longer in node sets.
+	for _, n := range remains {
+		for round := uint64(4); round <= untilRound; round++ {
+			nodeSet := make(map[types.NodeID]struct{})
+			for _, key := range n.gov.NodeSet(round) {
+				nodeSet[types.NewNodeID(key)] = struct{}{}
+			}
+			for nID, exitRound := range exits {
+				if round >= exitRound {
+					req.NotContains(nodeSet, nID)
+				}
+			}
+		}
+	}
+}
+
 func (s *ConsensusTestSuite) TestSync() {
 	// The sync test case:
 	// - No configuration change.
@@ -414,8 +556,10 @@ func (s *ConsensusTestSuite) TestSync() {
 		test.StateChangeRoundLength, uint64(100)))
 	seedGov.CatchUpWithRound(0)
 	seedGov.CatchUpWithRound(1)
+	clock, stopClock := s.newFakeClock(dMoment)
+	defer stopClock()
 	// A short round interval.
-	nodes := s.setupNodes(dMoment, prvKeys, seedGov)
+	nodes := s.setupNodesWithClock(dMoment, prvKeys, seedGov, clock)
 	// Choose the first node as "syncNode" that its consensus' Run() is called
 	// later.
 	syncNode := nodes[types.NewNodeID(pubKeys[0])]
@@ -447,7 +591,7 @@ ReachAlive:
 		select {
 		case err := <-errChan:
 			req.NoError(err)
-		case <-time.After(5 * time.Second):
+		case <-time.After(pollInterval):
 		}
 		// If all nodes excepts syncNode have reached aliveRound, call syncNode's
 		// Run() and send it all blocks in one of normal node's compaction chain.
@@ -482,6 +626,7 @@ ReachAlive:
 		syncNode.network,
 		prvKeys[0],
 		logger,
+		core.WithClock(clock),
 	)
 	// Initialize communication channel, it's not recommended to assertion in
 	// another go routine.
@@ -511,7 +656,7 @@ ReachAlive:
 			select {
 			case <-runnerCtx.Done():
 				break SyncLoop
-			case <-time.After(4 * time.Second):
+			case <-time.After(pollInterval):
 			}
 		}
 	}()
@@ -522,7 +667,7 @@ ReachAlive:
 	ReachFinished:
 		for {
 			fmt.Println("latestPos", n.ID, &pos)
-			time.Sleep(5 * time.Second)
+			time.Sleep(pollInterval)
 			if stoppedNode.con != nil {
 				pos = n.app.GetLatestDeliveredPosition()
 				if pos.Round >= stopRound {
@@ -730,7 +875,9 @@ func (s *ConsensusTestSuite) TestResetDKG() {
 		test.StateChangeRoundLength, uint64(100)))
 	req.NoError(seedGov.State().RequestChange(
 		test.StateChangeNotarySetSize, uint32(4)))
-	nodes := s.setupNodes(dMoment, prvKeys, seedGov)
+	clock, stopClock := s.newFakeClock(dMoment)
+	defer stopClock()
+	nodes := s.setupNodesWithClock(dMoment, prvKeys, seedGov, clock)
 	for _, n := range nodes {
 		n.rEvt.Register(purgeHandlerGen(n.network))
 		// Round Height reference table:
@@ -748,7 +895,7 @@ func (s *ConsensusTestSuite) TestResetDKG() {
 	}
 Loop:
 	for {
-		<-time.After(5 * time.Second)
+		<-time.After(pollInterval)
 		for _, n := range nodes {
 			latestPos := n.app.GetLatestDeliveredPosition()
 			fmt.Println("latestPos", n.ID, &latestPos)
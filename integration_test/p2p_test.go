@@ -0,0 +1,134 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core"
+	"github.com/dexon-foundation/dexon-consensus/core/db"
+	"github.com/dexon-foundation/dexon-consensus/core/test"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+	"github.com/dexon-foundation/dexon-consensus/network/p2p"
+)
+
+type p2pNode struct {
+	ID      types.NodeID
+	con     *core.Consensus
+	app     *test.App
+	db      db.Database
+	network *p2p.Network
+}
+
+// P2PTestSuite runs consensus over p2p.Network on loopback addresses. All
+// nodes share one governance instance, just like a governance contract on
+// chain.
+type P2PTestSuite struct {
+	suite.Suite
+}
+
+func (s *P2PTestSuite) TestLoopback() {
+	if testing.Short() {
+		return
+	}
+	var (
+		req        = s.Require()
+		peerCount  = 4
+		dMoment    = time.Now().UTC().Add(3 * time.Second)
+		untilRound = uint64(3)
+	)
+	prvKeys, pubKeys, err := test.NewKeys(peerCount)
+	req.NoError(err)
+	gov, err := test.NewGovernance(
+		test.NewState(core.DKGDelayRound,
+			pubKeys, 100*time.Millisecond, &common.NullLogger{}, true),
+		core.ConfigRoundShift)
+	req.NoError(err)
+	req.NoError(gov.State().RequestChange(
+		test.StateChangeRoundLength, uint64(100)))
+	gov.NotifyRound(0, types.GenesisHeight)
+	var (
+		nodes     []*p2pNode
+		bootstrap []string
+	)
+	for _, k := range prvKeys {
+		dbInst, err := db.NewMemBackedDB()
+		req.NoError(err)
+		logger := &common.NullLogger{}
+		networkModule := p2p.NewNetwork(k, gov, p2p.Config{
+			ListenAddr:   "127.0.0.1:0",
+			Bootstrap:    bootstrap,
+			DialInterval: 200 * time.Millisecond,
+		}, logger)
+		req.NoError(networkModule.Start())
+		defer networkModule.Close()
+		bootstrap = []string{networkModule.Addr()}
+		rEvt, err := utils.NewRoundEvent(context.Background(), gov, logger,
+			types.Position{Height: types.GenesisHeight}, core.ConfigRoundShift)
+		req.NoError(err)
+		rEvt.Register(func(evts []utils.RoundEventParam) {
+			networkModule.NotifyRound(evts[len(evts)-1].Round)
+		})
+		app := test.NewApp(1, gov, rEvt)
+		con, err := core.NewConsensus(dMoment, app, gov, dbInst,
+			networkModule, k, logger)
+		req.NoError(err)
+		nodes = append(nodes, &p2pNode{
+			ID:      types.NewNodeID(k.PublicKey()),
+			con:     con,
+			app:     app,
+			db:      dbInst,
+			network: networkModule,
+		})
+	}
+	for _, n := range nodes {
+		go n.con.Run()
+		defer n.con.Stop()
+	}
+Loop:
+	for {
+		<-time.After(5 * time.Second)
+		for _, n := range nodes {
+			latestPos := n.app.GetLatestDeliveredPosition()
+			fmt.Println("latestPos", n.ID, &latestPos,
+				"peers", len(n.network.Peers()))
+			if latestPos.Round < untilRound {
+				continue Loop
+			}
+		}
+		break
+	}
+	for i, n := range nodes {
+		req.NoError(test.VerifyDB(n.db))
+		req.NoError(n.app.Verify())
+		for _, other := range nodes[i+1:] {
+			req.NoError(n.app.Compare(other.app))
+		}
+	}
+}
+
+func TestP2P(t *testing.T) {
+	suite.Run(t, new(P2PTestSuite))
+}
@@ -0,0 +1,75 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	"github.com/dexon-foundation/dexon-consensus/network/secure"
+)
+
+const (
+	// protocolVersion is bumped when peers of different versions can't talk
+	// to each other.
+	protocolVersion uint32 = 3
+	// maxFrameSize is the maximum size of a frame sent between peers.
+	maxFrameSize     = 16 * 1024 * 1024
+	handshakeTimeout = 5 * time.Second
+)
+
+// ErrEmptyFrame means a frame without its kind is received.
+var ErrEmptyFrame = errors.New("empty frame")
+
+type frameKind uint8
+
+const (
+	// frameMsg carries messages gossiped to all nodes.
+	frameMsg frameKind = iota
+	// frameDirectMsg carries messages to the receiver only, they are not
+	// relayed or deduplicated.
+	frameDirectMsg
+	framePullBlocks
+	framePullVotes
+	framePeersRequest
+	framePeers
+)
+
+// handshake authenticates the peer of a connection within handshakeTimeout,
+// and announces the address we listen on. The allow function is called to
+// check if the authenticated peer is welcome.
+func handshake(conn net.Conn, prvKey crypto.PrivateKey, listenAddr string,
+	allow func(types.NodeID) bool) (res *secure.Peer, err error) {
+	if err = conn.SetDeadline(time.Now().Add(handshakeTimeout)); err != nil {
+		return
+	}
+	defer func() {
+		if resetErr := conn.SetDeadline(time.Time{}); err == nil {
+			err = resetErr
+		}
+	}()
+	return secure.Handshake(conn, prvKey, secure.Config{
+		Version:    protocolVersion,
+		Info:       []byte(listenAddr),
+		Allow:      allow,
+		MaxMsgSize: maxFrameSize,
+	})
+}
@@ -0,0 +1,900 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package p2p implements core.Network over TCP. Peers are authenticated by
+// their keys in handshake, which also negotiates session keys to encrypt
+// frames between them. Peers are found from bootstrap nodes and addresses
+// exchanged between peers, and limited to node sets of recent rounds. Blocks,
+// votes and agreement results are gossiped to all peers, DKG messages are sent
+// directly to receivers.
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/codec"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+)
+
+const (
+	defaultDialInterval = 2 * time.Second
+	seenCacheSize       = 16384
+	blockCacheSize      = 1024
+	maxVoteCache        = 1024
+	maxPullingPeerCount = 3
+	maxPeerAddrs        = 1024
+	receiveChanSize     = 1000
+	// badPeerBanDuration is how long a reported peer is refused.
+	badPeerBanDuration = 10 * time.Minute
+)
+
+var (
+	// ErrNetworkClosed means the network is already closed.
+	ErrNetworkClosed = errors.New("network closed")
+	// ErrMessageTypeTooLong means the type of a message returned by the
+	// marshaller is too long to be encoded.
+	ErrMessageTypeTooLong = errors.New("message type too long")
+	// ErrUnexpectedMessage means a peer sends a message not expected by
+	// core.Consensus.
+	ErrUnexpectedMessage = errors.New("unexpected message")
+)
+
+// Marshaller encodes and decodes messages sent by core.Consensus, it has the
+// same method set as test.Marshaller.
+type Marshaller interface {
+	// Unmarshal converts a []byte back to interface based on the type
+	// of message.
+	Unmarshal(msgType string, payload []byte) (msg interface{}, err error)
+	// Marshal converts a message to byte string.
+	Marshal(msg interface{}) (msgType string, payload []byte, err error)
+}
+
+// Config is the configuration of Network.
+type Config struct {
+	// ListenAddr is the address to accept connections from peers, ex.
+	// "127.0.0.1:0" for loopback only tests.
+	ListenAddr string
+	// AnnounceAddr is the address announced to peers, the address of the
+	// listener is used if it's empty.
+	AnnounceAddr string
+	// Bootstrap is a static list of addresses to connect to when there is no
+	// peer.
+	Bootstrap []string
+	// Marshaller encodes messages of core.Consensus, codec.Codec is used if
+	// it's nil.
+	Marshaller Marshaller
+	// DialInterval is the interval to connect to missing peers and exchange
+	// addresses with peers.
+	DialInterval time.Duration
+}
+
+type peerAddr struct {
+	PubKey []byte `json:"pubkey"`
+	Addr   string `json:"addr"`
+}
+
+// Network implements core.Network over TCP connections.
+type Network struct {
+	ID            types.NodeID
+	prvKey        crypto.PrivateKey
+	config        Config
+	marshaller    Marshaller
+	cache         *utils.NodeSetCache
+	logger        common.Logger
+	ctx           context.Context
+	cancel        context.CancelFunc
+	listener      net.Listener
+	lock          sync.RWMutex
+	peers         map[types.NodeID]*peer
+	addrBook      map[types.NodeID]string
+	dialing       map[string]struct{}
+	allowed       map[types.NodeID]struct{}
+	banned        map[types.NodeID]time.Time
+	seen          *lru.Cache
+	blocks        *lru.Cache
+	voteCacheLock sync.Mutex
+	votes         map[types.Position][]*types.Vote
+	votePositions []types.Position
+	voteCacheSize int
+	toConsensus   chan types.Msg
+	badPeerChan   chan interface{}
+	badPeerReport chan *types.BadPeerReport
+	wg            sync.WaitGroup
+}
+
+// NewNetwork constructs a Network instance. Peers are limited to node sets
+// provided by nsIntf, everyone is accepted when it's nil.
+func NewNetwork(prvKey crypto.PrivateKey, nsIntf utils.NodeSetCacheInterface,
+	config Config, logger common.Logger) *Network {
+	if config.Marshaller == nil {
+		config.Marshaller = codec.NewCodec(0)
+	}
+	if config.DialInterval <= 0 {
+		config.DialInterval = defaultDialInterval
+	}
+	seen, err := lru.New(seenCacheSize)
+	if err != nil {
+		panic(err)
+	}
+	blocks, err := lru.New(blockCacheSize)
+	if err != nil {
+		panic(err)
+	}
+	n := &Network{
+		ID:          types.NewNodeID(prvKey.PublicKey()),
+		prvKey:      prvKey,
+		config:      config,
+		marshaller:  config.Marshaller,
+		logger:      logger,
+		peers:       make(map[types.NodeID]*peer),
+		addrBook:    make(map[types.NodeID]string),
+		dialing:     make(map[string]struct{}),
+		banned:      make(map[types.NodeID]time.Time),
+		seen:        seen,
+		blocks:      blocks,
+		votes:       make(map[types.Position][]*types.Vote),
+		toConsensus: make(chan types.Msg, receiveChanSize),
+		badPeerChan: make(chan interface{}, receiveChanSize),
+		badPeerReport: make(
+			chan *types.BadPeerReport, receiveChanSize),
+	}
+	if nsIntf != nil {
+		n.cache = utils.NewNodeSetCache(nsIntf)
+	}
+	n.ctx, n.cancel = context.WithCancel(context.Background())
+	return n
+}
+
+// Start listens for peers and connects to bootstrap nodes.
+func (n *Network) Start() (err error) {
+	if n.listener, err = net.Listen("tcp", n.config.ListenAddr); err != nil {
+		return
+	}
+	n.NotifyRound(0)
+	n.wg.Add(3)
+	go n.acceptLoop()
+	go n.maintainLoop()
+	go n.badPeerLoop()
+	n.dialMissingPeers()
+	return
+}
+
+// Close disconnects all peers and stops the network.
+func (n *Network) Close() (err error) {
+	select {
+	case <-n.ctx.Done():
+		return ErrNetworkClosed
+	default:
+	}
+	n.cancel()
+	if n.listener != nil {
+		err = n.listener.Close()
+	}
+	n.lock.Lock()
+	for _, p := range n.peers {
+		p.close()
+	}
+	n.lock.Unlock()
+	n.wg.Wait()
+	close(n.toConsensus)
+	return
+}
+
+// Addr returns the address announced to peers.
+func (n *Network) Addr() string {
+	if n.config.AnnounceAddr != "" {
+		return n.config.AnnounceAddr
+	}
+	if n.listener == nil {
+		return ""
+	}
+	return n.listener.Addr().String()
+}
+
+// Peers returns IDs of connected peers.
+func (n *Network) Peers() (IDs []types.NodeID) {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+	for nID := range n.peers {
+		IDs = append(IDs, nID)
+	}
+	return
+}
+
+// NotifyRound limits peers to nodes in node sets of the previous, current and
+// next rounds, peers not in them are disconnected. It should be called when
+// entering a new round.
+func (n *Network) NotifyRound(round uint64) {
+	if n.cache == nil {
+		return
+	}
+	allowed := make(map[types.NodeID]struct{})
+	begin := round
+	if begin > 0 {
+		begin--
+	}
+	for r := begin; r <= round+1; r++ {
+		nodeSet, err := n.cache.GetNodeSet(r)
+		if err != nil {
+			// Node set of the next round might not be ready yet.
+			if r <= round {
+				n.logger.Warn("Failed to get node set",
+					"round", r, "error", err)
+			}
+			continue
+		}
+		for nID := range nodeSet.IDs {
+			allowed[nID] = struct{}{}
+		}
+	}
+	if len(allowed) == 0 {
+		return
+	}
+	var disallowed []*peer
+	func() {
+		n.lock.Lock()
+		defer n.lock.Unlock()
+		n.allowed = allowed
+		for nID, p := range n.peers {
+			if _, exists := allowed[nID]; !exists {
+				disallowed = append(disallowed, p)
+			}
+		}
+	}()
+	for _, p := range disallowed {
+		n.logger.Info("Disconnect peer not in node sets", "peer", p.ID)
+		p.close()
+	}
+}
+
+// PullBlocks implements core.Network interface.
+func (n *Network) PullBlocks(hashes common.Hashes) {
+	payload, err := json.Marshal(hashes)
+	if err != nil {
+		n.logger.Error("Failed to encode pull request", "error", err)
+		return
+	}
+	n.sendToSome(nil, maxPullingPeerCount, framePullBlocks, payload)
+}
+
+// PullVotes implements core.Network interface.
+func (n *Network) PullVotes(pos types.Position) {
+	payload, err := json.Marshal(&pos)
+	if err != nil {
+		n.logger.Error("Failed to encode pull request", "error", err)
+		return
+	}
+	n.sendToSome(n.notarySet(pos.Round), maxPullingPeerCount, framePullVotes,
+		payload)
+}
+
+// BroadcastVote implements core.Network interface.
+func (n *Network) BroadcastVote(vote *types.Vote) {
+	n.addVoteToCache(vote)
+	n.gossip(vote)
+}
+
+// BroadcastBlock implements core.Network interface.
+func (n *Network) BroadcastBlock(block *types.Block) {
+	block = block.Clone()
+	n.blocks.Add(block.Hash, block)
+	n.gossip(block)
+}
+
+// BroadcastAgreementResult implements core.Network interface.
+func (n *Network) BroadcastAgreementResult(result *types.AgreementResult) {
+	n.gossip(result)
+}
+
+// SendDKGPrivateShare implements core.Network interface.
+func (n *Network) SendDKGPrivateShare(
+	pubKey crypto.PublicKey, prvShare *typesDKG.PrivateShare) {
+	payload, err := n.encodeMsg(prvShare)
+	if err != nil {
+		n.logger.Error("Failed to encode message", "error", err)
+		return
+	}
+	n.lock.RLock()
+	p, exists := n.peers[types.NewNodeID(pubKey)]
+	n.lock.RUnlock()
+	if !exists || !p.send(frameDirectMsg, payload) {
+		n.logger.Warn("Failed to send private share",
+			"receiver", types.NewNodeID(pubKey))
+	}
+}
+
+// BroadcastDKGPrivateShare implements core.Network interface.
+func (n *Network) BroadcastDKGPrivateShare(prvShare *typesDKG.PrivateShare) {
+	n.sendMsgToSet(n.notarySet(prvShare.Round), prvShare)
+}
+
+// BroadcastDKGPartialSignature implements core.Network interface.
+func (n *Network) BroadcastDKGPartialSignature(
+	psig *typesDKG.PartialSignature) {
+	n.sendMsgToSet(n.notarySet(psig.Round), psig)
+}
+
+// BroadcastEvidence implements core.EvidenceBroadcaster interface.
+func (n *Network) BroadcastEvidence(evidence *types.Evidence) {
+	n.sendMsgToSet(nil, evidence)
+}
+
+// ReceiveChan implements core.Network interface.
+func (n *Network) ReceiveChan() <-chan types.Msg {
+	return n.toConsensus
+}
+
+// ReportBadPeerChan implements core.Network interface, reported peers are
+// disconnected and refused for a while.
+func (n *Network) ReportBadPeerChan() chan<- interface{} {
+	return n.badPeerChan
+}
+
+// BadPeerReportChan implements core.BadPeerReporter interface, reported
+// peers are handled like the ones reported through ReportBadPeerChan with
+// reasons logged.
+func (n *Network) BadPeerReportChan() chan<- *types.BadPeerReport {
+	return n.badPeerReport
+}
+
+func (n *Network) acceptLoop() {
+	defer n.wg.Done()
+	for {
+		conn, err := n.listener.Accept()
+		if err != nil {
+			select {
+			case <-n.ctx.Done():
+				return
+			default:
+			}
+			n.logger.Error("Failed to accept connection", "error", err)
+			select {
+			case <-n.ctx.Done():
+				return
+			case <-time.After(100 * time.Millisecond):
+			}
+			continue
+		}
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+			n.setupConn(conn, false)
+		}()
+	}
+}
+
+func (n *Network) maintainLoop() {
+	defer n.wg.Done()
+	ticker := time.NewTicker(n.config.DialInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-n.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		n.dialMissingPeers()
+		// Exchange addresses with peers.
+		n.lock.RLock()
+		for _, p := range n.peers {
+			p.send(framePeersRequest, nil)
+		}
+		n.lock.RUnlock()
+	}
+}
+
+func (n *Network) badPeerLoop() {
+	defer n.wg.Done()
+	for {
+		select {
+		case <-n.ctx.Done():
+			return
+		case peer := <-n.badPeerChan:
+			n.banPeer(peer, nil)
+		case r := <-n.badPeerReport:
+			n.banPeer(r.PeerID, r.Reason)
+		}
+	}
+}
+
+func (n *Network) banPeer(peer interface{}, reason error) {
+	nID, ok := peer.(types.NodeID)
+	if !ok {
+		return
+	}
+	n.lock.Lock()
+	n.banned[nID] = time.Now().Add(badPeerBanDuration)
+	p, exists := n.peers[nID]
+	n.lock.Unlock()
+	if exists {
+		n.logger.Info("Disconnect bad peer", "peer", nID, "reason", reason)
+		p.close()
+	}
+}
+
+// dialMissingPeers connects to known peers in node sets not connected yet,
+// and bootstrap nodes when there is no peer.
+func (n *Network) dialMissingPeers() {
+	var addrs []string
+	func() {
+		n.lock.Lock()
+		defer n.lock.Unlock()
+		if len(n.peers) == 0 {
+			addrs = append(addrs, n.config.Bootstrap...)
+		}
+		for nID, addr := range n.addrBook {
+			if _, connected := n.peers[nID]; connected {
+				continue
+			}
+			if !n.isAllowedNoLock(nID) {
+				continue
+			}
+			addrs = append(addrs, addr)
+		}
+		dialing := addrs[:0]
+		for _, addr := range addrs {
+			if _, exists := n.dialing[addr]; exists || addr == n.Addr() {
+				continue
+			}
+			n.dialing[addr] = struct{}{}
+			dialing = append(dialing, addr)
+		}
+		addrs = dialing
+	}()
+	for _, addr := range addrs {
+		n.wg.Add(1)
+		go func(addr string) {
+			defer n.wg.Done()
+			defer func() {
+				n.lock.Lock()
+				defer n.lock.Unlock()
+				delete(n.dialing, addr)
+			}()
+			conn, err := net.DialTimeout("tcp", addr, handshakeTimeout)
+			if err != nil {
+				n.logger.Debug("Failed to dial", "addr", addr, "error", err)
+				return
+			}
+			n.setupConn(conn, true)
+		}(addr)
+	}
+}
+
+// setupConn authenticates a connection and serves it as a peer.
+func (n *Network) setupConn(conn net.Conn, outbound bool) {
+	res, err := handshake(conn, n.prvKey, n.Addr(), n.isAllowed)
+	if err != nil {
+		n.logger.Debug("Handshake failed",
+			"remote", conn.RemoteAddr(), "error", err)
+		conn.Close()
+		return
+	}
+	p := newPeer(conn, res, outbound)
+	if !n.addPeer(p) {
+		p.close()
+		return
+	}
+	n.logger.Debug("Peer connected", "peer", p.ID, "outbound", outbound)
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		p.writeLoop()
+	}()
+	p.send(framePeersRequest, nil)
+	if err = p.readLoop(n.handleFrame); err != nil {
+		select {
+		case <-n.ctx.Done():
+		default:
+			n.logger.Debug("Peer disconnected", "peer", p.ID, "error", err)
+		}
+	}
+	n.removePeer(p)
+}
+
+// addPeer registers a peer, it returns false if the existing connection to
+// that peer is kept. When two nodes dial each other at the same time, both
+// sides keep the connection dialed by the node with smaller ID.
+func (n *Network) addPeer(p *peer) bool {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	select {
+	case <-n.ctx.Done():
+		return false
+	default:
+	}
+	if old, exists := n.peers[p.ID]; exists {
+		dialer := func(p *peer) types.NodeID {
+			if p.outbound {
+				return n.ID
+			}
+			return p.ID
+		}
+		oldDialer, newDialer := dialer(old), dialer(p)
+		if oldDialer != newDialer &&
+			bytes.Compare(newDialer.Hash[:], oldDialer.Hash[:]) > 0 {
+			return false
+		}
+		old.close()
+	}
+	n.peers[p.ID] = p
+	if p.listenAddr != "" {
+		n.addrBook[p.ID] = p.listenAddr
+	}
+	return true
+}
+
+func (n *Network) removePeer(p *peer) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if n.peers[p.ID] == p {
+		delete(n.peers, p.ID)
+	}
+}
+
+func (n *Network) isAllowed(nID types.NodeID) bool {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.isAllowedNoLock(nID)
+}
+
+func (n *Network) isAllowedNoLock(nID types.NodeID) bool {
+	if until, exists := n.banned[nID]; exists {
+		if time.Now().Before(until) {
+			return false
+		}
+		delete(n.banned, nID)
+	}
+	if n.allowed == nil {
+		return true
+	}
+	_, exists := n.allowed[nID]
+	return exists
+}
+
+func (n *Network) handleFrame(p *peer, kind frameKind, payload []byte) error {
+	switch kind {
+	case frameMsg:
+		return n.handleMsg(p, payload)
+	case frameDirectMsg:
+		msg, err := n.decodeMsg(payload)
+		if err != nil {
+			return err
+		}
+		n.deliver(p, msg)
+	case framePullBlocks:
+		var hashes common.Hashes
+		if err := json.Unmarshal(payload, &hashes); err != nil {
+			return err
+		}
+		for _, h := range hashes {
+			if b, exists := n.blocks.Get(h); exists {
+				n.sendMsg(p, b)
+			}
+		}
+	case framePullVotes:
+		var pos types.Position
+		if err := json.Unmarshal(payload, &pos); err != nil {
+			return err
+		}
+		n.voteCacheLock.Lock()
+		votes := append([]*types.Vote(nil), n.votes[pos]...)
+		n.voteCacheLock.Unlock()
+		for _, v := range votes {
+			n.sendMsg(p, v)
+		}
+	case framePeersRequest:
+		var addrs []peerAddr
+		n.lock.RLock()
+		for nID, other := range n.peers {
+			if nID == p.ID || other.listenAddr == "" {
+				continue
+			}
+			addrs = append(addrs, peerAddr{
+				PubKey: other.pubKey.Bytes(),
+				Addr:   other.listenAddr,
+			})
+		}
+		n.lock.RUnlock()
+		b, err := json.Marshal(addrs)
+		if err != nil {
+			return err
+		}
+		p.send(framePeers, b)
+	case framePeers:
+		var addrs []peerAddr
+		if err := json.Unmarshal(payload, &addrs); err != nil {
+			return err
+		}
+		if len(addrs) > maxPeerAddrs {
+			addrs = addrs[:maxPeerAddrs]
+		}
+		n.lock.Lock()
+		for _, a := range addrs {
+			key, err := ecdsa.NewPublicKeyFromByteSlice(a.PubKey)
+			if err != nil || a.Addr == "" {
+				continue
+			}
+			if nID := types.NewNodeID(key); nID != n.ID {
+				n.addrBook[nID] = a.Addr
+			}
+		}
+		n.lock.Unlock()
+	default:
+		n.logger.Debug("Unknown frame", "peer", p.ID, "kind", kind)
+	}
+	return nil
+}
+
+// handleMsg delivers a gossiped message and relays it to other peers when it's
+// not seen before. Messages failing verifyMsg are neither cached nor relayed,
+// and the peer is disconnected.
+func (n *Network) handleMsg(p *peer, payload []byte) error {
+	msg, err := n.decodeMsg(payload)
+	if err != nil {
+		return err
+	}
+	switch v := msg.(type) {
+	case *types.Block, *types.Vote, *types.AgreementResult:
+		// Messages are marked seen after verified, or forged ones could
+		// make the genuine ones dropped.
+		if n.isSeen(msg) {
+			return nil
+		}
+		if err = verifyMsg(msg); err != nil {
+			return err
+		}
+		if !n.markSeen(msg) {
+			return nil
+		}
+		switch v := v.(type) {
+		case *types.Block:
+			n.cacheBlock(v)
+		case *types.Vote:
+			n.addVoteToCache(v)
+		}
+		n.broadcast(p.ID, frameMsg, payload)
+	default:
+		return ErrUnexpectedMessage
+	}
+	n.deliver(p, msg)
+	return nil
+}
+
+// verifyMsg checks hashes and signatures of a gossiped message, so peers
+// could not spread forged messages through this node. Randomness is not
+// verified here, it's left to core.Consensus.
+func verifyMsg(msg interface{}) error {
+	switch v := msg.(type) {
+	case *types.Block:
+		if !v.IsEmpty() {
+			return utils.VerifyBlockSignature(v)
+		}
+		// Empty blocks are not signed by proposers.
+		hash, err := utils.HashBlock(v)
+		if err != nil {
+			return err
+		}
+		if hash != v.Hash {
+			return utils.ErrIncorrectHash
+		}
+	case *types.Vote:
+		return verifyVote(v)
+	case *types.AgreementResult:
+		for i := range v.Votes {
+			if err := verifyVote(&v.Votes[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func verifyVote(vote *types.Vote) error {
+	ok, err := utils.VerifyVoteSignature(vote)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return utils.ErrIncorrectSignature
+	}
+	return nil
+}
+
+// cacheBlock caches a block for pulling, the one with randomness is not
+// replaced by copies without it.
+func (n *Network) cacheBlock(b *types.Block) {
+	if cached, exists := n.blocks.Get(b.Hash); exists &&
+		len(cached.(*types.Block).Randomness) != 0 {
+		return
+	}
+	n.blocks.Add(b.Hash, b.Clone())
+}
+
+func (n *Network) deliver(p *peer, msg interface{}) {
+	select {
+	case n.toConsensus <- types.Msg{PeerID: p.ID, Payload: msg}:
+	case <-n.ctx.Done():
+	}
+}
+
+// gossip sends a message to all peers, and it won't be relayed again when
+// received from peers.
+func (n *Network) gossip(msg interface{}) {
+	n.markSeen(msg)
+	payload, err := n.encodeMsg(msg)
+	if err != nil {
+		n.logger.Error("Failed to encode message", "error", err)
+		return
+	}
+	n.broadcast(types.NodeID{}, frameMsg, payload)
+}
+
+// markSeen returns false if the message is seen before.
+func (n *Network) markSeen(msg interface{}) bool {
+	key, ok := seenKey(msg)
+	if !ok {
+		return true
+	}
+	seen, _ := n.seen.ContainsOrAdd(key, struct{}{})
+	return !seen
+}
+
+// isSeen checks if the message is seen before without marking it.
+func (n *Network) isSeen(msg interface{}) bool {
+	key, ok := seenKey(msg)
+	return ok && n.seen.Contains(key)
+}
+
+// seenKey returns the key of a message in the dedup cache, ok is false for
+// messages not deduplicated.
+func seenKey(msg interface{}) (key common.Hash, ok bool) {
+	switch v := msg.(type) {
+	case *types.Block:
+		key = crypto.Keccak256Hash(v.Hash[:], v.Randomness)
+	case *types.Vote:
+		h := utils.HashVote(v)
+		key = crypto.Keccak256Hash(h[:], v.Signature.Signature)
+	case *types.AgreementResult:
+		key = crypto.Keccak256Hash(v.BlockHash[:], v.Randomness)
+	default:
+		return
+	}
+	ok = true
+	return
+}
+
+func (n *Network) broadcast(except types.NodeID, kind frameKind,
+	payload []byte) {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+	for nID, p := range n.peers {
+		if nID == except {
+			continue
+		}
+		if !p.send(kind, payload) {
+			n.logger.Debug("Failed to send to peer", "peer", nID)
+		}
+	}
+}
+
+// sendToSome sends a frame to at most count peers in set, or any peer when
+// set is nil.
+func (n *Network) sendToSome(set map[types.NodeID]struct{}, count int,
+	kind frameKind, payload []byte) {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+	for nID, p := range n.peers {
+		if count <= 0 {
+			break
+		}
+		if set != nil {
+			if _, exists := set[nID]; !exists {
+				continue
+			}
+		}
+		if p.send(kind, payload) {
+			count--
+		}
+	}
+}
+
+// sendMsgToSet sends a message to connected peers in set, or all peers when
+// set is nil.
+func (n *Network) sendMsgToSet(set map[types.NodeID]struct{},
+	msg interface{}) {
+	payload, err := n.encodeMsg(msg)
+	if err != nil {
+		n.logger.Error("Failed to encode message", "error", err)
+		return
+	}
+	n.sendToSome(set, len(n.Peers()), frameDirectMsg, payload)
+}
+
+func (n *Network) sendMsg(p *peer, msg interface{}) {
+	payload, err := n.encodeMsg(msg)
+	if err != nil {
+		n.logger.Error("Failed to encode message", "error", err)
+		return
+	}
+	p.send(frameDirectMsg, payload)
+}
+
+// notarySet returns notary set of a round, nil is returned if it's not
+// available.
+func (n *Network) notarySet(round uint64) map[types.NodeID]struct{} {
+	if n.cache == nil {
+		return nil
+	}
+	set, err := n.cache.GetNotarySet(round)
+	if err != nil {
+		n.logger.Warn("Failed to get notary set", "round", round, "error", err)
+		return nil
+	}
+	return set
+}
+
+func (n *Network) addVoteToCache(v *types.Vote) {
+	n.voteCacheLock.Lock()
+	defer n.voteCacheLock.Unlock()
+	if n.voteCacheSize >= maxVoteCache {
+		pos := n.votePositions[0]
+		n.voteCacheSize -= len(n.votes[pos])
+		delete(n.votes, pos)
+		n.votePositions = n.votePositions[1:]
+	}
+	if _, exists := n.votes[v.Position]; !exists {
+		n.votePositions = append(n.votePositions, v.Position)
+	}
+	n.votes[v.Position] = append(n.votes[v.Position], v)
+	n.voteCacheSize++
+}
+
+// encodeMsg encodes a message as the length of its type, the type and the
+// payload from the marshaller.
+func (n *Network) encodeMsg(msg interface{}) ([]byte, error) {
+	msgType, payload, err := n.marshaller.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	if len(msgType) > 255 {
+		return nil, ErrMessageTypeTooLong
+	}
+	b := make([]byte, 0, 1+len(msgType)+len(payload))
+	b = append(b, byte(len(msgType)))
+	b = append(b, msgType...)
+	return append(b, payload...), nil
+}
+
+func (n *Network) decodeMsg(b []byte) (interface{}, error) {
+	if len(b) == 0 || len(b) < 1+int(b[0]) {
+		return nil, ErrUnexpectedMessage
+	}
+	return n.marshaller.Unmarshal(string(b[1:1+int(b[0])]), b[1+int(b[0]):])
+}
@@ -0,0 +1,314 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	cryptoDKG "github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	typesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+	"github.com/dexon-foundation/dexon-consensus/core/utils"
+	"github.com/dexon-foundation/dexon-consensus/network/secure"
+)
+
+type nsIntf struct {
+	keys []crypto.PublicKey
+}
+
+func (g *nsIntf) Configuration(round uint64) *types.Config {
+	return &types.Config{
+		NotarySetSize: uint32(len(g.keys)),
+		RoundLength:   100,
+	}
+}
+
+func (g *nsIntf) CRS(round uint64) common.Hash {
+	return common.NewRandomHash()
+}
+
+func (g *nsIntf) NodeSet(round uint64) []crypto.PublicKey {
+	return g.keys
+}
+
+type NetworkTestSuite struct {
+	suite.Suite
+}
+
+func (s *NetworkTestSuite) newKeys(count int) (prvKeys []crypto.PrivateKey,
+	pubKeys []crypto.PublicKey) {
+	for i := 0; i < count; i++ {
+		prvKey, err := ecdsa.NewPrivateKey()
+		s.Require().NoError(err)
+		prvKeys = append(prvKeys, prvKey)
+		pubKeys = append(pubKeys, prvKey.PublicKey())
+	}
+	return
+}
+
+// setupNetworks starts one network for each key, each network bootstraps from
+// the previous one only.
+func (s *NetworkTestSuite) setupNetworks(prvKeys []crypto.PrivateKey,
+	pubKeys []crypto.PublicKey) (nets []*Network) {
+	gov := &nsIntf{keys: pubKeys}
+	for i, prvKey := range prvKeys {
+		config := Config{
+			ListenAddr:   "127.0.0.1:0",
+			DialInterval: 100 * time.Millisecond,
+		}
+		if i > 0 {
+			config.Bootstrap = []string{nets[i-1].Addr()}
+		}
+		n := NewNetwork(prvKey, gov, config, &common.NullLogger{})
+		s.Require().NoError(n.Start())
+		nets = append(nets, n)
+	}
+	// Wait until all networks are fully connected.
+	s.Require().True(s.waitFor(func() bool {
+		for _, n := range nets {
+			if len(n.Peers()) != len(nets)-1 {
+				return false
+			}
+		}
+		return true
+	}))
+	return
+}
+
+func (s *NetworkTestSuite) closeNetworks(nets []*Network) {
+	for _, n := range nets {
+		s.Require().NoError(n.Close())
+	}
+}
+
+func (s *NetworkTestSuite) waitFor(check func() bool) bool {
+	for i := 0; i < 100; i++ {
+		if check() {
+			return true
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return false
+}
+
+func (s *NetworkTestSuite) receive(n *Network) interface{} {
+	select {
+	case msg := <-n.ReceiveChan():
+		return msg.Payload
+	case <-time.After(5 * time.Second):
+		s.FailNow("timeout")
+	}
+	return nil
+}
+
+func (s *NetworkTestSuite) noMoreMsg(n *Network) {
+	select {
+	case msg := <-n.ReceiveChan():
+		s.FailNow("unexpected message", "%v", msg.Payload)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+// handshakePair runs handshake on both ends of a loopback TCP connection.
+func (s *NetworkTestSuite) handshakePair(
+	serverKey, clientKey crypto.PrivateKey,
+	allow func(types.NodeID) bool) (serverErr, clientErr error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	s.Require().NoError(err)
+	defer ln.Close()
+	errChan := make(chan error, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			errChan <- err
+			return
+		}
+		defer conn.Close()
+		_, err = handshake(conn, serverKey, "", allow)
+		errChan <- err
+	}()
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	s.Require().NoError(err)
+	_, clientErr = handshake(conn, clientKey, "", nil)
+	conn.Close()
+	serverErr = <-errChan
+	return
+}
+
+func (s *NetworkTestSuite) TestHandshake() {
+	prvKeys, pubKeys := s.newKeys(3)
+	allowed := map[types.NodeID]struct{}{
+		types.NewNodeID(pubKeys[0]): struct{}{},
+		types.NewNodeID(pubKeys[1]): struct{}{},
+	}
+	allow := func(nID types.NodeID) bool {
+		_, exists := allowed[nID]
+		return exists
+	}
+	serverErr, clientErr := s.handshakePair(prvKeys[0], prvKeys[1], allow)
+	s.Require().NoError(serverErr)
+	s.Require().NoError(clientErr)
+	// Nodes not in node sets are rejected.
+	serverErr, _ = s.handshakePair(prvKeys[0], prvKeys[2], allow)
+	s.Require().Equal(secure.ErrPeerNotAllowed, serverErr)
+	// Connect to self.
+	serverErr, _ = s.handshakePair(prvKeys[0], prvKeys[0], nil)
+	s.Require().Equal(secure.ErrSelfConnection, serverErr)
+}
+
+func (s *NetworkTestSuite) TestGossip() {
+	prvKeys, pubKeys := s.newKeys(4)
+	nets := s.setupNetworks(prvKeys, pubKeys)
+	defer s.closeNetworks(nets)
+	vote := types.NewVote(types.VoteCom, common.NewRandomHash(), 1)
+	vote.Position = types.Position{Round: 0, Height: 1}
+	sig, err := prvKeys[0].Sign(common.NewRandomHash())
+	s.Require().NoError(err)
+	vote.PartialSignature = cryptoDKG.PartialSignature(sig)
+	s.Require().NoError(utils.NewSigner(prvKeys[0]).SignVote(vote))
+	nets[0].BroadcastVote(vote)
+	for _, n := range nets[1:] {
+		msg := s.receive(n)
+		s.Require().Equal(vote, msg)
+	}
+	// Relayed votes are dropped by dedup cache.
+	for _, n := range nets {
+		s.noMoreMsg(n)
+	}
+	// Votes are cached for pulling.
+	nets[3].PullVotes(vote.Position)
+	s.Require().Equal(vote, s.receive(nets[3]))
+}
+
+func (s *NetworkTestSuite) TestPullBlocks() {
+	prvKeys, pubKeys := s.newKeys(2)
+	nets := s.setupNetworks(prvKeys, pubKeys)
+	defer s.closeNetworks(nets)
+	block := &types.Block{
+		Position:  types.Position{Height: 1},
+		Timestamp: time.Now().UTC(),
+	}
+	s.Require().NoError(utils.NewSigner(prvKeys[0]).SignBlock(block))
+	nets[0].BroadcastBlock(block)
+	s.Require().Equal(block.Hash, s.receive(nets[1]).(*types.Block).Hash)
+	nets[1].PullBlocks(common.Hashes{block.Hash})
+	s.Require().Equal(block.Hash, s.receive(nets[1]).(*types.Block).Hash)
+	s.noMoreMsg(nets[0])
+}
+
+func (s *NetworkTestSuite) TestForgedMessages() {
+	prvKeys, pubKeys := s.newKeys(3)
+	nets := s.setupNetworks(prvKeys, pubKeys)
+	defer s.closeNetworks(nets)
+	signer := utils.NewSigner(prvKeys[0])
+	block := &types.Block{
+		Position:  types.Position{Height: 1},
+		Timestamp: time.Now().UTC(),
+	}
+	s.Require().NoError(signer.SignBlock(block))
+	vote := types.NewVote(types.VoteCom, block.Hash, 1)
+	s.Require().NoError(signer.SignVote(vote))
+	// Forged messages are neither delivered nor relayed, and the genuine
+	// ones are still accepted afterward.
+	forgedBlock := block.Clone()
+	forgedBlock.Position.Height++
+	forgedVote := vote.Clone()
+	forgedVote.Period++
+	for _, msg := range []interface{}{forgedBlock, forgedVote} {
+		nets[0].gossip(msg)
+		s.noMoreMsg(nets[1])
+		s.noMoreMsg(nets[2])
+		s.Require().True(s.waitFor(func() bool {
+			return len(nets[1].Peers()) == 2
+		}))
+	}
+	nets[0].BroadcastBlock(block)
+	nets[0].BroadcastVote(vote)
+	for _, n := range nets[1:] {
+		s.Require().Equal(block.Hash, s.receive(n).(*types.Block).Hash)
+		s.Require().Equal(utils.HashVote(vote),
+			utils.HashVote(s.receive(n).(*types.Vote)))
+	}
+	// Blocks cached are not replaced by copies with randomness forged.
+	block.Randomness = []byte{1}
+	nets[0].BroadcastBlock(block)
+	for _, n := range nets[1:] {
+		s.receive(n)
+	}
+	forgedBlock = block.Clone()
+	forgedBlock.Randomness = []byte{2}
+	nets[0].gossip(forgedBlock)
+	for _, n := range nets[1:] {
+		s.receive(n)
+	}
+	nets[2].PullBlocks(common.Hashes{block.Hash})
+	s.Require().Equal(block.Randomness,
+		s.receive(nets[2]).(*types.Block).Randomness)
+}
+
+func (s *NetworkTestSuite) TestDKG() {
+	prvKeys, pubKeys := s.newKeys(3)
+	nets := s.setupNetworks(prvKeys, pubKeys)
+	defer s.closeNetworks(nets)
+	prvShare := &typesDKG.PrivateShare{
+		ProposerID: nets[0].ID,
+		ReceiverID: nets[1].ID,
+		Round:      1,
+	}
+	nets[0].SendDKGPrivateShare(pubKeys[1], prvShare)
+	recv := s.receive(nets[1]).(*typesDKG.PrivateShare)
+	s.Require().Equal(prvShare.ProposerID, recv.ProposerID)
+	s.Require().Equal(prvShare.ReceiverID, recv.ReceiverID)
+	s.Require().Equal(prvShare.Round, recv.Round)
+	s.noMoreMsg(nets[2])
+	psig := &typesDKG.PartialSignature{ProposerID: nets[0].ID, Round: 1}
+	nets[0].BroadcastDKGPartialSignature(psig)
+	for _, n := range nets[1:] {
+		s.Require().Equal(psig.ProposerID,
+			s.receive(n).(*typesDKG.PartialSignature).ProposerID)
+	}
+}
+
+func (s *NetworkTestSuite) TestBadPeer() {
+	prvKeys, pubKeys := s.newKeys(3)
+	nets := s.setupNetworks(prvKeys, pubKeys)
+	defer s.closeNetworks(nets)
+	nets[0].ReportBadPeerChan() <- nets[1].ID
+	nets[0].BadPeerReportChan() <- &types.BadPeerReport{
+		PeerID: nets[2].ID,
+		Reason: errors.New("bad"),
+	}
+	s.Require().True(s.waitFor(func() bool {
+		return len(nets[0].Peers()) == 0
+	}))
+	// Reported peers are refused when they reconnect.
+	time.Sleep(500 * time.Millisecond)
+	s.Require().Empty(nets[0].Peers())
+}
+
+func TestNetwork(t *testing.T) {
+	suite.Run(t, new(NetworkTestSuite))
+}
@@ -0,0 +1,119 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"net"
+	"sync"
+
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	"github.com/dexon-foundation/dexon-consensus/network/secure"
+)
+
+const peerSendQueueSize = 1024
+
+type frame struct {
+	kind    frameKind
+	payload []byte
+}
+
+// peer is an authenticated connection to another node.
+type peer struct {
+	ID     types.NodeID
+	pubKey crypto.PublicKey
+	// listenAddr is the address announced by the peer in handshake.
+	listenAddr string
+	// outbound is true when the connection is dialed by us.
+	outbound  bool
+	conn      net.Conn
+	session   *secure.Session
+	sendQueue chan frame
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newPeer(conn net.Conn, res *secure.Peer, outbound bool) *peer {
+	return &peer{
+		ID:         res.ID,
+		pubKey:     res.PubKey,
+		listenAddr: string(res.Info),
+		outbound:   outbound,
+		conn:       conn,
+		session:    res.Session,
+		sendQueue:  make(chan frame, peerSendQueueSize),
+		done:       make(chan struct{}),
+	}
+}
+
+// send queues a frame, it returns false when the queue is full or the peer is
+// closed. Frames are dropped instead of blocking callers on slow peers.
+func (p *peer) send(kind frameKind, payload []byte) bool {
+	select {
+	case <-p.done:
+		return false
+	default:
+	}
+	select {
+	case p.sendQueue <- frame{kind: kind, payload: payload}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *peer) close() {
+	p.closeOnce.Do(func() {
+		close(p.done)
+		p.conn.Close()
+	})
+}
+
+// writeLoop writes queued frames until the peer is closed.
+func (p *peer) writeLoop() {
+	defer p.close()
+	for {
+		select {
+		case <-p.done:
+			return
+		case f := <-p.sendQueue:
+			if err := p.session.WriteMsg(p.conn,
+				append([]byte{byte(f.kind)}, f.payload...)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readLoop reads frames and passes them to handle until the connection is
+// broken or handle returns an error.
+func (p *peer) readLoop(handle func(*peer, frameKind, []byte) error) error {
+	defer p.close()
+	for {
+		b, err := p.session.ReadMsg(p.conn)
+		if err != nil {
+			return err
+		}
+		if len(b) == 0 {
+			return ErrEmptyFrame
+		}
+		if err = handle(p, frameKind(b[0]), b[1:]); err != nil {
+			return err
+		}
+	}
+}
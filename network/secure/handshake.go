@@ -0,0 +1,249 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package secure authenticates both sides of a connection by their node keys
+// and encrypts messages between them by session keys negotiated in the
+// handshake. It's shared by TCP based transports.
+package secure
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/curve25519"
+
+	"github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+const (
+	// MaxHandshakeMsgSize limits messages read before the peer is
+	// authenticated.
+	MaxHandshakeMsgSize = 4096
+	// nonceSize is the size of challenges in handshake.
+	nonceSize       = 32
+	handshakeDomain = "DEXON consensus secure handshake"
+)
+
+var (
+	// ErrMsgTooLarge means the size of a message exceeds the limit.
+	ErrMsgTooLarge = errors.New("message too large")
+	// ErrVersionMismatch means the peer speaks another protocol version.
+	ErrVersionMismatch = errors.New("protocol version mismatch")
+	// ErrInvalidHandshake means the handshake message from the peer is
+	// malformed.
+	ErrInvalidHandshake = errors.New("invalid handshake")
+	// ErrAuthenticationFailed means the peer can't prove it holds the private
+	// key of the public key it claims.
+	ErrAuthenticationFailed = errors.New("authentication failed")
+	// ErrSelfConnection means a node connects to itself.
+	ErrSelfConnection = errors.New("connect to self")
+	// ErrPeerNotAllowed means the peer is refused by Config.Allow.
+	ErrPeerNotAllowed = errors.New("peer not allowed")
+	// ErrDecryptFailed means a message can't be opened by session keys, it's
+	// either tampered or out of order.
+	ErrDecryptFailed = errors.New("message decrypt failed")
+)
+
+// Config is the configuration of Handshake.
+type Config struct {
+	// Version is the protocol version of the caller, peers of other versions
+	// are rejected.
+	Version uint32
+	// Info is sent to the peer along with the public key, ex. the address
+	// this node listens on. It's not encrypted.
+	Info []byte
+	// Allow is called to check if the authenticated peer is welcome,
+	// everyone is welcome when it's nil.
+	Allow func(types.NodeID) bool
+	// AllowSelf accepts connections to the node itself, ex. to check if a
+	// listener is its own.
+	AllowSelf bool
+	// MaxMsgSize limits messages sent and received in the session.
+	MaxMsgSize int
+}
+
+// Peer is what we know about the other side after handshake.
+type Peer struct {
+	ID      types.NodeID
+	PubKey  crypto.PublicKey
+	Info    []byte
+	Session *Session
+}
+
+// helloMsg is the first message in handshake. EphemeralKey is a X25519
+// public key to derive session keys, Nonce is the challenge to be signed by
+// the other side.
+type helloMsg struct {
+	Version      uint32 `json:"version"`
+	PubKey       []byte `json:"pubkey"`
+	EphemeralKey []byte `json:"ephemeral"`
+	Info         []byte `json:"info"`
+	Nonce        []byte `json:"nonce"`
+}
+
+type authMsg struct {
+	Signature crypto.Signature `json:"sig"`
+}
+
+// hashChallenge returns the hash a node signs to prove it holds the private
+// key of pubKey. The nonce is chosen by the other side. The ephemeral key is
+// signed along with the nonce, so it can't be replaced by others.
+func hashChallenge(nonce, ephemeralKey, pubKey []byte) common.Hash {
+	return crypto.Keccak256Hash(
+		[]byte(handshakeDomain), nonce, ephemeralKey, pubKey)
+}
+
+// Handshake authenticates both sides of a connection and negotiates session
+// keys. It's symmetric, each side sends its public key, a X25519 ephemeral key
+// and a random nonce, signs the nonce from the other side along with its own
+// ephemeral key, and verifies the signature from the other side with the
+// claimed public key. Deadlines of the connection are left to callers.
+func Handshake(rw io.ReadWriter, prvKey crypto.PrivateKey, config Config) (
+	peer *Peer, err error) {
+	var ephemeralPrvKey, ephemeralPubKey, peerEphemeralKey, secret [32]byte
+	if _, err = rand.Read(ephemeralPrvKey[:]); err != nil {
+		return
+	}
+	curve25519.ScalarBaseMult(&ephemeralPubKey, &ephemeralPrvKey)
+	nonce := make([]byte, nonceSize)
+	if _, err = rand.Read(nonce); err != nil {
+		return
+	}
+	pubKeyBytes := prvKey.PublicKey().Bytes()
+	if err = writeJSON(rw, &helloMsg{
+		Version:      config.Version,
+		PubKey:       pubKeyBytes,
+		EphemeralKey: ephemeralPubKey[:],
+		Info:         config.Info,
+		Nonce:        nonce,
+	}); err != nil {
+		return
+	}
+	hello := &helloMsg{}
+	if err = readJSON(rw, hello); err != nil {
+		return
+	}
+	if hello.Version != config.Version {
+		err = ErrVersionMismatch
+		return
+	}
+	if len(hello.Nonce) != nonceSize ||
+		len(hello.EphemeralKey) != len(peerEphemeralKey) {
+		err = ErrInvalidHandshake
+		return
+	}
+	peerKey, err := ecdsa.NewPublicKeyFromByteSlice(hello.PubKey)
+	if err != nil {
+		err = ErrInvalidHandshake
+		return
+	}
+	peerID := types.NewNodeID(peerKey)
+	if !config.AllowSelf && peerID == types.NewNodeID(prvKey.PublicKey()) {
+		err = ErrSelfConnection
+		return
+	}
+	sig, err := prvKey.Sign(
+		hashChallenge(hello.Nonce, ephemeralPubKey[:], pubKeyBytes))
+	if err != nil {
+		return
+	}
+	if err = writeJSON(rw, &authMsg{Signature: sig}); err != nil {
+		return
+	}
+	auth := &authMsg{}
+	if err = readJSON(rw, auth); err != nil {
+		return
+	}
+	if !peerKey.VerifySignature(hashChallenge(
+		nonce, hello.EphemeralKey, hello.PubKey), auth.Signature) {
+		err = ErrAuthenticationFailed
+		return
+	}
+	if config.Allow != nil && !config.Allow(peerID) {
+		err = ErrPeerNotAllowed
+		return
+	}
+	copy(peerEphemeralKey[:], hello.EphemeralKey)
+	curve25519.ScalarMult(&secret, &ephemeralPrvKey, &peerEphemeralKey)
+	// Low order points result in an all zero secret.
+	if secret == [32]byte{} {
+		err = ErrInvalidHandshake
+		return
+	}
+	session, err := newSession(secret[:], ephemeralPubKey[:],
+		hello.EphemeralKey, config.MaxMsgSize)
+	if err != nil {
+		return
+	}
+	peer = &Peer{
+		ID:      peerID,
+		PubKey:  peerKey,
+		Info:    hello.Info,
+		Session: session,
+	}
+	return
+}
+
+// writeMsg writes a message prefixed by its size in big-endian.
+func writeMsg(w io.Writer, b []byte) error {
+	buf := make([]byte, 4+len(b))
+	binary.BigEndian.PutUint32(buf, uint32(len(b)))
+	copy(buf[4:], b)
+	_, err := w.Write(buf)
+	return err
+}
+
+// readMsg reads a message written by writeMsg with size no more than limit.
+func readMsg(r io.Reader, limit int) (b []byte, err error) {
+	header := make([]byte, 4)
+	if _, err = io.ReadFull(r, header); err != nil {
+		return
+	}
+	size := binary.BigEndian.Uint32(header)
+	if uint64(size) > uint64(limit) {
+		err = ErrMsgTooLarge
+		return
+	}
+	b = make([]byte, size)
+	_, err = io.ReadFull(r, b)
+	return
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return writeMsg(w, b)
+}
+
+func readJSON(r io.Reader, v interface{}) error {
+	b, err := readMsg(r, MaxHandshakeMsgSize)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(b, v); err != nil {
+		return ErrInvalidHandshake
+	}
+	return nil
+}
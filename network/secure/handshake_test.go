@@ -0,0 +1,231 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package secure
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+const testMaxMsgSize = 1024
+
+type HandshakeTestSuite struct {
+	suite.Suite
+}
+
+func (s *HandshakeTestSuite) newKeys(count int) (prvKeys []crypto.PrivateKey) {
+	for i := 0; i < count; i++ {
+		prvKey, err := ecdsa.NewPrivateKey()
+		s.Require().NoError(err)
+		prvKeys = append(prvKeys, prvKey)
+	}
+	return
+}
+
+func (s *HandshakeTestSuite) newConfig(
+	allow func(types.NodeID) bool) Config {
+	return Config{
+		Version:    1,
+		Info:       []byte("info"),
+		Allow:      allow,
+		MaxMsgSize: testMaxMsgSize,
+	}
+}
+
+type accepted struct {
+	conn net.Conn
+	peer *Peer
+	err  error
+}
+
+// serve runs handshake with config on the server side of a loopback TCP
+// connection, the client side connection is returned.
+func (s *HandshakeTestSuite) serve(prvKey crypto.PrivateKey,
+	config Config) (net.Conn, <-chan accepted) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	s.Require().NoError(err)
+	acceptChan := make(chan accepted, 1)
+	go func() {
+		defer ln.Close()
+		conn, err := ln.Accept()
+		if err != nil {
+			acceptChan <- accepted{err: err}
+			return
+		}
+		peer, err := Handshake(conn, prvKey, config)
+		acceptChan <- accepted{conn: conn, peer: peer, err: err}
+	}()
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	s.Require().NoError(err)
+	return conn, acceptChan
+}
+
+func (s *HandshakeTestSuite) TestHandshake() {
+	prvKeys := s.newKeys(3)
+	allowed := map[types.NodeID]struct{}{
+		types.NewNodeID(prvKeys[0].PublicKey()): struct{}{},
+		types.NewNodeID(prvKeys[1].PublicKey()): struct{}{},
+	}
+	allow := func(nID types.NodeID) bool {
+		_, exists := allowed[nID]
+		return exists
+	}
+	conn, acceptChan := s.serve(prvKeys[0], s.newConfig(allow))
+	peer, err := Handshake(conn, prvKeys[1], s.newConfig(nil))
+	conn.Close()
+	s.Require().NoError(err)
+	s.Require().Equal(types.NewNodeID(prvKeys[0].PublicKey()), peer.ID)
+	s.Require().Equal([]byte("info"), peer.Info)
+	server := <-acceptChan
+	s.Require().NoError(server.err)
+	server.conn.Close()
+	s.Require().Equal(types.NewNodeID(prvKeys[1].PublicKey()), server.peer.ID)
+	// Nodes not allowed are rejected.
+	conn, acceptChan = s.serve(prvKeys[0], s.newConfig(allow))
+	Handshake(conn, prvKeys[2], s.newConfig(nil))
+	conn.Close()
+	s.Require().Equal(ErrPeerNotAllowed, (<-acceptChan).err)
+	// Connect to self.
+	conn, acceptChan = s.serve(prvKeys[0], s.newConfig(nil))
+	Handshake(conn, prvKeys[0], s.newConfig(nil))
+	conn.Close()
+	s.Require().Equal(ErrSelfConnection, (<-acceptChan).err)
+	config := s.newConfig(nil)
+	config.AllowSelf = true
+	conn, acceptChan = s.serve(prvKeys[0], config)
+	peer, err = Handshake(conn, prvKeys[0], config)
+	conn.Close()
+	s.Require().NoError(err)
+	s.Require().Equal(types.NewNodeID(prvKeys[0].PublicKey()), peer.ID)
+	server = <-acceptChan
+	s.Require().NoError(server.err)
+	server.conn.Close()
+}
+
+func (s *HandshakeTestSuite) TestImpersonation() {
+	prvKeys := s.newKeys(3)
+	conn, acceptChan := s.serve(prvKeys[0], s.newConfig(nil))
+	defer conn.Close()
+	// Claim to be prvKeys[1], but sign with prvKeys[2].
+	ephemeralKey := make([]byte, 32)
+	s.Require().NoError(writeJSON(conn, &helloMsg{
+		Version:      1,
+		PubKey:       prvKeys[1].PublicKey().Bytes(),
+		EphemeralKey: ephemeralKey,
+		Nonce:        make([]byte, nonceSize),
+	}))
+	hello := &helloMsg{}
+	s.Require().NoError(readJSON(conn, hello))
+	sig, err := prvKeys[2].Sign(hashChallenge(
+		hello.Nonce, ephemeralKey, prvKeys[1].PublicKey().Bytes()))
+	s.Require().NoError(err)
+	s.Require().NoError(writeJSON(conn, &authMsg{Signature: sig}))
+	s.Require().Equal(ErrAuthenticationFailed, (<-acceptChan).err)
+}
+
+func (s *HandshakeTestSuite) TestInvalidHello() {
+	prvKeys := s.newKeys(2)
+	// Versions mismatch.
+	conn, acceptChan := s.serve(prvKeys[0], s.newConfig(nil))
+	s.Require().NoError(writeJSON(conn, &helloMsg{
+		Version: 2,
+		PubKey:  prvKeys[1].PublicKey().Bytes(),
+		Nonce:   make([]byte, nonceSize),
+	}))
+	s.Require().Equal(ErrVersionMismatch, (<-acceptChan).err)
+	conn.Close()
+	// Ephemeral key is missing.
+	conn, acceptChan = s.serve(prvKeys[0], s.newConfig(nil))
+	s.Require().NoError(writeJSON(conn, &helloMsg{
+		Version: 1,
+		PubKey:  prvKeys[1].PublicKey().Bytes(),
+		Nonce:   make([]byte, nonceSize),
+	}))
+	s.Require().Equal(ErrInvalidHandshake, (<-acceptChan).err)
+	conn.Close()
+	// Messages larger than MaxHandshakeMsgSize are refused before the peer
+	// is authenticated.
+	conn, acceptChan = s.serve(prvKeys[0], s.newConfig(nil))
+	s.Require().NoError(writeMsg(conn, make([]byte, MaxHandshakeMsgSize+1)))
+	s.Require().Equal(ErrMsgTooLarge, (<-acceptChan).err)
+	conn.Close()
+}
+
+func (s *HandshakeTestSuite) TestSession() {
+	prvKeys := s.newKeys(2)
+	conn, acceptChan := s.serve(prvKeys[0], s.newConfig(nil))
+	defer conn.Close()
+	peer, err := Handshake(conn, prvKeys[1], s.newConfig(nil))
+	s.Require().NoError(err)
+	server := <-acceptChan
+	s.Require().NoError(server.err)
+	defer server.conn.Close()
+	// Messages are decrypted by the other side in both directions.
+	msg := []byte("message")
+	s.Require().NoError(peer.Session.WriteMsg(conn, msg))
+	received, err := server.peer.Session.ReadMsg(server.conn)
+	s.Require().NoError(err)
+	s.Require().Equal(msg, received)
+	s.Require().NoError(server.peer.Session.WriteMsg(server.conn, msg))
+	received, err = peer.Session.ReadMsg(conn)
+	s.Require().NoError(err)
+	s.Require().Equal(msg, received)
+	// Messages larger than the limit are neither sent nor read.
+	s.Require().Equal(ErrMsgTooLarge,
+		peer.Session.WriteMsg(conn, make([]byte, testMaxMsgSize+1)))
+	s.Require().NoError(writeMsg(conn, make([]byte, 2*testMaxMsgSize)))
+	_, err = server.peer.Session.ReadMsg(server.conn)
+	s.Require().Equal(ErrMsgTooLarge, err)
+}
+
+func (s *HandshakeTestSuite) TestTamperedMessage() {
+	prvKeys := s.newKeys(2)
+	conn, acceptChan := s.serve(prvKeys[0], s.newConfig(nil))
+	defer conn.Close()
+	peer, err := Handshake(conn, prvKeys[1], s.newConfig(nil))
+	s.Require().NoError(err)
+	server := <-acceptChan
+	s.Require().NoError(server.err)
+	defer server.conn.Close()
+	// Plaintext messages are rejected after handshake.
+	s.Require().NoError(writeMsg(conn, make([]byte, 32)))
+	_, err = server.peer.Session.ReadMsg(server.conn)
+	s.Require().Equal(ErrDecryptFailed, err)
+	// Replayed messages are rejected.
+	buf := &bytes.Buffer{}
+	s.Require().NoError(peer.Session.WriteMsg(buf, []byte("message")))
+	sealed := buf.Bytes()
+	_, err = conn.Write(append(append([]byte{}, sealed...), sealed...))
+	s.Require().NoError(err)
+	received, err := server.peer.Session.ReadMsg(server.conn)
+	s.Require().NoError(err)
+	s.Require().Equal([]byte("message"), received)
+	_, err = server.peer.Session.ReadMsg(server.conn)
+	s.Require().Equal(ErrDecryptFailed, err)
+}
+
+func TestHandshake(t *testing.T) {
+	suite.Run(t, new(HandshakeTestSuite))
+}
@@ -0,0 +1,96 @@
+// Copyright 2019 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package secure
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+)
+
+// Session encrypts messages after handshake. Each direction has its own key,
+// and messages are numbered by a counter used as the AEAD nonce, so they
+// can't be replayed or reordered. Writing and reading could be done in
+// different goroutines, but neither is safe for concurrent use.
+type Session struct {
+	sendAEAD   cipher.AEAD
+	recvAEAD   cipher.AEAD
+	sendCount  uint64
+	recvCount  uint64
+	maxMsgSize int
+}
+
+func newSession(secret, ephemeralKey, peerEphemeralKey []byte,
+	maxMsgSize int) (*Session, error) {
+	// The key for messages sent by a node is derived from its ephemeral key,
+	// so both sides agree on keys of each direction without roles.
+	deriveAEAD := func(senderKey []byte) (cipher.AEAD, error) {
+		key := crypto.Keccak256Hash(
+			[]byte(handshakeDomain), secret, senderKey)
+		return chacha20poly1305.New(key[:])
+	}
+	sendAEAD, err := deriveAEAD(ephemeralKey)
+	if err != nil {
+		return nil, err
+	}
+	recvAEAD, err := deriveAEAD(peerEphemeralKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{
+		sendAEAD:   sendAEAD,
+		recvAEAD:   recvAEAD,
+		maxMsgSize: maxMsgSize,
+	}, nil
+}
+
+func sessionNonce(count uint64) []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], count)
+	return nonce
+}
+
+// WriteMsg encrypts and writes a message.
+func (s *Session) WriteMsg(w io.Writer, b []byte) error {
+	if len(b) > s.maxMsgSize {
+		return ErrMsgTooLarge
+	}
+	sealed := s.sendAEAD.Seal(nil, sessionNonce(s.sendCount), b, nil)
+	s.sendCount++
+	return writeMsg(w, sealed)
+}
+
+// ReadMsg reads and decrypts a message, messages larger than the limit are
+// rejected before read.
+func (s *Session) ReadMsg(r io.Reader) (b []byte, err error) {
+	sealed, err := readMsg(r, s.maxMsgSize+chacha20poly1305.Overhead)
+	if err != nil {
+		return
+	}
+	if b, err = s.recvAEAD.Open(
+		sealed[:0], sessionNonce(s.recvCount), sealed, nil); err != nil {
+		err = ErrDecryptFailed
+		return
+	}
+	s.recvCount++
+	return
+}
@@ -51,6 +51,8 @@ type Node struct {
 	Num       uint32
 	MaxBlock  uint64
 	Changes   []Change
+	// DB is the type of database backend, could be "memory" or "log".
+	DB string
 }
 
 // LatencyModel for ths simulation.
@@ -125,6 +127,7 @@ func GenerateDefault(path string) error {
 			},
 			Num:      7,
 			MaxBlock: math.MaxUint64,
+			DB:       "memory",
 		},
 		Networking: Networking{
 			Type:       test.NetworkTypeTCPLocal,
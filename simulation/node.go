@@ -70,7 +70,7 @@ type node struct {
 func newNode(prvKey crypto.PrivateKey, logger common.Logger,
 	cfg config.Config) *node {
 	pubKey := prvKey.PublicKey()
-	netModule := test.NewNetwork(pubKey, test.NetworkConfig{
+	netModule := test.NewNetwork(prvKey, test.NetworkConfig{
 		Type:       cfg.Networking.Type,
 		PeerServer: cfg.Networking.PeerServer,
 		PeerPort:   peerPort,
@@ -82,9 +82,19 @@ func newNode(prvKey crypto.PrivateKey, logger common.Logger,
 			Mean:  cfg.Networking.Gossip.Mean,
 			Sigma: cfg.Networking.Gossip.Sigma,
 		},
-		Marshaller: test.NewDefaultMarshaller(&jsonMarshaller{})})
+		Marshaller: test.NewDefaultMarshaller(&jsonMarshaller{}),
+		Logger:     logger})
 	id := types.NewNodeID(pubKey)
-	dbInst, err := db.NewMemBackedDB(id.String() + ".db")
+	var (
+		dbInst db.Database
+		err    error
+	)
+	switch cfg.Node.DB {
+	case "log":
+		dbInst, err = db.NewLogBackedDB(id.String() + ".log")
+	default:
+		dbInst, err = db.NewMemBackedDB(id.String() + ".db")
+	}
 	if err != nil {
 		panic(err)
 	}
@@ -164,7 +174,8 @@ readyLoop:
 		}
 	}
 	// Setup Consensus.
-	n.consensus = core.NewConsensusForSimulation(
+	var err error
+	n.consensus, err = core.NewConsensusForSimulation(
 		dMoment,
 		n.app,
 		n.gov,
@@ -172,6 +183,9 @@ readyLoop:
 		n.netModule,
 		n.prvKey,
 		n.logger)
+	if err != nil {
+		panic(err)
+	}
 	go n.consensus.Run()
 
 	// Blocks forever.